@@ -2,4 +2,7 @@ module github.com/RyanOliveira00/go-compiler
 
 go 1.23.2
 
-require github.com/sanity-io/litter v1.5.5 // indirect
+require (
+	github.com/sanity-io/litter v1.5.5 // indirect
+	github.com/tetratelabs/wazero v1.10.1
+)
@@ -1,11 +1,24 @@
 package ast
 
+import "github.com/RyanOliveira00/go-compiler/src/lexer"
+
 type Stmt interface {
 	stmt()
 }
 
 type Expr interface {
 	expr()
+	Span() lexer.Span
+}
+
+// Node records where an expression was parsed from, so errors raised
+// while evaluating it can point at the source.
+type Node struct {
+	Loc lexer.Span
+}
+
+func (n Node) Span() lexer.Span {
+	return n.Loc
 }
 
 type Type interface {
@@ -6,28 +6,85 @@ import "github.com/RyanOliveira00/go-compiler/src/lexer"
 // LITERAL EXPRESSIONS
 // --------------------
 type NumberExpr struct {
+	Node
+
+	// Int is the value of an int, Value the value of a float
+	Int   int64
 	Value float64
+	// Float makes the number a float: literals written with a decimal
+	// point, such as 2.0, and constants folded from float operands.
+	Float bool
 }
 
 func (n NumberExpr) expr() {}
 
 type StringExpr struct {
+	Node
+
 	Value string
 }
 
 func (n StringExpr) expr() {}
 
 type SymbolExpr struct {
+	Node
+
 	Value string
 }
 
 func (n SymbolExpr) expr() {}
 
+type NullExpr struct {
+	Node
+}
+
+func (n NullExpr) expr() {}
+
+// [1, 2, 3]
+type ArrayLiteralExpr struct {
+	Node
+
+	Elements []Expr
+}
+
+func (a ArrayLiteralExpr) expr() {}
+
+type MapEntry struct {
+	Key   Expr
+	Value Expr
+}
+
+// { "a": 1, "b": 2 }
+type MapLiteralExpr struct {
+	Node
+
+	Entries []MapEntry
+}
+
+func (m MapLiteralExpr) expr() {}
+
+type StructLiteralField struct {
+	Name  string
+	Value Expr
+}
+
+// Point { x: 1, y: 2 }
+type StructLiteralExpr struct {
+	Node
+
+	TypeName string
+	Fields   []StructLiteralField
+}
+
+func (s StructLiteralExpr) expr() {}
+
 // --------------------
 // COMPLEX EXPRESSIONS
 // --------------------
 
 type BinaryExpr struct {
+	Node
+
 	Left     Expr
 	Operator lexer.Token
 	Right    Expr
@@ -36,7 +93,11 @@ type BinaryExpr struct {
 func (b BinaryExpr) expr() {}
 
 // -2
+// !ok
+// typeof x
 type PrefixExpr struct {
+	Node
+
 	Operator  lexer.Token
 	RightExpr Expr
 }
@@ -47,9 +108,99 @@ func (p PrefixExpr) expr() {}
 // a += 5
 // foo.bar += 5
 type AssignmentExpr struct {
+	Node
+
 	Assigne  Expr
 	Operator lexer.Token
 	Value    Expr
 }
 
 func (a AssignmentExpr) expr() {}
+
+// ok ? a : b
+type TernaryExpr struct {
+	Node
+
+	Condition  Expr
+	Consequent Expr
+	Alternate  Expr
+}
+
+func (t TernaryExpr) expr() {}
+
+// m["key"]
+type IndexExpr struct {
+	Node
+
+	Target Expr
+	Index  Expr
+}
+
+func (i IndexExpr) expr() {}
+
+// a..b, a..=b, a..b step s
+type RangeExpr struct {
+	Node
+
+	Start     Expr
+	End       Expr
+	Step      Expr // nil for the default step of 1
+	Inclusive bool
+}
+
+func (r RangeExpr) expr() {}
+
+// len(m)
+type CallExpr struct {
+	Node
+
+	Callee    Expr
+	Arguments []Expr
+}
+
+func (c CallExpr) expr() {}
+
+// p.x, p?.x
+type MemberExpr struct {
+	Node
+
+	Object   Expr
+	Property string
+	Optional bool // ?. yields null instead of failing when Object is null
+}
+
+func (m MemberExpr) expr() {}
+
+// new Reader("/tmp")
+type NewExpr struct {
+	Node
+
+	ClassName string
+	// new Box<int>(1); empty when the type arguments are inferred
+	TypeArgs  []Type
+	Arguments []Expr
+}
+
+func (n NewExpr) expr() {}
+
+// fn (x: int): int { ... } or (x: int) => x * 2. An arrow function with an
+// expression body is parsed as a body returning that expression.
+type FunctionExpr struct {
+	Node
+
+	Parameters []Parameter
+	ReturnType Type
+	Body       BlockStmt
+}
+
+func (f FunctionExpr) expr() {}
+
+// match (x) { 1 => "one", _ => "many" }
+type MatchExpr struct {
+	Node
+
+	Subject Expr
+	Arms    []MatchArm
+}
+
+func (m MatchExpr) expr() {}
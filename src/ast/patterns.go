@@ -0,0 +1,51 @@
+package ast
+
+// Pattern is the left side of a match arm.
+type Pattern interface {
+	pattern()
+}
+
+// _ matches anything
+type WildcardPattern struct{}
+
+func (w WildcardPattern) pattern() {}
+
+// n matches anything and binds it to n inside the arm
+type BindingPattern struct {
+	Name string
+}
+
+func (b BindingPattern) pattern() {}
+
+// 1, "a", -2.5 or null
+type LiteralPattern struct {
+	Value Expr
+}
+
+func (l LiteralPattern) pattern() {}
+
+// 1..10 or 1..=10
+type RangePattern struct {
+	Start     Expr
+	End       Expr
+	Inclusive bool
+}
+
+func (r RangePattern) pattern() {}
+
+// Color.Red or Shape.Circle(r). Without parentheses a variant with a
+// payload matches whatever it holds.
+type VariantPattern struct {
+	Enum     string
+	Variant  string
+	Bindings []string
+}
+
+func (v VariantPattern) pattern() {}
+
+// Color.Red | Color.Blue => expr, or => { ... } in a match statement
+type MatchArm struct {
+	Patterns []Pattern
+	Value    Expr
+	Body     *BlockStmt
+}
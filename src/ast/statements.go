@@ -38,21 +38,40 @@ type WhileStmt struct {
 
 func (w WhileStmt) stmt() {}
 
+// foreach value in xs { ... }
+// foreach key, value in m { ... }
+type ForeachStmt struct {
+	KeyName   string
+	ValueName string
+	Iterable  Expr
+	Body      BlockStmt
+}
+
+func (f ForeachStmt) stmt() {}
+
 type PrintStmt struct {
 	Expression Expr
 }
 
 func (p PrintStmt) stmt() {}
 
+// ReadStmt reads a word into the variable Target, or, when Target is a
+// string, writes it as a prompt and waits for a line it discards.
 type ReadStmt struct {
 	Target Expr
 }
 
 func (r ReadStmt) stmt() {}
 
+type Parameter struct {
+	Name string
+	Type Type
+}
+
 type FunctionDeclStmt struct {
 	Name       string
-	Parameters []string
+	TypeParams []TypeParam
+	Parameters []Parameter
 	ReturnType Type
 	Body       BlockStmt
 }
@@ -64,3 +83,75 @@ type ReturnStmt struct {
 }
 
 func (r ReturnStmt) stmt() {}
+
+type ThrowStmt struct {
+	Value Expr
+}
+
+func (t ThrowStmt) stmt() {}
+
+// try { ... } catch (e) { ... } finally { ... }, at least one of Catch and
+// Finally is set
+type TryStmt struct {
+	Body      BlockStmt
+	CatchName string
+	Catch     *BlockStmt
+	Finally   *BlockStmt
+}
+
+func (t TryStmt) stmt() {}
+
+// type Point = { x: float, y: float };
+type TypeDeclStmt struct {
+	Name string
+	Type Type
+}
+
+func (t TypeDeclStmt) stmt() {}
+
+// class Name { let field: T; fn method() { ... } }
+type ClassDeclStmt struct {
+	Name       string
+	TypeParams []TypeParam
+	Implements []string
+	Fields     []VarDeclStmt
+	Methods    []FunctionDeclStmt
+}
+
+func (c ClassDeclStmt) stmt() {}
+
+// enum Shape { Circle(float), Rect(float, float), Empty }
+type EnumDeclStmt struct {
+	Name     string
+	Variants []EnumVariant
+}
+
+func (e EnumDeclStmt) stmt() {}
+
+type EnumVariant struct {
+	Name    string
+	Payload []Type
+}
+
+// match (x) { ... } as a statement, whose arms may be blocks
+type MatchStmt struct {
+	Subject Expr
+	Arms    []MatchArm
+}
+
+func (m MatchStmt) stmt() {}
+
+// import { a, b } from "./util.lang";
+type ImportStmt struct {
+	Names []string
+	Path  string
+}
+
+func (i ImportStmt) stmt() {}
+
+// export fn add(a: int, b: int): int { ... }
+type ExportStmt struct {
+	Declaration Stmt
+}
+
+func (e ExportStmt) stmt() {}
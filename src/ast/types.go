@@ -11,3 +11,76 @@ type ArrayType struct {
 }
 
 func (t ArrayType) _type() {}
+
+type StructField struct {
+	Name string
+	Type Type
+}
+
+// { x: float, y: float }
+type StructType struct {
+	Fields []StructField
+}
+
+func (t StructType) _type() {}
+
+// fn(int, string): bool
+type FunctionType struct {
+	Params []Type
+	Return Type // nil when the function has no result
+}
+
+func (t FunctionType) _type() {}
+
+type MapType struct {
+	Key   Type // map[K]V
+	Value Type
+}
+
+func (t MapType) _type() {}
+
+// T?
+type OptionalType struct {
+	Underlying Type
+}
+
+func (t OptionalType) _type() {}
+
+// int | string
+type UnionType struct {
+	Types []Type
+}
+
+func (t UnionType) _type() {}
+
+// Box<int>
+type GenericType struct {
+	Name string
+	Args []Type
+}
+
+func (t GenericType) _type() {}
+
+// T or T: int | float in fn max<T: int | float>(a: T, b: T): T. It is a
+// Type so the interpreter can bind a type parameter's name to it.
+type TypeParam struct {
+	Name       string
+	Constraint Type // nil when T accepts any type
+}
+
+func (t TypeParam) _type() {}
+
+// read(): string in an interface
+type MethodSignature struct {
+	Name       string
+	Parameters []Parameter
+	ReturnType Type // nil when the method has no result
+}
+
+// interface Reader { fn read(): string } declares a TypeDeclStmt whose
+// type is an InterfaceType.
+type InterfaceType struct {
+	Methods []MethodSignature
+}
+
+func (t InterfaceType) _type() {}
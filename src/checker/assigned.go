@@ -0,0 +1,233 @@
+package checker
+
+import (
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+// assignments finds the variables code assigns with = or read, which
+// undo what a condition proved about them: in a loop body, for the
+// condition's next evaluation, and in a function, for any code calling
+// it.
+type assignments struct {
+	// scopes holds the names declared by the code walked so far, and
+	// function is the index of the innermost function's first scope
+	scopes   []map[string]bool
+	function int
+	// outer are the variables assigned that the walked code does not
+	// declare, byFunctions those a function assigns without declaring
+	// them itself
+	outer       map[string]bool
+	byFunctions map[string]bool
+}
+
+// assignedIn returns the assignments of body to variables declared
+// outside of it, names being declared at its start.
+func assignedIn(body []ast.Stmt, names ...string) *assignments {
+	a := &assignments{outer: make(map[string]bool), byFunctions: make(map[string]bool)}
+	a.enter(names...)
+	a.stmts(body)
+	return a
+}
+
+func (a *assignments) enter(names ...string) {
+	scope := make(map[string]bool)
+	for _, name := range names {
+		scope[name] = true
+	}
+	a.scopes = append(a.scopes, scope)
+}
+
+func (a *assignments) exit() {
+	a.scopes = a.scopes[:len(a.scopes)-1]
+}
+
+func (a *assignments) declare(name string) {
+	a.scopes[len(a.scopes)-1][name] = true
+}
+
+func (a *assignments) assign(name string) {
+	for i := len(a.scopes) - 1; i >= 0; i-- {
+		if a.scopes[i][name] {
+			if i < a.function {
+				a.byFunctions[name] = true
+			}
+			return
+		}
+	}
+	a.outer[name] = true
+	if a.function > 0 {
+		a.byFunctions[name] = true
+	}
+}
+
+func (a *assignments) block(block ast.BlockStmt, names ...string) {
+	a.enter(names...)
+	a.stmts(block.Body)
+	a.exit()
+}
+
+func (a *assignments) fn(params []ast.Parameter, body ast.BlockStmt) {
+	names := make([]string, 0, len(params))
+	for _, param := range params {
+		names = append(names, param.Name)
+	}
+	outer := a.function
+	a.function = len(a.scopes)
+	a.block(body, names...)
+	a.function = outer
+}
+
+func (a *assignments) stmts(stmts []ast.Stmt) {
+	// Functions and classes are hoisted, so they are declared from the
+	// start of the block
+	for _, stmt := range stmts {
+		switch s := unwrapExport(stmt).(type) {
+		case ast.FunctionDeclStmt:
+			a.declare(s.Name)
+		case ast.ClassDeclStmt:
+			a.declare(s.Name)
+		case ast.EnumDeclStmt:
+			a.declare(s.Name)
+		}
+	}
+	for _, stmt := range stmts {
+		a.stmt(stmt)
+	}
+}
+
+func (a *assignments) stmt(stmt ast.Stmt) {
+	switch s := stmt.(type) {
+	case ast.ExportStmt:
+		a.stmt(s.Declaration)
+	case ast.BlockStmt:
+		a.block(s)
+	case ast.ExprStmt:
+		a.expr(s.Expression)
+	case ast.VarDeclStmt:
+		a.expr(s.AssignedValue)
+		a.declare(s.VariableName)
+	case ast.IfStmt:
+		a.expr(s.Condition)
+		a.block(s.Consequence)
+		if s.Alternative != nil {
+			a.block(*s.Alternative)
+		}
+	case ast.WhileStmt:
+		a.expr(s.Condition)
+		a.block(s.Body)
+	case ast.ForeachStmt:
+		a.expr(s.Iterable)
+		a.block(s.Body, s.KeyName, s.ValueName)
+	case ast.PrintStmt:
+		a.expr(s.Expression)
+	case ast.ReadStmt:
+		if target, ok := s.Target.(ast.SymbolExpr); ok {
+			a.assign(target.Value)
+		}
+	case ast.ReturnStmt:
+		a.expr(s.Value)
+	case ast.ThrowStmt:
+		a.expr(s.Value)
+	case ast.TryStmt:
+		a.block(s.Body)
+		if s.Catch != nil {
+			a.block(*s.Catch, s.CatchName)
+		}
+		if s.Finally != nil {
+			a.block(*s.Finally)
+		}
+	case ast.FunctionDeclStmt:
+		a.fn(s.Parameters, s.Body)
+	case ast.ClassDeclStmt:
+		for _, field := range s.Fields {
+			a.expr(field.AssignedValue)
+		}
+		for _, method := range s.Methods {
+			a.fn(method.Parameters, method.Body)
+		}
+	case ast.MatchStmt:
+		a.expr(s.Subject)
+		a.arms(s.Arms)
+	case ast.ImportStmt:
+		for _, name := range s.Names {
+			a.declare(name)
+		}
+	}
+}
+
+func (a *assignments) arms(arms []ast.MatchArm) {
+	for _, arm := range arms {
+		var names []string
+		for _, pattern := range arm.Patterns {
+			switch p := pattern.(type) {
+			case ast.BindingPattern:
+				names = append(names, p.Name)
+			case ast.VariantPattern:
+				names = append(names, p.Bindings...)
+			}
+		}
+		a.enter(names...)
+		a.expr(arm.Value)
+		if arm.Body != nil {
+			a.block(*arm.Body)
+		}
+		a.exit()
+	}
+}
+
+func (a *assignments) exprs(exprs []ast.Expr) {
+	for _, expr := range exprs {
+		a.expr(expr)
+	}
+}
+
+func (a *assignments) expr(expr ast.Expr) {
+	switch e := expr.(type) {
+	case ast.AssignmentExpr:
+		a.expr(e.Assigne)
+		a.expr(e.Value)
+		if target, ok := e.Assigne.(ast.SymbolExpr); ok && e.Operator.Kind == lexer.ASSIGNMENT {
+			a.assign(target.Value)
+		}
+	case ast.ArrayLiteralExpr:
+		a.exprs(e.Elements)
+	case ast.MapLiteralExpr:
+		for _, entry := range e.Entries {
+			a.expr(entry.Key)
+			a.expr(entry.Value)
+		}
+	case ast.StructLiteralExpr:
+		for _, field := range e.Fields {
+			a.expr(field.Value)
+		}
+	case ast.BinaryExpr:
+		a.expr(e.Left)
+		a.expr(e.Right)
+	case ast.PrefixExpr:
+		a.expr(e.RightExpr)
+	case ast.TernaryExpr:
+		a.expr(e.Condition)
+		a.expr(e.Consequent)
+		a.expr(e.Alternate)
+	case ast.IndexExpr:
+		a.expr(e.Target)
+		a.expr(e.Index)
+	case ast.RangeExpr:
+		a.expr(e.Start)
+		a.expr(e.End)
+		a.expr(e.Step)
+	case ast.CallExpr:
+		a.expr(e.Callee)
+		a.exprs(e.Arguments)
+	case ast.MemberExpr:
+		a.expr(e.Object)
+	case ast.NewExpr:
+		a.exprs(e.Arguments)
+	case ast.FunctionExpr:
+		a.fn(e.Parameters, e.Body)
+	case ast.MatchExpr:
+		a.expr(e.Subject)
+		a.arms(e.Arms)
+	}
+}
@@ -0,0 +1,180 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+)
+
+var builtinNames = map[string]bool{
+	"len":     true,
+	"has":     true,
+	"delete":  true,
+	"println": true,
+	"error":   true,
+}
+
+func (c *Checker) checkCallExpr(expr ast.CallExpr) (Type, error) {
+	if member, ok := expr.Callee.(ast.MemberExpr); ok && (member.Property == "map" || member.Property == "filter") {
+		object, err := c.checkExpr(member.Object)
+		if err != nil {
+			return nil, err
+		}
+		if array, ok := object.(ArrayType); ok {
+			defer c.widenCalled()
+			return c.checkArrayMethodCall(array, member.Property, expr.Arguments)
+		}
+	}
+
+	if callee, ok := expr.Callee.(ast.SymbolExpr); ok && builtinNames[callee.Value] {
+		if _, shadowed := c.scope.lookup(callee.Value); !shadowed {
+			args, err := c.checkArguments(expr.Arguments)
+			if err != nil {
+				return nil, err
+			}
+			return checkBuiltinCall(callee.Value, args)
+		}
+	}
+
+	callee, err := c.checkExpr(expr.Callee)
+	if err != nil {
+		return nil, err
+	}
+	args, err := c.checkArguments(expr.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	// What the function assigns is known after the call, not during it
+	defer c.widenCalled()
+
+	// x?.method() is null when x is
+	if opt, ok := callee.(OptionalType); ok {
+		member, isMember := expr.Callee.(ast.MemberExpr)
+		fn, isFunction := opt.Elem.(FunctionType)
+		if !isMember || !member.Optional || !isFunction {
+			return nil, nullableError(expr.Callee, callee)
+		}
+		fn, err := instantiateCall(calleeName(expr.Callee), fn, args)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.checkCallArguments(calleeName(expr.Callee), fn, expr.Arguments, args); err != nil {
+			return nil, err
+		}
+		c.record(expr.Callee, optional(fn))
+		return optional(fn.Return), nil
+	}
+
+	switch fn := callee.(type) {
+	case FunctionType:
+		fn, err := instantiateCall(calleeName(expr.Callee), fn, args)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.checkCallArguments(calleeName(expr.Callee), fn, expr.Arguments, args); err != nil {
+			return nil, err
+		}
+		// The callee's type at this call, the signature its arguments
+		// are converted to
+		c.record(expr.Callee, fn)
+		return fn.Return, nil
+	default:
+		if callee == Any {
+			return Any, nil
+		}
+		return nil, fmt.Errorf("cannot call non-function %s", callee)
+	}
+}
+
+func calleeName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case ast.SymbolExpr:
+		return e.Value
+	case ast.MemberExpr:
+		return calleeName(e.Object) + "." + e.Property
+	default:
+		return "function"
+	}
+}
+
+func checkBuiltinCall(name string, args []Type) (Type, error) {
+	switch name {
+	case "len":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("len expects 1 argument, got %d", len(args))
+		}
+		switch args[0].(type) {
+		case MapType, ArrayType, RangeType:
+			return Int, nil
+		}
+		if args[0] != String && args[0] != Any {
+			return nil, fmt.Errorf("invalid argument for len: %s", args[0])
+		}
+		return Int, nil
+	case "has", "delete":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s expects 2 arguments, got %d", name, len(args))
+		}
+		m, ok := args[0].(MapType)
+		if !ok {
+			if args[0] == Any {
+				m = MapType{Key: Any, Value: Any}
+			} else {
+				return nil, fmt.Errorf("invalid argument for %s: %s", name, args[0])
+			}
+		}
+		if !IsAssignable(m.Key, args[1]) {
+			return nil, fmt.Errorf("cannot use %s as %s key", args[1], m)
+		}
+		if name == "has" {
+			return Bool, nil
+		}
+		return Void, nil
+	case "println":
+		return Void, nil
+	case "error":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("error expects 1 argument, got %d", len(args))
+		}
+		if !IsAssignable(String, args[0]) {
+			return nil, fmt.Errorf("cannot use %s as string in argument 1 of error", args[0])
+		}
+		return ErrorType, nil
+	default:
+		return nil, fmt.Errorf("undefined function: %s", name)
+	}
+}
+
+// checkArrayMethodCall checks xs.map(f) and xs.filter(f), whose callback
+// receives each element.
+func (c *Checker) checkArrayMethodCall(array ArrayType, method string, arguments []ast.Expr) (Type, error) {
+	args, err := c.checkArguments(arguments)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%s expects 1 argument, got %d", method, len(args))
+	}
+
+	callback, ok := args[0].(FunctionType)
+	if !ok {
+		if args[0] == Any {
+			return ArrayType{Elem: Any}, nil
+		}
+		return nil, fmt.Errorf("cannot use %s as function in argument 1 of %s", args[0], method)
+	}
+	if len(callback.Params) != 1 || !IsAssignable(callback.Params[0], array.Elem) {
+		return nil, fmt.Errorf("%s expects a fn(%s), got %s", method, array.Elem, callback)
+	}
+
+	if method == "filter" {
+		if !IsAssignable(Bool, callback.Return) {
+			return nil, fmt.Errorf("filter callback must return bool, got %s", callback.Return)
+		}
+		return array, nil
+	}
+	if callback.Return == Void {
+		return nil, fmt.Errorf("map callback must return a value")
+	}
+	return ArrayType{Elem: callback.Return}, nil
+}
@@ -0,0 +1,952 @@
+// src/checker/checker.go
+package checker
+
+import (
+	"fmt"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+type symbol struct {
+	Type       Type
+	IsConstant bool
+	// Declared is set on narrowed symbols to the type the variable was
+	// declared with, which is what assignments must respect.
+	Declared Type
+}
+
+type scope struct {
+	symbols map[string]*symbol
+	types   map[string]Type
+	outer   *scope
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{
+		symbols: make(map[string]*symbol),
+		types:   make(map[string]Type),
+		outer:   outer,
+	}
+}
+
+func (s *scope) lookupType(name string) (Type, bool) {
+	for current := s; current != nil; current = current.outer {
+		if t, exists := current.types[name]; exists {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+func (s *scope) lookup(name string) (*symbol, bool) {
+	for current := s; current != nil; current = current.outer {
+		if sym, exists := current.symbols[name]; exists {
+			return sym, true
+		}
+	}
+	return nil, false
+}
+
+// Checker validates a program before it is executed. Its global scope
+// is kept between calls to Check so the REPL can check one line at a time.
+type Checker struct {
+	// Importer resolves import statements, imports fail when it is nil
+	Importer Importer
+	// Types records the type of every expression checked, by where it was
+	// parsed from, with generic callees instantiated for their call and
+	// literals given the type expected of them. Code generators working from the AST read it to pick
+	// the operations on the values, and the interpreter to convert them.
+	Types map[lexer.Span]Type
+
+	scope    *scope
+	function *FunctionType
+	exports  map[string]Export
+	// assignedByFunctions are the variables assigned by the functions
+	// checked so far, outside of their own scopes
+	assignedByFunctions map[string]bool
+}
+
+func New() *Checker {
+	c := &Checker{
+		Types:               make(map[lexer.Span]Type),
+		scope:               newScope(nil),
+		exports:             make(map[string]Export),
+		assignedByFunctions: make(map[string]bool),
+	}
+	c.scope.types[ErrorType.Name] = ErrorType
+	return c
+}
+
+// Declare adds a predeclared constant, such as a built-in module, to the
+// global scope.
+func (c *Checker) Declare(name string, t Type) {
+	c.scope.symbols[name] = &symbol{Type: t, IsConstant: true}
+}
+
+// LookupType returns the named type visible from the global scope.
+func (c *Checker) LookupType(name string) (Type, bool) {
+	return c.scope.lookupType(name)
+}
+
+// DeclareType adds a predeclared named type to the global scope.
+func (c *Checker) DeclareType(name string, t ast.Type) error {
+	return c.checkTypeDecl(ast.TypeDeclStmt{Name: name, Type: t})
+}
+
+func (c *Checker) Check(program ast.BlockStmt) error {
+	for name := range assignedIn(program.Body).byFunctions {
+		c.assignedByFunctions[name] = true
+	}
+	if err := c.hoist(program.Body); err != nil {
+		return err
+	}
+
+	for _, stmt := range program.Body {
+		if err := c.checkTopLevelStmt(stmt); err != nil {
+			return err
+		}
+		// The global scope outlives this program, so top level guards
+		// narrow the globals themselves
+		for name, t := range c.narrowedAfter(stmt) {
+			c.scope.symbols[name] = c.narrowedScope(narrowing{name: t}).symbols[name]
+		}
+	}
+	return nil
+}
+
+func (c *Checker) checkStmt(stmt ast.Stmt) error {
+	switch s := stmt.(type) {
+	case ast.ExprStmt:
+		_, err := c.checkExpr(s.Expression)
+		return err
+	case ast.VarDeclStmt:
+		return c.checkVarDecl(s)
+	case ast.IfStmt:
+		return c.checkIf(s)
+	case ast.WhileStmt:
+		c.widenAssigned(s.Body)
+		if _, err := c.checkExpr(s.Condition); err != nil {
+			return err
+		}
+		whenTrue, _ := c.narrowings(s.Condition)
+		return c.withNarrowed(whenTrue, func() error { return c.checkBlock(s.Body) })
+	case ast.ForeachStmt:
+		return c.checkForeach(s)
+	case ast.PrintStmt:
+		_, err := c.checkExpr(s.Expression)
+		return err
+	case ast.ReadStmt:
+		return c.checkRead(s)
+	case ast.BlockStmt:
+		return c.checkBlock(s)
+	case ast.TypeDeclStmt:
+		return c.checkTypeDecl(s)
+	case ast.ReturnStmt:
+		return c.checkReturn(s)
+	case ast.ThrowStmt:
+		return c.checkThrow(s)
+	case ast.TryStmt:
+		return c.checkTry(s)
+	case ast.MatchStmt:
+		_, err := c.checkMatch(s.Subject, s.Arms, false)
+		return err
+	case ast.FunctionDeclStmt, ast.ClassDeclStmt, ast.EnumDeclStmt, ast.ImportStmt, ast.ExportStmt:
+		return fmt.Errorf("%s is only allowed at top level", declKind(s))
+	default:
+		return fmt.Errorf("unknown statement type: %T", stmt)
+	}
+}
+
+func (c *Checker) checkBlock(block ast.BlockStmt) error {
+	outer := c.scope
+	c.scope = newScope(outer)
+	defer func() { c.scope = outer }()
+
+	return c.checkStmts(block.Body)
+}
+
+func (c *Checker) checkThrow(stmt ast.ThrowStmt) error {
+	t, err := c.checkExpr(stmt.Value)
+	if err != nil {
+		return err
+	}
+	if t != String && t != Any && !Identical(t, ErrorType) {
+		return fmt.Errorf("cannot throw %s, expected string or Error", t)
+	}
+	return nil
+}
+
+func (c *Checker) checkTry(stmt ast.TryStmt) error {
+	if err := c.checkBlock(stmt.Body); err != nil {
+		return err
+	}
+
+	if stmt.Catch != nil {
+		outer := c.scope
+		c.scope = newScope(outer)
+		c.scope.symbols[stmt.CatchName] = &symbol{Type: ErrorType}
+		err := c.checkBlock(*stmt.Catch)
+		c.scope = outer
+		if err != nil {
+			return err
+		}
+	}
+
+	if stmt.Finally != nil {
+		return c.checkBlock(*stmt.Finally)
+	}
+	return nil
+}
+
+func (c *Checker) checkVarDecl(stmt ast.VarDeclStmt) error {
+	var declared Type
+	if stmt.ExplicitType != nil {
+		t, err := c.resolveType(stmt.ExplicitType)
+		if err != nil {
+			return err
+		}
+		declared = t
+	}
+
+	if stmt.AssignedValue != nil {
+		valueType, err := c.checkExpr(stmt.AssignedValue)
+		if err != nil {
+			return err
+		}
+		if valueType == Void {
+			return fmt.Errorf("%s: cannot use an expression without a value as initializer", stmt.VariableName)
+		}
+		if valueType == Null && declared == nil {
+			return fmt.Errorf("%s: cannot infer a type from null, declare it as T?", stmt.VariableName)
+		}
+		if declared == nil {
+			declared = valueType
+		} else if !c.assignable(declared, stmt.AssignedValue, valueType) {
+			return fmt.Errorf("cannot use %s as %s in declaration of %s", valueType, declared, stmt.VariableName)
+		}
+	}
+
+	c.scope.symbols[stmt.VariableName] = &symbol{
+		Type:       declared,
+		IsConstant: stmt.IsConstant,
+	}
+	return nil
+}
+
+func (c *Checker) checkTypeDecl(stmt ast.TypeDeclStmt) error {
+	if isBuiltinType(stmt.Name) {
+		return fmt.Errorf("cannot redeclare builtin type %s", stmt.Name)
+	}
+
+	// Interface methods may refer to the interface itself
+	if t, ok := stmt.Type.(ast.InterfaceType); ok {
+		iface := &InterfaceType{Name: stmt.Name, Methods: make(map[string]FunctionType)}
+		c.scope.types[stmt.Name] = iface
+		if err := c.declareInterfaceMethods(iface, t); err != nil {
+			return fmt.Errorf("%s: %w", stmt.Name, err)
+		}
+		return nil
+	}
+
+	declared, err := c.resolveType(stmt.Type)
+	if err != nil {
+		return err
+	}
+	if structType, ok := declared.(StructType); ok {
+		structType.Name = stmt.Name
+		declared = structType
+	}
+
+	c.scope.types[stmt.Name] = declared
+	return nil
+}
+
+func isBuiltinType(name string) bool {
+	switch name {
+	case "int", "float", "string", "bool", "Time", "Duration", "Error", "range":
+		return true
+	}
+	return false
+}
+
+func (c *Checker) checkIf(stmt ast.IfStmt) error {
+	if _, err := c.checkExpr(stmt.Condition); err != nil {
+		return err
+	}
+
+	whenTrue, whenFalse := c.narrowings(stmt.Condition)
+	if err := c.withNarrowed(whenTrue, func() error { return c.checkBlock(stmt.Consequence) }); err != nil {
+		return err
+	}
+	if stmt.Alternative != nil {
+		return c.withNarrowed(whenFalse, func() error { return c.checkBlock(*stmt.Alternative) })
+	}
+	return nil
+}
+
+func (c *Checker) checkForeach(stmt ast.ForeachStmt) error {
+	iterable, err := c.checkExpr(stmt.Iterable)
+	if err != nil {
+		return err
+	}
+
+	var keyType, valueType Type
+	switch t := iterable.(type) {
+	case OptionalType:
+		return nullableError(stmt.Iterable, t)
+	case MapType:
+		keyType, valueType = t.Key, t.Value
+	case ArrayType:
+		// foreach x in xs binds the element, foreach i, x in xs the index too
+		keyType, valueType = t.Elem, t.Elem
+		if stmt.ValueName != "" {
+			keyType = Int
+		}
+	case RangeType:
+		keyType, valueType = t.Elem, t.Elem
+		if stmt.ValueName != "" {
+			keyType = Int
+		}
+	default:
+		if iterable != Any {
+			return fmt.Errorf("cannot iterate over %s", iterable)
+		}
+		keyType, valueType = Any, Any
+	}
+
+	c.widenAssigned(stmt.Body, stmt.KeyName, stmt.ValueName)
+	outer := c.scope
+	c.scope = newScope(outer)
+	defer func() { c.scope = outer }()
+
+	c.scope.symbols[stmt.KeyName] = &symbol{Type: keyType}
+	if stmt.ValueName != "" {
+		c.scope.symbols[stmt.ValueName] = &symbol{Type: valueType}
+	}
+
+	return c.checkBlock(stmt.Body)
+}
+
+func (c *Checker) checkRead(stmt ast.ReadStmt) error {
+	if _, ok := stmt.Target.(ast.StringExpr); ok {
+		return nil
+	}
+	target, ok := stmt.Target.(ast.SymbolExpr)
+	if !ok {
+		return fmt.Errorf("invalid read target")
+	}
+	sym, exists := c.scope.lookup(target.Value)
+	if !exists {
+		return fmt.Errorf("undefined variable: %s", target.Value)
+	}
+	if sym.IsConstant {
+		return fmt.Errorf("cannot read into constant %s", target.Value)
+	}
+	// The input is parsed as the type the variable was declared with
+	declared := sym.Type
+	if sym.Declared != nil {
+		declared = sym.Declared
+	}
+	c.record(target, declared)
+	return nil
+}
+
+func (c *Checker) checkExpr(expr ast.Expr) (Type, error) {
+	t, err := c.exprType(expr)
+	if err == nil {
+		c.record(expr, t)
+	}
+	return t, err
+}
+
+func (c *Checker) record(expr ast.Expr, t Type) {
+	c.Types[expr.Span()] = t
+}
+
+func (c *Checker) exprType(expr ast.Expr) (Type, error) {
+	switch e := expr.(type) {
+	case ast.NumberExpr:
+		if e.Float {
+			return Float, nil
+		}
+		return Int, nil
+	case ast.StringExpr:
+		return String, nil
+	case ast.NullExpr:
+		return Null, nil
+	case ast.MapLiteralExpr:
+		return c.checkMapLiteralExpr(e)
+	case ast.ArrayLiteralExpr:
+		return c.checkArrayLiteralExpr(e)
+	case ast.StructLiteralExpr:
+		return c.checkStructLiteralExpr(e)
+	case ast.SymbolExpr:
+		if sym, exists := c.scope.lookup(e.Value); exists {
+			return sym.Type, nil
+		}
+		// The name of an enum holds its variants, as in Color.Red
+		if t, exists := c.scope.lookupType(e.Value); exists {
+			if enum, ok := t.(*EnumType); ok {
+				return enum.namespace(), nil
+			}
+		}
+		return nil, fmt.Errorf("undefined variable: %s", e.Value)
+	case ast.PrefixExpr:
+		return c.checkPrefixExpr(e)
+	case ast.BinaryExpr:
+		return c.checkBinaryExpr(e)
+	case ast.AssignmentExpr:
+		return c.checkAssignment(e)
+	case ast.TernaryExpr:
+		return c.checkTernaryExpr(e)
+	case ast.IndexExpr:
+		return c.checkIndexExpr(e)
+	case ast.RangeExpr:
+		return c.checkRangeExpr(e)
+	case ast.MemberExpr:
+		return c.checkMemberExpr(e)
+	case ast.CallExpr:
+		return c.checkCallExpr(e)
+	case ast.NewExpr:
+		return c.checkNewExpr(e)
+	case ast.FunctionExpr:
+		return c.checkFunctionExpr(e)
+	case ast.MatchExpr:
+		return c.checkMatch(e.Subject, e.Arms, true)
+	default:
+		return nil, fmt.Errorf("unknown expression type: %T", expr)
+	}
+}
+
+func (c *Checker) checkPrefixExpr(expr ast.PrefixExpr) (Type, error) {
+	right, err := c.checkExpr(expr.RightExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	switch expr.Operator.Kind {
+	case lexer.DASH, lexer.PLUS:
+		if !isNumeric(right) && !isNumericParam(right) {
+			return nil, fmt.Errorf("invalid operation: %s%s", expr.Operator.Value, right)
+		}
+		return right, nil
+	case lexer.NOT:
+		if right != Bool && right != Any {
+			return nil, fmt.Errorf("invalid operation: !%s", right)
+		}
+		return Bool, nil
+	case lexer.TYPEOF:
+		return String, nil
+	default:
+		return nil, fmt.Errorf("unknown prefix operator: %s", expr.Operator.Value)
+	}
+}
+
+func (c *Checker) checkBinaryExpr(expr ast.BinaryExpr) (Type, error) {
+	switch expr.Operator.Kind {
+	case lexer.AND, lexer.OR, lexer.NULLISH:
+		return c.checkLogicalExpr(expr)
+	}
+
+	left, err := c.checkExpr(expr.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := c.checkExpr(expr.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	mismatch := fmt.Errorf("invalid operation: %s %s %s", left, expr.Operator.Value, right)
+
+	if isTimeType(left) || isTimeType(right) {
+		if result, ok := timeOperatorType(expr.Operator.Kind, left, right); ok {
+			return result, nil
+		}
+		return nil, mismatch
+	}
+
+	switch expr.Operator.Kind {
+	case lexer.EQUALS, lexer.NOT_EQUALS, lexer.IN:
+	default:
+		if _, ok := left.(OptionalType); ok {
+			return nil, nullableError(expr.Left, left)
+		}
+		if _, ok := right.(OptionalType); ok {
+			return nil, nullableError(expr.Right, right)
+		}
+	}
+
+	result, ok := operatorType(expr.Operator.Kind, left, right)
+	if !ok {
+		result, ok = typeParamOperatorType(expr.Operator.Kind, left, right)
+	}
+	if !ok {
+		return nil, mismatch
+	}
+	return result, nil
+}
+
+// operatorType is the type of left operator right, if it is valid.
+func operatorType(operator lexer.TokenKind, left, right Type) (Type, bool) {
+	switch operator {
+	case lexer.PLUS, lexer.DASH, lexer.STAR, lexer.SLASH, lexer.PERCENT:
+		if operator == lexer.PLUS && left == String && right == String {
+			return String, true
+		}
+		if !isNumeric(left) || !isNumeric(right) {
+			return nil, false
+		}
+		if left == Int && right == Int {
+			return Int, true
+		}
+		if left == Any || right == Any {
+			return Any, true
+		}
+		return Float, true
+	case lexer.LESS, lexer.LESS_EQUALS, lexer.GREATER, lexer.GREATER_EQUALS:
+		if left == String && right == String {
+			return Bool, true
+		}
+		if !isNumeric(left) || !isNumeric(right) {
+			return nil, false
+		}
+		return Bool, true
+	case lexer.EQUALS, lexer.NOT_EQUALS:
+		if _, ok := commonType(left, right); !ok {
+			return nil, false
+		}
+		return Bool, true
+	case lexer.IN:
+		if !isMember(left, right) {
+			return nil, false
+		}
+		return Bool, true
+	default:
+		return Any, true
+	}
+}
+
+// checkLogicalExpr checks &&, || and ??, whose right operand is only
+// evaluated depending on the left one: x != null && x.y is valid.
+func (c *Checker) checkLogicalExpr(expr ast.BinaryExpr) (Type, error) {
+	left, err := c.checkExpr(expr.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	whenTrue, whenFalse := c.narrowings(expr.Left)
+	narrowed := whenTrue
+	if expr.Operator.Kind != lexer.AND {
+		narrowed = whenFalse
+	}
+
+	var right Type
+	err = c.withNarrowed(narrowed, func() error {
+		var err error
+		right, err = c.checkExpr(expr.Right)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mismatch := fmt.Errorf("invalid operation: %s %s %s", left, expr.Operator.Value, right)
+
+	if expr.Operator.Kind == lexer.NULLISH {
+		if left == Null {
+			return right, nil
+		}
+		if opt, ok := left.(OptionalType); ok {
+			left = opt.Elem
+		}
+		result, ok := commonType(left, right)
+		if !ok {
+			return nil, mismatch
+		}
+		return result, nil
+	}
+
+	if !IsAssignable(Bool, left) || !IsAssignable(Bool, right) {
+		return nil, mismatch
+	}
+	return Bool, nil
+}
+
+func (c *Checker) checkAssignment(expr ast.AssignmentExpr) (Type, error) {
+	var targetType Type
+	var targetName string
+	var narrowed *symbol
+
+	switch target := expr.Assigne.(type) {
+	case ast.SymbolExpr:
+		sym, exists := c.scope.lookup(target.Value)
+		if !exists {
+			return nil, fmt.Errorf("undefined variable: %s", target.Value)
+		}
+		if sym.IsConstant {
+			return nil, fmt.Errorf("cannot assign to constant %s", target.Value)
+		}
+		targetType, targetName = sym.Type, target.Value
+		if sym.Declared != nil {
+			targetType, narrowed = sym.Declared, sym
+		}
+	case ast.IndexExpr:
+		t, err := c.checkIndexExpr(target)
+		if err != nil {
+			return nil, err
+		}
+		targetType, targetName = t, "element"
+	case ast.MemberExpr:
+		if target.Optional {
+			return nil, fmt.Errorf("cannot assign to %s through ?.", target.Property)
+		}
+		if err := c.checkMutable(target.Object); err != nil {
+			return nil, err
+		}
+		t, err := c.checkMemberExpr(target)
+		if err != nil {
+			return nil, err
+		}
+		if _, isMethod := t.(FunctionType); isMethod {
+			return nil, fmt.Errorf("cannot assign to method %s", target.Property)
+		}
+		targetType, targetName = t, "field "+target.Property
+	default:
+		return nil, fmt.Errorf("invalid assignment target")
+	}
+
+	value, err := c.checkExpr(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if expr.Operator.Kind == lexer.NULLISH_ASSIGNMENT {
+		opt, ok := targetType.(OptionalType)
+		if !ok {
+			return nil, fmt.Errorf("invalid operation: %s ??= on non-optional %s", targetName, targetType)
+		}
+		if !c.assignable(opt, expr.Value, value) {
+			return nil, fmt.Errorf("cannot assign %s to %s (of type %s)", value, targetName, targetType)
+		}
+		if IsAssignable(opt.Elem, value) {
+			return opt.Elem, nil
+		}
+		return opt, nil
+	}
+
+	if expr.Operator.Kind != lexer.ASSIGNMENT {
+		if !isNumeric(targetType) || !isNumeric(value) {
+			if !(expr.Operator.Kind == lexer.PLUS_EQUALS && targetType == String && value == String) {
+				return nil, fmt.Errorf("invalid operation: %s %s %s", targetType, expr.Operator.Value, value)
+			}
+		}
+	}
+
+	if !c.assignable(targetType, expr.Value, value) {
+		return nil, fmt.Errorf("cannot assign %s to %s (of type %s)", value, targetName, targetType)
+	}
+	// Assigning something that may be null undoes a narrowing
+	if narrowed != nil && !IsAssignable(narrowed.Type, value) {
+		c.widen(targetName)
+	}
+	return targetType, nil
+}
+
+func (c *Checker) checkMapLiteralExpr(expr ast.MapLiteralExpr) (Type, error) {
+	var keyType, valueType Type = Any, Any
+
+	for i, entry := range expr.Entries {
+		key, err := c.checkExpr(entry.Key)
+		if err != nil {
+			return nil, err
+		}
+		if !isComparable(key) {
+			return nil, fmt.Errorf("invalid map key type: %s", key)
+		}
+		value, err := c.checkExpr(entry.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			keyType, valueType = key, value
+			continue
+		}
+
+		commonKey, ok := commonType(keyType, key)
+		if !ok {
+			return nil, fmt.Errorf("mismatched map keys: %s and %s", keyType, key)
+		}
+		commonValue, ok := commonType(valueType, value)
+		if !ok {
+			return nil, fmt.Errorf("mismatched map values: %s and %s", valueType, value)
+		}
+		keyType, valueType = commonKey, commonValue
+	}
+
+	return MapType{Key: keyType, Value: valueType}, nil
+}
+
+func (c *Checker) checkArrayLiteralExpr(expr ast.ArrayLiteralExpr) (Type, error) {
+	var elemType Type = Any
+
+	for i, element := range expr.Elements {
+		t, err := c.checkExpr(element)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			elemType = t
+			continue
+		}
+
+		// [1, "a"] is an array of int | string
+		common, ok := commonType(elemType, t)
+		if !ok {
+			common = unionOf([]Type{elemType, t})
+		}
+		elemType = common
+	}
+
+	return ArrayType{Elem: elemType}, nil
+}
+
+func (c *Checker) checkStructLiteralExpr(expr ast.StructLiteralExpr) (Type, error) {
+	declared, exists := c.scope.lookupType(expr.TypeName)
+	if !exists {
+		return nil, fmt.Errorf("unknown type: %s", expr.TypeName)
+	}
+	structType, ok := declared.(StructType)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct type", expr.TypeName)
+	}
+
+	seen := make(map[string]bool)
+	for _, field := range expr.Fields {
+		fieldType, exists := structType.Field(field.Name)
+		if !exists {
+			return nil, fmt.Errorf("unknown field %s in %s literal", field.Name, expr.TypeName)
+		}
+		if seen[field.Name] {
+			return nil, fmt.Errorf("duplicate field %s in %s literal", field.Name, expr.TypeName)
+		}
+		seen[field.Name] = true
+
+		value, err := c.checkExpr(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		if !c.assignable(fieldType, field.Value, value) {
+			return nil, fmt.Errorf("cannot use %s as %s in field %s of %s", value, fieldType, field.Name, expr.TypeName)
+		}
+	}
+
+	for _, field := range structType.Fields {
+		if !seen[field.Name] {
+			return nil, fmt.Errorf("missing field %s in %s literal", field.Name, expr.TypeName)
+		}
+	}
+
+	return structType, nil
+}
+
+func (c *Checker) checkMemberExpr(expr ast.MemberExpr) (Type, error) {
+	object, err := c.checkExpr(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	if opt, ok := object.(OptionalType); ok {
+		if !expr.Optional {
+			return nil, nullableError(expr.Object, object)
+		}
+		member, err := c.memberType(opt.Elem, expr.Property)
+		if err != nil {
+			return nil, err
+		}
+		return optional(member), nil
+	}
+	return c.memberType(object, expr.Property)
+}
+
+func (c *Checker) memberType(object Type, property string) (Type, error) {
+	switch t := object.(type) {
+	case StructType:
+		if fieldType, exists := t.Field(property); exists {
+			return fieldType, nil
+		}
+		return nil, fmt.Errorf("%s has no field %s", t, property)
+	case NamespaceType:
+		if member, exists := t.Members[property]; exists {
+			return member, nil
+		}
+		return nil, fmt.Errorf("%s has no member %s", t, property)
+	case ArrayType:
+		switch property {
+		case "push":
+			return FunctionType{Params: []Type{t.Elem}, Return: Void}, nil
+		case "map":
+			// The result type depends on the callback, checkArrayMethodCall
+			// refines it for direct calls
+			return FunctionType{Params: []Type{FunctionType{Params: []Type{t.Elem}, Return: Any}}, Return: ArrayType{Elem: Any}}, nil
+		case "filter":
+			return FunctionType{Params: []Type{FunctionType{Params: []Type{t.Elem}, Return: Bool}}, Return: t}, nil
+		}
+		return nil, fmt.Errorf("%s has no member %s", t, property)
+	case *ClassType:
+		if fieldType, exists := t.Field(property); exists {
+			return fieldType, nil
+		}
+		if method, exists := t.Method(property); exists {
+			return method, nil
+		}
+		return nil, fmt.Errorf("%s has no member %s", t, property)
+	case *InterfaceType:
+		if method, exists := t.Methods[property]; exists {
+			return method, nil
+		}
+		return nil, fmt.Errorf("%s has no method %s", t, property)
+	case *TypeParam:
+		// A T constrained to a single type has its members
+		if _, isUnion := t.Constraint.(UnionType); t.Constraint != nil && !isUnion {
+			return c.memberType(t.Constraint, property)
+		}
+		return nil, fmt.Errorf("%s has no member %s", t, property)
+	default:
+		if object == Any {
+			return Any, nil
+		}
+		return nil, fmt.Errorf("%s has no field %s", object, property)
+	}
+}
+
+// checkMutable reports an error when a field of expr cannot be updated.
+// Structs are values, so p.x = 1 modifies the variable p itself and is
+// refused for constants; class instances are references and always are.
+func (c *Checker) checkMutable(expr ast.Expr) error {
+	switch e := expr.(type) {
+	case ast.SymbolExpr:
+		if sym, exists := c.scope.lookup(e.Value); exists && sym.IsConstant {
+			if _, isStruct := sym.Type.(StructType); isStruct {
+				return fmt.Errorf("cannot assign to field of constant %s", e.Value)
+			}
+		}
+	case ast.MemberExpr:
+		object, err := c.checkExpr(e.Object)
+		if err != nil {
+			return err
+		}
+		if _, isStruct := object.(StructType); isStruct {
+			return c.checkMutable(e.Object)
+		}
+	}
+	return nil
+}
+
+func declKind(stmt ast.Stmt) string {
+	switch stmt.(type) {
+	case ast.FunctionDeclStmt:
+		return "function declaration"
+	case ast.ClassDeclStmt:
+		return "class declaration"
+	case ast.EnumDeclStmt:
+		return "enum declaration"
+	case ast.ImportStmt:
+		return "import"
+	default:
+		return "export"
+	}
+}
+
+func (c *Checker) checkIndexExpr(expr ast.IndexExpr) (Type, error) {
+	target, err := c.checkExpr(expr.Target)
+	if err != nil {
+		return nil, err
+	}
+	index, err := c.checkExpr(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := target.(type) {
+	case OptionalType:
+		return nil, nullableError(expr.Target, t)
+	case MapType:
+		if !IsAssignable(t.Key, index) {
+			return nil, fmt.Errorf("cannot use %s as %s key", index, t)
+		}
+		return t.Value, nil
+	case ArrayType:
+		if _, ok := index.(RangeType); ok {
+			return t, nil
+		}
+		if !IsAssignable(Int, index) {
+			return nil, fmt.Errorf("cannot use %s as array index", index)
+		}
+		return t.Elem, nil
+	default:
+		if _, ok := index.(RangeType); ok && (target == String || target == Any) {
+			return target, nil
+		}
+		if target == Any {
+			return Any, nil
+		}
+		return nil, fmt.Errorf("cannot index %s", target)
+	}
+}
+
+func (c *Checker) checkRangeExpr(expr ast.RangeExpr) (Type, error) {
+	bounds := []ast.Expr{expr.Start, expr.End}
+	if expr.Step != nil {
+		bounds = append(bounds, expr.Step)
+	}
+
+	for _, bound := range bounds {
+		t, err := c.checkExpr(bound)
+		if err != nil {
+			return nil, err
+		}
+		if !IsAssignable(Int, t) {
+			return nil, fmt.Errorf("range bounds must be int, got %s", t)
+		}
+	}
+	return Range, nil
+}
+
+func (c *Checker) checkTernaryExpr(expr ast.TernaryExpr) (Type, error) {
+	condition, err := c.checkExpr(expr.Condition)
+	if err != nil {
+		return nil, err
+	}
+	if !IsAssignable(Bool, condition) {
+		return nil, fmt.Errorf("ternary condition must be bool, got %s", condition)
+	}
+
+	var consequent, alternate Type
+	whenTrue, whenFalse := c.narrowings(expr.Condition)
+	err = c.withNarrowed(whenTrue, func() error {
+		var err error
+		consequent, err = c.checkExpr(expr.Consequent)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = c.withNarrowed(whenFalse, func() error {
+		var err error
+		alternate, err = c.checkExpr(expr.Alternate)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, ok := commonType(consequent, alternate)
+	if !ok {
+		return nil, fmt.Errorf("mismatched ternary branches: %s and %s", consequent, alternate)
+	}
+	return result, nil
+}
@@ -0,0 +1,539 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+)
+
+// Export describes a name a module makes available to its importers.
+type Export struct {
+	Type   Type
+	IsType bool
+}
+
+// Importer loads the module at path and returns what it exports.
+type Importer interface {
+	Import(path string) (map[string]Export, error)
+}
+
+func (c *Checker) Exports() map[string]Export {
+	return c.exports
+}
+
+func unwrapExport(stmt ast.Stmt) ast.Stmt {
+	if export, ok := stmt.(ast.ExportStmt); ok {
+		return export.Declaration
+	}
+	return stmt
+}
+
+// hoist declares imports, types, classes and function signatures before
+// any statement is checked, so they can be used ahead of their declaration.
+func (c *Checker) hoist(body []ast.Stmt) error {
+	decls := make([]ast.Stmt, 0, len(body))
+	for _, stmt := range body {
+		decls = append(decls, unwrapExport(stmt))
+	}
+
+	for _, stmt := range decls {
+		if s, ok := stmt.(ast.ImportStmt); ok {
+			if err := c.checkImport(s); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Classes come first so types and interfaces can mention them
+	for _, stmt := range decls {
+		if s, ok := stmt.(ast.ClassDeclStmt); ok {
+			typeParams, err := newTypeParams(s.TypeParams)
+			if err != nil {
+				return fmt.Errorf("%s: %w", s.Name, err)
+			}
+			c.scope.types[s.Name] = &ClassType{
+				Name:       s.Name,
+				TypeParams: typeParams,
+				Methods:    make(map[string]FunctionType),
+			}
+		}
+	}
+
+	for _, stmt := range decls {
+		switch s := stmt.(type) {
+		case ast.TypeDeclStmt:
+			if err := c.checkTypeDecl(s); err != nil {
+				return err
+			}
+		case ast.EnumDeclStmt:
+			if err := c.declareEnum(s); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Constraints may name any class, so they wait for all of them
+	for _, stmt := range decls {
+		if s, ok := stmt.(ast.ClassDeclStmt); ok && len(s.TypeParams) > 0 {
+			t, _ := c.scope.lookupType(s.Name)
+			class := t.(*ClassType)
+			err := c.withTypeParams(class.TypeParams, func() error {
+				return c.resolveConstraints(class.TypeParams, s.TypeParams)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, stmt := range decls {
+		switch s := stmt.(type) {
+		case ast.FunctionDeclStmt:
+			signature, err := c.resolveSignature(s)
+			if err != nil {
+				return err
+			}
+			c.scope.symbols[s.Name] = &symbol{Type: signature, IsConstant: true}
+		case ast.ClassDeclStmt:
+			if err := c.declareClassMembers(s); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, stmt := range body {
+		export, ok := stmt.(ast.ExportStmt)
+		if !ok {
+			continue
+		}
+		switch s := export.Declaration.(type) {
+		case ast.FunctionDeclStmt:
+			sym, _ := c.scope.lookup(s.Name)
+			c.exports[s.Name] = Export{Type: sym.Type}
+		case ast.ClassDeclStmt:
+			t, _ := c.scope.lookupType(s.Name)
+			c.exports[s.Name] = Export{Type: t, IsType: true}
+		case ast.TypeDeclStmt:
+			t, _ := c.scope.lookupType(s.Name)
+			c.exports[s.Name] = Export{Type: t, IsType: true}
+		case ast.EnumDeclStmt:
+			t, _ := c.scope.lookupType(s.Name)
+			c.exports[s.Name] = Export{Type: t, IsType: true}
+		}
+	}
+
+	return nil
+}
+
+func (c *Checker) checkTopLevelStmt(stmt ast.Stmt) error {
+	switch s := stmt.(type) {
+	case ast.ImportStmt, ast.TypeDeclStmt, ast.EnumDeclStmt:
+		return nil // already handled by hoist
+	case ast.FunctionDeclStmt:
+		sym, _ := c.scope.lookup(s.Name)
+		return c.checkFunctionBody(s, sym.Type.(FunctionType), nil)
+	case ast.ClassDeclStmt:
+		return c.checkClassBody(s)
+	case ast.ExportStmt:
+		if err := c.checkTopLevelStmt(s.Declaration); err != nil {
+			return err
+		}
+		if decl, ok := s.Declaration.(ast.VarDeclStmt); ok {
+			sym, _ := c.scope.lookup(decl.VariableName)
+			c.exports[decl.VariableName] = Export{Type: sym.Type}
+		}
+		return nil
+	default:
+		return c.checkStmt(stmt)
+	}
+}
+
+func (c *Checker) checkImport(stmt ast.ImportStmt) error {
+	if c.Importer == nil {
+		return fmt.Errorf("cannot import %s: imports are not supported here", stmt.Path)
+	}
+
+	exports, err := c.Importer.Import(stmt.Path)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range stmt.Names {
+		export, exists := exports[name]
+		if !exists {
+			return fmt.Errorf("%s does not export %s", stmt.Path, name)
+		}
+		if export.IsType {
+			c.scope.types[name] = export.Type
+		} else {
+			// Imported bindings belong to their module and are read-only here
+			c.scope.symbols[name] = &symbol{Type: export.Type, IsConstant: true}
+		}
+	}
+	return nil
+}
+
+func (c *Checker) resolveSignature(decl ast.FunctionDeclStmt) (FunctionType, error) {
+	typeParams, err := newTypeParams(decl.TypeParams)
+	if err != nil {
+		return FunctionType{}, fmt.Errorf("%s: %w", decl.Name, err)
+	}
+
+	signature := FunctionType{TypeParams: typeParams, Return: Void}
+	err = c.withTypeParams(typeParams, func() error {
+		if err := c.resolveConstraints(typeParams, decl.TypeParams); err != nil {
+			return err
+		}
+
+		for _, param := range decl.Parameters {
+			t, err := c.resolveType(param.Type)
+			if err != nil {
+				return err
+			}
+			signature.Params = append(signature.Params, t)
+		}
+
+		if decl.ReturnType != nil {
+			t, err := c.resolveType(decl.ReturnType)
+			if err != nil {
+				return err
+			}
+			signature.Return = t
+		}
+		return nil
+	})
+	if err != nil {
+		return FunctionType{}, err
+	}
+	return signature, nil
+}
+
+func (c *Checker) declareClassMembers(decl ast.ClassDeclStmt) error {
+	t, _ := c.scope.lookupType(decl.Name)
+	class := t.(*ClassType)
+	return c.withTypeParams(class.TypeParams, func() error {
+		return c.declareClassMembersIn(decl, class)
+	})
+}
+
+func (c *Checker) declareClassMembersIn(decl ast.ClassDeclStmt, class *ClassType) error {
+	seen := make(map[string]bool)
+
+	for _, field := range decl.Fields {
+		if seen[field.VariableName] {
+			return fmt.Errorf("duplicate member %s in class %s", field.VariableName, decl.Name)
+		}
+		seen[field.VariableName] = true
+
+		var fieldType Type
+		var err error
+		if field.ExplicitType != nil {
+			fieldType, err = c.resolveType(field.ExplicitType)
+		} else {
+			fieldType, err = c.checkExpr(field.AssignedValue)
+		}
+		if err != nil {
+			return err
+		}
+		class.Fields = append(class.Fields, StructField{Name: field.VariableName, Type: fieldType})
+	}
+
+	for _, method := range decl.Methods {
+		if seen[method.Name] {
+			return fmt.Errorf("duplicate member %s in class %s", method.Name, decl.Name)
+		}
+		seen[method.Name] = true
+
+		signature, err := c.resolveSignature(method)
+		if err != nil {
+			return err
+		}
+		class.Methods[method.Name] = signature
+	}
+
+	for _, name := range decl.Implements {
+		t, exists := c.scope.lookupType(name)
+		if !exists {
+			return fmt.Errorf("unknown type: %s", name)
+		}
+		iface, ok := t.(*InterfaceType)
+		if !ok {
+			return fmt.Errorf("%s cannot implement %s, which is not an interface", decl.Name, name)
+		}
+		if err := conforms(iface, selfType(class)); err != nil {
+			return fmt.Errorf("%s does not implement %s: %w", decl.Name, name, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Checker) declareInterfaceMethods(iface *InterfaceType, t ast.InterfaceType) error {
+	for _, method := range t.Methods {
+		if _, exists := iface.Methods[method.Name]; exists {
+			return fmt.Errorf("duplicate method %s", method.Name)
+		}
+		signature, err := c.resolveSignature(ast.FunctionDeclStmt{
+			Name:       method.Name,
+			Parameters: method.Parameters,
+			ReturnType: method.ReturnType,
+		})
+		if err != nil {
+			return err
+		}
+		iface.Methods[method.Name] = signature
+		iface.names = append(iface.names, method.Name)
+	}
+	return nil
+}
+
+func (c *Checker) checkClassBody(decl ast.ClassDeclStmt) error {
+	t, _ := c.scope.lookupType(decl.Name)
+	class := t.(*ClassType)
+	return c.withTypeParams(class.TypeParams, func() error {
+		return c.checkClassBodyIn(decl, class)
+	})
+}
+
+func (c *Checker) checkClassBodyIn(decl ast.ClassDeclStmt, class *ClassType) error {
+
+	for _, field := range decl.Fields {
+		if field.AssignedValue == nil || field.ExplicitType == nil {
+			continue
+		}
+		value, err := c.checkExpr(field.AssignedValue)
+		if err != nil {
+			return err
+		}
+		fieldType, _ := class.Field(field.VariableName)
+		if !c.assignable(fieldType, field.AssignedValue, value) {
+			return fmt.Errorf("cannot use %s as %s in field %s of %s", value, fieldType, field.VariableName, decl.Name)
+		}
+	}
+
+	for _, method := range decl.Methods {
+		if err := c.checkFunctionBody(method, class.Methods[method.Name], selfType(class)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Checker) checkFunctionBody(decl ast.FunctionDeclStmt, signature FunctionType, this *ClassType) error {
+	outerScope, outerFunction := c.scope, c.function
+	c.scope = newScope(outerScope)
+	c.function = &signature
+	defer func() { c.scope, c.function = outerScope, outerFunction }()
+
+	for _, param := range signature.TypeParams {
+		c.scope.types[param.Name] = param
+	}
+	if this != nil {
+		c.scope.symbols["this"] = &symbol{Type: this, IsConstant: true}
+	}
+	for i, param := range decl.Parameters {
+		c.scope.symbols[param.Name] = &symbol{Type: signature.Params[i]}
+	}
+
+	if err := c.checkStmts(decl.Body.Body); err != nil {
+		return fmt.Errorf("%s: %w", decl.Name, err)
+	}
+
+	if signature.Return != Void && !returns(decl.Body.Body) {
+		return fmt.Errorf("missing return at end of function %s", decl.Name)
+	}
+	return nil
+}
+
+// anonymousName is how function expressions appear in errors.
+const anonymousName = "anonymous function"
+
+// checkFunctionExpr checks a function expression. Without a declared
+// return type, a body made of a single return, as arrow functions with an
+// expression body are, returns the type of that expression.
+func (c *Checker) checkFunctionExpr(expr ast.FunctionExpr) (Type, error) {
+	decl := ast.FunctionDeclStmt{
+		Name:       anonymousName,
+		Parameters: expr.Parameters,
+		ReturnType: expr.ReturnType,
+		Body:       expr.Body,
+	}
+
+	signature, err := c.resolveSignature(decl)
+	if err != nil {
+		return nil, err
+	}
+
+	if expr.ReturnType == nil && len(expr.Body.Body) == 1 {
+		if ret, ok := expr.Body.Body[0].(ast.ReturnStmt); ok && ret.Value != nil {
+			outer := c.scope
+			c.scope = newScope(outer)
+			for i, param := range expr.Parameters {
+				c.scope.symbols[param.Name] = &symbol{Type: signature.Params[i]}
+			}
+			result, err := c.checkExpr(ret.Value)
+			c.scope = outer
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", anonymousName, err)
+			}
+			if result != Void {
+				signature.Return = result
+			}
+		}
+	}
+
+	if err := c.checkFunctionBody(decl, signature, nil); err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
+func (c *Checker) checkReturn(stmt ast.ReturnStmt) error {
+	if c.function == nil {
+		return fmt.Errorf("return outside function")
+	}
+
+	if stmt.Value == nil {
+		if c.function.Return != Void {
+			return fmt.Errorf("missing return value, expected %s", c.function.Return)
+		}
+		return nil
+	}
+
+	value, err := c.checkExpr(stmt.Value)
+	if err != nil {
+		return err
+	}
+	if c.function.Return == Void {
+		return fmt.Errorf("unexpected return value in function without return type")
+	}
+	if !c.assignable(c.function.Return, stmt.Value, value) {
+		return fmt.Errorf("cannot return %s as %s", value, c.function.Return)
+	}
+	return nil
+}
+
+// returns reports whether every path through body ends in a return.
+func returns(body []ast.Stmt) bool {
+	if len(body) == 0 {
+		return false
+	}
+
+	switch s := body[len(body)-1].(type) {
+	case ast.ReturnStmt, ast.ThrowStmt:
+		return true
+	case ast.TryStmt:
+		if s.Finally != nil && returns(s.Finally.Body) {
+			return true
+		}
+		return returns(s.Body.Body) && (s.Catch == nil || returns(s.Catch.Body))
+	case ast.IfStmt:
+		return s.Alternative != nil && returns(s.Consequence.Body) && returns(s.Alternative.Body)
+	case ast.BlockStmt:
+		return returns(s.Body)
+	case ast.MatchStmt:
+		// Matches are exhaustive, so returning from every arm is enough
+		for _, arm := range s.Arms {
+			if arm.Body == nil || !returns(arm.Body.Body) {
+				return false
+			}
+		}
+		return len(s.Arms) > 0
+	default:
+		return false
+	}
+}
+
+func (c *Checker) checkNewExpr(expr ast.NewExpr) (Type, error) {
+	t, exists := c.scope.lookupType(expr.ClassName)
+	if !exists {
+		return nil, fmt.Errorf("unknown type: %s", expr.ClassName)
+	}
+	class, ok := t.(*ClassType)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a class", expr.ClassName)
+	}
+
+	args, err := c.checkArguments(expr.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	defer c.widenCalled()
+
+	switch {
+	case len(expr.TypeArgs) > 0:
+		if len(class.TypeParams) == 0 {
+			return nil, fmt.Errorf("%s is not generic", class)
+		}
+		if class, err = c.resolveTypeArgs(class, expr.TypeArgs); err != nil {
+			return nil, err
+		}
+	case len(class.TypeParams) > 0:
+		// new Box(1) infers Box<int> from the constructor arguments
+		constructor, exists := class.Methods["constructor"]
+		if !exists {
+			return nil, fmt.Errorf("cannot infer type arguments of %s, write new %s%s()", class, class, typeParamList(class.TypeParams))
+		}
+		b, err := inferTypeArgs("new "+class.Name, class.TypeParams, constructor, args)
+		if err != nil {
+			return nil, err
+		}
+		typeArgs := make([]Type, 0, len(class.TypeParams))
+		for _, param := range class.TypeParams {
+			typeArgs = append(typeArgs, b[param])
+		}
+		class = instantiate(class, typeArgs)
+	}
+
+	constructor, exists := class.Method("constructor")
+	if !exists {
+		if len(args) > 0 {
+			return nil, fmt.Errorf("%s has no constructor but was given %d arguments", class, len(args))
+		}
+		return class, nil
+	}
+
+	if constructor, err = instantiateCall("new "+class.Name, constructor, args); err != nil {
+		return nil, err
+	}
+	if err := c.checkCallArguments("new "+class.Name, constructor, expr.Arguments, args); err != nil {
+		return nil, err
+	}
+	return class, nil
+}
+
+func (c *Checker) checkArguments(arguments []ast.Expr) ([]Type, error) {
+	args := make([]Type, 0, len(arguments))
+	for _, argument := range arguments {
+		arg, err := c.checkExpr(argument)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+func (c *Checker) checkCallArguments(name string, signature FunctionType, arguments []ast.Expr, args []Type) error {
+	params := signature.Params
+	if signature.Variadic {
+		fixed := len(params) - 1
+		if len(args) < fixed {
+			return fmt.Errorf("%s expects at least %d arguments, got %d", name, fixed, len(args))
+		}
+		for len(params) < len(args) {
+			params = append(params, signature.Params[fixed])
+		}
+		params = params[:len(args)]
+	}
+
+	if len(args) != len(params) {
+		return fmt.Errorf("%s expects %d arguments, got %d", name, len(params), len(args))
+	}
+	for i, arg := range args {
+		if !c.assignable(params[i], arguments[i], arg) {
+			return fmt.Errorf("cannot use %s as %s in argument %d of %s", arg, params[i], i+1, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,340 @@
+package checker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+// TypeParam is a type parameter such as T in fn first<T>(xs: []T): T.
+// Generic bodies are checked once with T as an opaque type that only
+// supports what every type allowed by its Constraint supports. Calls and
+// Box<int> substitute the type arguments for it.
+type TypeParam struct {
+	Name       string
+	Constraint Type // nil when T accepts any type
+}
+
+func (t *TypeParam) String() string { return t.Name }
+
+func typeParamList(params []*TypeParam) string {
+	if len(params) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(params))
+	for _, param := range params {
+		names = append(names, param.Name)
+	}
+	return "<" + strings.Join(names, ", ") + ">"
+}
+
+// newTypeParams creates the parameters of a generic declaration. Their
+// constraints are resolved by resolveConstraints once all of them are in
+// scope.
+func newTypeParams(decls []ast.TypeParam) ([]*TypeParam, error) {
+	params := make([]*TypeParam, 0, len(decls))
+	seen := make(map[string]bool)
+	for _, decl := range decls {
+		if seen[decl.Name] {
+			return nil, fmt.Errorf("duplicate type parameter %s", decl.Name)
+		}
+		seen[decl.Name] = true
+		params = append(params, &TypeParam{Name: decl.Name})
+	}
+	return params, nil
+}
+
+func (c *Checker) resolveConstraints(params []*TypeParam, decls []ast.TypeParam) error {
+	for i, decl := range decls {
+		if decl.Constraint == nil {
+			continue
+		}
+		constraint, err := c.resolveType(decl.Constraint)
+		if err != nil {
+			return err
+		}
+		params[i].Constraint = constraint
+	}
+	return nil
+}
+
+// withTypeParams runs check with params visible as types.
+func (c *Checker) withTypeParams(params []*TypeParam, check func() error) error {
+	if len(params) == 0 {
+		return check()
+	}
+	outer := c.scope
+	c.scope = newScope(outer)
+	defer func() { c.scope = outer }()
+
+	for _, param := range params {
+		c.scope.types[param.Name] = param
+	}
+	return check()
+}
+
+// bindings maps the class's type parameters to args.
+func (t *ClassType) bindings(args []Type) map[*TypeParam]Type {
+	b := make(map[*TypeParam]Type, len(args))
+	for i, param := range t.TypeParams {
+		b[param] = args[i]
+	}
+	return b
+}
+
+// instantiate returns generic applied to args, such as Box<int>.
+func instantiate(generic *ClassType, args []Type) *ClassType {
+	return &ClassType{Name: generic.Name, generic: generic, args: args}
+}
+
+// selfType is the type of this inside a generic class: the class applied
+// to its own parameters, Box<T>.
+func selfType(class *ClassType) *ClassType {
+	if len(class.TypeParams) == 0 {
+		return class
+	}
+	args := make([]Type, 0, len(class.TypeParams))
+	for _, param := range class.TypeParams {
+		args = append(args, param)
+	}
+	return instantiate(class, args)
+}
+
+// substitute replaces the type parameters bound in b wherever they occur
+// in t.
+func substitute(t Type, b map[*TypeParam]Type) Type {
+	switch t := t.(type) {
+	case *TypeParam:
+		if bound, exists := b[t]; exists {
+			return bound
+		}
+		return t
+	case ArrayType:
+		return ArrayType{Elem: substitute(t.Elem, b)}
+	case MapType:
+		return MapType{Key: substitute(t.Key, b), Value: substitute(t.Value, b)}
+	case OptionalType:
+		return optional(substitute(t.Elem, b))
+	case UnionType:
+		types := make([]Type, 0, len(t.Types))
+		for _, member := range t.Types {
+			types = append(types, substitute(member, b))
+		}
+		return unionOf(types)
+	case StructType:
+		fields := make([]StructField, 0, len(t.Fields))
+		for _, field := range t.Fields {
+			fields = append(fields, StructField{Name: field.Name, Type: substitute(field.Type, b)})
+		}
+		return StructType{Name: t.Name, Fields: fields}
+	case FunctionType:
+		params := make([]Type, 0, len(t.Params))
+		for _, param := range t.Params {
+			params = append(params, substitute(param, b))
+		}
+		return FunctionType{
+			TypeParams: t.TypeParams,
+			Params:     params,
+			Return:     substitute(t.Return, b),
+			Variadic:   t.Variadic,
+		}
+	case *ClassType:
+		if t.generic == nil {
+			return t
+		}
+		args := make([]Type, 0, len(t.args))
+		for _, arg := range t.args {
+			args = append(args, substitute(arg, b))
+		}
+		return instantiate(t.generic, args)
+	default:
+		return t
+	}
+}
+
+// unify infers the type parameters in b, which start out unbound, by
+// matching the parameter type param against the argument type arg. A
+// parameter seen with int and float is inferred as float.
+func unify(param, arg Type, b map[*TypeParam]Type) {
+	switch p := param.(type) {
+	case *TypeParam:
+		bound, inferring := b[p]
+		if !inferring || arg == Null {
+			return
+		}
+		if bound == nil || !IsAssignable(bound, arg) && IsAssignable(arg, bound) {
+			b[p] = arg
+		}
+	case ArrayType:
+		if a, ok := arg.(ArrayType); ok {
+			unify(p.Elem, a.Elem, b)
+		}
+	case MapType:
+		if a, ok := arg.(MapType); ok {
+			unify(p.Key, a.Key, b)
+			unify(p.Value, a.Value, b)
+		}
+	case OptionalType:
+		if a, ok := arg.(OptionalType); ok {
+			arg = a.Elem
+		}
+		unify(p.Elem, arg, b)
+	case FunctionType:
+		if a, ok := arg.(FunctionType); ok && len(a.Params) == len(p.Params) {
+			for i := range p.Params {
+				unify(p.Params[i], a.Params[i], b)
+			}
+			unify(p.Return, a.Return, b)
+		}
+	case *ClassType:
+		if a, ok := arg.(*ClassType); ok && p.generic != nil && a.generic == p.generic {
+			for i := range p.args {
+				unify(p.args[i], a.args[i], b)
+			}
+		}
+	}
+}
+
+// inferTypeArgs infers typeParams from the arguments of a call to
+// signature and checks them against their constraints.
+func inferTypeArgs(name string, typeParams []*TypeParam, signature FunctionType, args []Type) (map[*TypeParam]Type, error) {
+	b := make(map[*TypeParam]Type, len(typeParams))
+	for _, param := range typeParams {
+		b[param] = nil
+	}
+
+	for i, arg := range args {
+		switch {
+		case i < len(signature.Params):
+			unify(signature.Params[i], arg, b)
+		case signature.Variadic && len(signature.Params) > 0:
+			unify(signature.Params[len(signature.Params)-1], arg, b)
+		}
+	}
+
+	inferred := make([]Type, 0, len(typeParams))
+	for _, param := range typeParams {
+		if b[param] == nil {
+			return nil, fmt.Errorf("cannot infer %s in call to %s", param, name)
+		}
+		inferred = append(inferred, b[param])
+	}
+	if err := checkConstraints(name, typeParams, inferred); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func checkConstraints(name string, params []*TypeParam, args []Type) error {
+	for i, param := range params {
+		if param.Constraint != nil && !IsAssignable(param.Constraint, args[i]) {
+			return fmt.Errorf("%s does not satisfy %s: %s in %s", args[i], param, param.Constraint, name)
+		}
+	}
+	return nil
+}
+
+// instantiateCall returns the signature of a call to fn with the given
+// arguments, inferring the type arguments when fn is generic.
+func instantiateCall(name string, fn FunctionType, args []Type) (FunctionType, error) {
+	if len(fn.TypeParams) == 0 {
+		return fn, nil
+	}
+	b, err := inferTypeArgs(name, fn.TypeParams, fn, args)
+	if err != nil {
+		return FunctionType{}, err
+	}
+	concrete := FunctionType{Params: fn.Params, Return: fn.Return, Variadic: fn.Variadic}
+	return substitute(concrete, b).(FunctionType), nil
+}
+
+func (c *Checker) resolveGenericType(t ast.GenericType) (Type, error) {
+	declared, exists := c.scope.lookupType(t.Name)
+	if !exists {
+		return nil, fmt.Errorf("unknown type: %s", t.Name)
+	}
+	class, ok := declared.(*ClassType)
+	if !ok || len(class.TypeParams) == 0 {
+		return nil, fmt.Errorf("%s is not generic", t.Name)
+	}
+	return c.resolveTypeArgs(class, t.Args)
+}
+
+// resolveTypeArgs applies class to explicit type arguments.
+func (c *Checker) resolveTypeArgs(class *ClassType, typeArgs []ast.Type) (*ClassType, error) {
+	if len(typeArgs) != len(class.TypeParams) {
+		return nil, fmt.Errorf("%s expects %d type arguments, got %d", class.Name, len(class.TypeParams), len(typeArgs))
+	}
+
+	args := make([]Type, 0, len(typeArgs))
+	for _, typeArg := range typeArgs {
+		arg, err := c.resolveType(typeArg)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if err := checkConstraints(class.Name, class.TypeParams, args); err != nil {
+		return nil, err
+	}
+	return instantiate(class, args), nil
+}
+
+// typeParamOperatorType types an operation with a type parameter operand,
+// which is valid when it is valid for every type the constraint allows.
+// a + b for a, b: T is a T; a + 1 also is when T only holds numbers.
+func typeParamOperatorType(operator lexer.TokenKind, left, right Type) (Type, bool) {
+	param, paramLeft := left.(*TypeParam)
+	other := right
+	if !paramLeft {
+		if param, _ = right.(*TypeParam); param == nil {
+			return nil, false
+		}
+		other = left
+	}
+	if param.Constraint == nil {
+		return nil, false
+	}
+
+	same := Identical(left, right)
+	allBool := true
+	for _, member := range members(param.Constraint) {
+		l, r := member, other
+		if same {
+			r = member
+		} else if !paramLeft {
+			l, r = other, member
+		}
+		result, ok := operatorType(operator, l, r)
+		if !ok {
+			return nil, false
+		}
+		if result != Bool {
+			allBool = false
+			if !Identical(result, member) {
+				return nil, false
+			}
+		}
+	}
+	if allBool {
+		return Bool, true
+	}
+	return param, true
+}
+
+// isNumericParam reports whether t is a type parameter constrained to
+// numbers.
+func isNumericParam(t Type) bool {
+	param, ok := t.(*TypeParam)
+	if !ok || param.Constraint == nil {
+		return false
+	}
+	for _, member := range members(param.Constraint) {
+		if member != Int && member != Float {
+			return false
+		}
+	}
+	return true
+}
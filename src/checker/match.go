@@ -0,0 +1,420 @@
+package checker
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+// EnumType is declared with enum Color { Red, Green, Blue }. Variants may
+// carry a payload, as in Circle(float), and are created through the enum's
+// name: Color.Red, Shape.Circle(1.5).
+type EnumType struct {
+	Name     string
+	Variants []EnumVariant
+}
+
+type EnumVariant struct {
+	Name    string
+	Payload []Type
+}
+
+func (t *EnumType) String() string { return t.Name }
+
+func (t *EnumType) Variant(name string) (EnumVariant, bool) {
+	for _, variant := range t.Variants {
+		if variant.Name == name {
+			return variant, true
+		}
+	}
+	return EnumVariant{}, false
+}
+
+// namespace is the type of the enum's name used as a value: variants
+// without payload are members, the others functions building the value.
+func (t *EnumType) namespace() NamespaceType {
+	members := make(map[string]Type, len(t.Variants))
+	for _, variant := range t.Variants {
+		if variant.Payload == nil {
+			members[variant.Name] = t
+			continue
+		}
+		members[variant.Name] = FunctionType{Params: variant.Payload, Return: t}
+	}
+	return NamespaceType{Name: t.Name, Members: members}
+}
+
+// hasPayload reports whether any variant carries values.
+func (t *EnumType) hasPayload() bool {
+	for _, variant := range t.Variants {
+		if variant.Payload != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Checker) declareEnum(decl ast.EnumDeclStmt) error {
+	if isBuiltinType(decl.Name) {
+		return fmt.Errorf("cannot redeclare builtin type %s", decl.Name)
+	}
+
+	// Declared before its payloads so a variant can hold the enum itself
+	enum := &EnumType{Name: decl.Name}
+	c.scope.types[decl.Name] = enum
+
+	for _, variant := range decl.Variants {
+		if _, exists := enum.Variant(variant.Name); exists {
+			return fmt.Errorf("duplicate variant %s in enum %s", variant.Name, decl.Name)
+		}
+
+		var payload []Type
+		for _, t := range variant.Payload {
+			resolved, err := c.resolveType(t)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", decl.Name, variant.Name, err)
+			}
+			payload = append(payload, resolved)
+		}
+		enum.Variants = append(enum.Variants, EnumVariant{Name: variant.Name, Payload: payload})
+	}
+	return nil
+}
+
+// coverage tracks what the arms of a match have handled so far.
+type coverage struct {
+	subject  Type
+	all      bool
+	null     bool
+	variants map[string]bool
+	// literals holds the numbers and strings matched, whole numbers as
+	// int64 since 3 and 3.0 are equal; ranges holds the integers matched
+	// by ranges with literal bounds
+	literals map[interface{}]bool
+	ranges   []interval
+}
+
+// interval holds the integers from lo to hi, both included.
+type interval struct{ lo, hi int64 }
+
+// covers reports whether the arms so far already match every value
+// pattern matches, which is then unreachable. Ranges with bounds that are
+// not literals are never covered, nor do they cover anything.
+func (cv *coverage) covers(pattern ast.Pattern) bool {
+	switch p := pattern.(type) {
+	case ast.LiteralPattern:
+		if _, ok := p.Value.(ast.NullExpr); ok {
+			return cv.null
+		}
+		value, ok := literalValue(p.Value)
+		if !ok {
+			return false
+		}
+		if cv.literals[value] {
+			return true
+		}
+		// Ranges only match integers
+		if n, ok := literalInt(p.Value); ok {
+			return cv.coversInterval(interval{n, n})
+		}
+		return false
+	case ast.RangePattern:
+		r, ok := rangeInterval(p)
+		return ok && r.lo <= r.hi && cv.coversInterval(r)
+	case ast.VariantPattern:
+		return cv.variants[p.Enum+"."+p.Variant]
+	}
+	return false
+}
+
+// coversInterval reports whether the earlier ranges and integer literals
+// together match every integer in r.
+func (cv *coverage) coversInterval(r interval) bool {
+	covered := append([]interval(nil), cv.ranges...)
+	for value := range cv.literals {
+		if n, ok := value.(int64); ok {
+			covered = append(covered, interval{n, n})
+		}
+	}
+	sort.Slice(covered, func(i, j int) bool { return covered[i].lo < covered[j].lo })
+
+	next := r.lo
+	for _, c := range covered {
+		if c.lo > next {
+			break
+		}
+		if c.hi >= r.hi {
+			return true
+		}
+		if c.hi >= next {
+			next = c.hi + 1
+		}
+	}
+	return false
+}
+
+// record adds what a literal or range pattern matches to the coverage.
+func (cv *coverage) record(pattern ast.Pattern) {
+	switch p := pattern.(type) {
+	case ast.LiteralPattern:
+		if value, ok := literalValue(p.Value); ok {
+			cv.literals[value] = true
+		}
+	case ast.RangePattern:
+		if r, ok := rangeInterval(p); ok && r.lo <= r.hi {
+			cv.ranges = append(cv.ranges, r)
+		}
+	}
+}
+
+// literalValue is the value of a literal pattern, null aside: an int64
+// for whole numbers, a float64 for the other ones and a string for
+// strings.
+func literalValue(expr ast.Expr) (interface{}, bool) {
+	if s, ok := expr.(ast.StringExpr); ok {
+		return s.Value, true
+	}
+	n, negated, ok := numberLiteral(expr)
+	if !ok {
+		return nil, false
+	}
+	if !n.Float {
+		if negated {
+			return -n.Int, true
+		}
+		return n.Int, true
+	}
+	f := n.Value
+	if negated {
+		f = -f
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+		return int64(f), true
+	}
+	return f, true
+}
+
+// literalInt is the value of an integer literal, written without a
+// decimal point.
+func literalInt(expr ast.Expr) (int64, bool) {
+	n, negated, ok := numberLiteral(expr)
+	if !ok || n.Float {
+		return 0, false
+	}
+	if negated {
+		return -n.Int, true
+	}
+	return n.Int, true
+}
+
+// numberLiteral unwraps the number of the literal pattern n or -n.
+func numberLiteral(expr ast.Expr) (n ast.NumberExpr, negated bool, ok bool) {
+	switch e := expr.(type) {
+	case ast.NumberExpr:
+		return e, false, true
+	case ast.PrefixExpr:
+		if n, ok := e.RightExpr.(ast.NumberExpr); ok && e.Operator.Kind == lexer.DASH {
+			return n, true, true
+		}
+	}
+	return ast.NumberExpr{}, false, false
+}
+
+// rangeInterval is the interval a range pattern with literal bounds
+// matches, empty when lo > hi.
+func rangeInterval(p ast.RangePattern) (interval, bool) {
+	lo, ok := literalInt(p.Start)
+	if !ok {
+		return interval{}, false
+	}
+	hi, ok := literalInt(p.End)
+	if !ok {
+		return interval{}, false
+	}
+	if !p.Inclusive {
+		hi--
+	}
+	return interval{lo, hi}, true
+}
+
+// missing lists what no arm matches yet, empty when the match is
+// exhaustive.
+func (cv *coverage) missing() []string {
+	if cv.all {
+		return nil
+	}
+	var missing []string
+	for _, member := range members(cv.subject) {
+		switch t := member.(type) {
+		case *EnumType:
+			for _, variant := range t.Variants {
+				if !cv.variants[t.Name+"."+variant.Name] {
+					missing = append(missing, t.Name+"."+variant.Name)
+				}
+			}
+		default:
+			if member == Null {
+				if !cv.null {
+					missing = append(missing, "null")
+				}
+				continue
+			}
+			missing = append(missing, "other "+member.String()+" values (add a _ arm)")
+		}
+	}
+	return missing
+}
+
+// checkMatch checks a match statement or, when asValue is set, a match
+// expression and returns the type of its value.
+func (c *Checker) checkMatch(subject ast.Expr, arms []ast.MatchArm, asValue bool) (Type, error) {
+	subjectType, err := c.checkExpr(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	cover := &coverage{subject: subjectType, variants: make(map[string]bool), literals: make(map[interface{}]bool)}
+	var result Type
+	for i, arm := range arms {
+		if len(cover.missing()) == 0 {
+			return nil, fmt.Errorf("unreachable match arm %d, the arms before it match everything", i+1)
+		}
+
+		var bindings map[string]Type
+		for _, pattern := range arm.Patterns {
+			if cover.covers(pattern) {
+				return nil, fmt.Errorf("unreachable pattern in match arm %d, the patterns before it match all of its values", i+1)
+			}
+			bound, err := c.checkPattern(pattern, subjectType, cover)
+			if err != nil {
+				return nil, err
+			}
+			cover.record(pattern)
+			if len(bound) > 0 && len(arm.Patterns) > 1 {
+				return nil, fmt.Errorf("cannot bind names in alternative patterns")
+			}
+			bindings = bound
+		}
+
+		t, err := c.checkArm(arm, bindings)
+		if err != nil {
+			return nil, err
+		}
+		if !asValue {
+			continue
+		}
+		if t == Void {
+			return nil, fmt.Errorf("match arm %d has no value", i+1)
+		}
+		if result == nil {
+			result = t
+		} else if common, ok := commonType(result, t); ok {
+			result = common
+		} else {
+			result = unionOf([]Type{result, t})
+		}
+	}
+
+	if missing := cover.missing(); len(missing) > 0 {
+		return nil, fmt.Errorf("non-exhaustive match on %s: missing %s", subjectType, strings.Join(missing, ", "))
+	}
+	if !asValue {
+		return Void, nil
+	}
+	return result, nil
+}
+
+func (c *Checker) checkArm(arm ast.MatchArm, bindings map[string]Type) (Type, error) {
+	outer := c.scope
+	c.scope = newScope(outer)
+	defer func() { c.scope = outer }()
+
+	for name, t := range bindings {
+		c.scope.symbols[name] = &symbol{Type: t}
+	}
+	if arm.Body != nil {
+		return Void, c.checkBlock(*arm.Body)
+	}
+	return c.checkExpr(arm.Value)
+}
+
+// checkPattern checks pattern against a subject of type subject, records
+// what it covers and returns the names it binds.
+func (c *Checker) checkPattern(pattern ast.Pattern, subject Type, cover *coverage) (map[string]Type, error) {
+	switch p := pattern.(type) {
+	case ast.WildcardPattern:
+		cover.all = true
+		return nil, nil
+	case ast.BindingPattern:
+		cover.all = true
+		return map[string]Type{p.Name: subject}, nil
+	case ast.LiteralPattern:
+		t, err := c.checkExpr(p.Value)
+		if err != nil {
+			return nil, err
+		}
+		if t == Null {
+			if _, ok := subject.(OptionalType); !ok && subject != Any {
+				return nil, fmt.Errorf("cannot match null against %s", subject)
+			}
+			cover.null = true
+			return nil, nil
+		}
+		if _, ok := commonType(subject, t); !ok {
+			return nil, fmt.Errorf("cannot match %s against %s", t, subject)
+		}
+		return nil, nil
+	case ast.RangePattern:
+		if _, err := c.checkRangeExpr(ast.RangeExpr{Start: p.Start, End: p.End}); err != nil {
+			return nil, err
+		}
+		for _, member := range members(subject) {
+			if member == Int || member == Any {
+				return nil, nil
+			}
+		}
+		return nil, fmt.Errorf("cannot match a range against %s", subject)
+	case ast.VariantPattern:
+		return c.checkVariantPattern(p, subject, cover)
+	default:
+		return nil, fmt.Errorf("unknown pattern: %T", pattern)
+	}
+}
+
+func (c *Checker) checkVariantPattern(p ast.VariantPattern, subject Type, cover *coverage) (map[string]Type, error) {
+	t, exists := c.scope.lookupType(p.Enum)
+	enum, ok := t.(*EnumType)
+	if !exists || !ok {
+		return nil, fmt.Errorf("%s is not an enum", p.Enum)
+	}
+	if subject != Any && !IsAssignable(subject, enum) {
+		return nil, fmt.Errorf("cannot match %s against %s", enum, subject)
+	}
+	variant, exists := enum.Variant(p.Variant)
+	if !exists {
+		return nil, fmt.Errorf("%s has no variant %s", enum, p.Variant)
+	}
+	cover.variants[enum.Name+"."+variant.Name] = true
+
+	if p.Bindings == nil {
+		return nil, nil
+	}
+	if len(p.Bindings) != len(variant.Payload) {
+		return nil, fmt.Errorf("%s.%s holds %d values, the pattern binds %d", enum, variant.Name, len(variant.Payload), len(p.Bindings))
+	}
+	bindings := make(map[string]Type, len(p.Bindings))
+	for i, name := range p.Bindings {
+		if name == "_" {
+			continue
+		}
+		if _, duplicate := bindings[name]; duplicate {
+			return nil, fmt.Errorf("%s is bound twice in %s.%s", name, enum, variant.Name)
+		}
+		bindings[name] = variant.Payload[i]
+	}
+	return bindings, nil
+}
@@ -0,0 +1,273 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+// A narrowing maps variables to the type they are known to have on one
+// side of a condition, e.g. x: T inside if (x != null) for x: T?.
+type narrowing map[string]Type
+
+// narrowings returns what cond proves about variables when it is true and
+// when it is false.
+func (c *Checker) narrowings(cond ast.Expr) (whenTrue, whenFalse narrowing) {
+	switch e := cond.(type) {
+	case ast.BinaryExpr:
+		switch e.Operator.Kind {
+		case lexer.EQUALS, lexer.NOT_EQUALS:
+			name, matched, rest, ok := c.nullCheck(e)
+			if !ok {
+				name, matched, rest, ok = c.typeofCheck(e)
+			}
+			if !ok {
+				return nil, nil
+			}
+			equal, unequal := narrowTo(name, matched), narrowTo(name, rest)
+			if e.Operator.Kind == lexer.NOT_EQUALS {
+				return unequal, equal
+			}
+			return equal, unequal
+		case lexer.AND:
+			left, _ := c.narrowings(e.Left)
+			right, _ := c.narrowings(e.Right)
+			return merge(left, right), nil
+		case lexer.OR:
+			_, left := c.narrowings(e.Left)
+			_, right := c.narrowings(e.Right)
+			return nil, merge(left, right)
+		}
+	case ast.PrefixExpr:
+		if e.Operator.Kind == lexer.NOT {
+			whenTrue, whenFalse := c.narrowings(e.RightExpr)
+			return whenFalse, whenTrue
+		}
+	}
+	return nil, nil
+}
+
+func narrowTo(name string, t Type) narrowing {
+	if t == nil {
+		return nil
+	}
+	return narrowing{name: t}
+}
+
+// nullCheck matches x == null and null == x where x is an optional
+// variable, returning x and its type when it is and is not null.
+func (c *Checker) nullCheck(expr ast.BinaryExpr) (name string, matched, rest Type, ok bool) {
+	operand := expr.Left
+	if _, isNull := operand.(ast.NullExpr); isNull {
+		operand = expr.Right
+	} else if _, isNull := expr.Right.(ast.NullExpr); !isNull {
+		return "", nil, nil, false
+	}
+
+	name, t, ok := c.variable(operand)
+	if !ok {
+		return "", nil, nil, false
+	}
+	opt, ok := t.(OptionalType)
+	if !ok {
+		return "", nil, nil, false
+	}
+	return name, Null, opt.Elem, true
+}
+
+// typeofCheck matches typeof x == "name" and "name" == typeof x, splitting
+// the members of x's type between those typeof names and the rest.
+func (c *Checker) typeofCheck(expr ast.BinaryExpr) (name string, matched, rest Type, ok bool) {
+	operand, literal := expr.Left, expr.Right
+	if _, isString := operand.(ast.StringExpr); isString {
+		operand, literal = literal, operand
+	}
+	prefix, ok := operand.(ast.PrefixExpr)
+	if !ok || prefix.Operator.Kind != lexer.TYPEOF {
+		return "", nil, nil, false
+	}
+	typeName, ok := literal.(ast.StringExpr)
+	if !ok {
+		return "", nil, nil, false
+	}
+	name, t, ok := c.variable(prefix.RightExpr)
+	if !ok {
+		return "", nil, nil, false
+	}
+
+	if t == Any {
+		switch typeName.Value {
+		case "int":
+			return name, Int, nil, true
+		case "float":
+			return name, Float, nil, true
+		case "string":
+			return name, String, nil, true
+		case "bool":
+			return name, Bool, nil, true
+		}
+		return "", nil, nil, false
+	}
+
+	all := members(t)
+	if len(all) < 2 {
+		return "", nil, nil, false
+	}
+	var matching, others []Type
+	for _, member := range all {
+		if TypeofName(member) == typeName.Value {
+			matching = append(matching, member)
+		} else {
+			others = append(others, member)
+		}
+	}
+	if len(matching) > 0 {
+		matched = unionOf(matching)
+	}
+	if len(others) > 0 {
+		rest = unionOf(others)
+	}
+	return name, matched, rest, true
+}
+
+// variable returns the name and type of expr when it is a variable.
+func (c *Checker) variable(expr ast.Expr) (string, Type, bool) {
+	symbol, ok := expr.(ast.SymbolExpr)
+	if !ok {
+		return "", nil, false
+	}
+	sym, exists := c.scope.lookup(symbol.Value)
+	if !exists {
+		return "", nil, false
+	}
+	return symbol.Value, sym.Type, true
+}
+
+func merge(a, b narrowing) narrowing {
+	if len(a) == 0 {
+		return b
+	}
+	merged := make(narrowing, len(a)+len(b))
+	for name, t := range a {
+		merged[name] = t
+	}
+	for name, t := range b {
+		merged[name] = t
+	}
+	return merged
+}
+
+// narrowedScope opens a scope where the narrowed variables shadow their
+// declarations. Assignments still check against the declared type.
+func (c *Checker) narrowedScope(narrowed narrowing) *scope {
+	s := newScope(c.scope)
+	for name, t := range narrowed {
+		sym, _ := c.scope.lookup(name)
+		declared := sym.Declared
+		if declared == nil {
+			declared = sym.Type
+		}
+		s.symbols[name] = &symbol{Type: t, IsConstant: sym.IsConstant, Declared: declared}
+	}
+	return s
+}
+
+// withNarrowed runs check with narrowed applied.
+func (c *Checker) withNarrowed(narrowed narrowing, check func() error) error {
+	if len(narrowed) == 0 {
+		return check()
+	}
+	outer := c.scope
+	c.scope = c.narrowedScope(narrowed)
+	defer func() { c.scope = outer }()
+	return check()
+}
+
+// narrowedAfter returns what stmt proves for the statements following it
+// in the same block: a guard such as if (x == null) { return; } or an
+// assignment x ??= value.
+func (c *Checker) narrowedAfter(stmt ast.Stmt) narrowing {
+	switch s := stmt.(type) {
+	case ast.IfStmt:
+		if s.Alternative != nil || !returns(s.Consequence.Body) {
+			return nil
+		}
+		_, whenFalse := c.narrowings(s.Condition)
+		return whenFalse
+	case ast.ExprStmt:
+		assignment, ok := s.Expression.(ast.AssignmentExpr)
+		if !ok || assignment.Operator.Kind != lexer.NULLISH_ASSIGNMENT {
+			return nil
+		}
+		target, ok := assignment.Assigne.(ast.SymbolExpr)
+		if !ok {
+			return nil
+		}
+		sym, _ := c.scope.lookup(target.Value)
+		opt, ok := sym.Type.(OptionalType)
+		if !ok {
+			return nil
+		}
+		value, err := c.checkExpr(assignment.Value)
+		if err != nil || !IsAssignable(opt.Elem, value) {
+			return nil
+		}
+		return narrowing{target.Value: opt.Elem}
+	}
+	return nil
+}
+
+// checkStmts checks a list of statements, narrowing the rest of the list
+// after each guard. The caller restores c.scope.
+func (c *Checker) checkStmts(body []ast.Stmt) error {
+	for _, stmt := range body {
+		if err := c.checkStmt(stmt); err != nil {
+			return err
+		}
+		if narrowed := c.narrowedAfter(stmt); len(narrowed) > 0 {
+			c.scope = c.narrowedScope(narrowed)
+		}
+	}
+	return nil
+}
+
+// widen undoes the narrowings of the variable name, in every scope
+// narrowing it, once it may have been assigned something else.
+func (c *Checker) widen(name string) {
+	for s := c.scope; s != nil; s = s.outer {
+		sym, exists := s.symbols[name]
+		if !exists {
+			continue
+		}
+		if sym.Declared == nil {
+			return
+		}
+		sym.Type = sym.Declared
+	}
+}
+
+// widenAssigned widens the variables a loop body assigns before it is
+// checked, as the next iteration, and the condition checked before it,
+// see what the body assigned in the previous one.
+func (c *Checker) widenAssigned(body ast.BlockStmt, names ...string) {
+	for name := range assignedIn(body.Body, names...).outer {
+		c.widen(name)
+	}
+}
+
+// widenCalled widens the variables functions assign after a call, which
+// may have run any of them.
+func (c *Checker) widenCalled() {
+	for name := range c.assignedByFunctions {
+		c.widen(name)
+	}
+}
+
+// nullableError reports the use of a possibly null value.
+func nullableError(expr ast.Expr, t Type) error {
+	if symbol, ok := expr.(ast.SymbolExpr); ok {
+		return fmt.Errorf("%s may be null (%s), check it with != null, ?. or ?? first", symbol.Value, t)
+	}
+	return fmt.Errorf("value of type %s may be null, check it with != null, ?. or ?? first", t)
+}
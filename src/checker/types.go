@@ -0,0 +1,676 @@
+package checker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+type Type interface {
+	String() string
+}
+
+type BasicType struct {
+	Name string
+}
+
+func (t BasicType) String() string { return t.Name }
+
+var (
+	Int    = BasicType{Name: "int"}
+	Float  = BasicType{Name: "float"}
+	String = BasicType{Name: "string"}
+	Bool   = BasicType{Name: "bool"}
+	Void   = BasicType{Name: "void"}
+	// Time and Duration are the values handled by the time module
+	Time     = BasicType{Name: "Time"}
+	Duration = BasicType{Name: "Duration"}
+	// Any is used where the checker cannot know the type statically;
+	// it is compatible with everything.
+	Any = BasicType{Name: "any"}
+	// Null is the type of the null literal, only assignable to optionals.
+	Null = BasicType{Name: "null"}
+)
+
+// ErrorType is the predeclared type of errors received by catch.
+var ErrorType = StructType{
+	Name: "Error",
+	Fields: []StructField{
+		{Name: "message", Type: String},
+		{Name: "stack", Type: ArrayType{Elem: String}},
+	},
+}
+
+type ArrayType struct {
+	Elem Type
+}
+
+func (t ArrayType) String() string {
+	if _, ok := t.Elem.(UnionType); ok {
+		return "[](" + t.Elem.String() + ")"
+	}
+	return "[]" + t.Elem.String()
+}
+
+// OptionalType is T?, a T or null. It must be narrowed to T, by
+// comparing it with null, before the value can be used as a T.
+type OptionalType struct {
+	Elem Type
+}
+
+func (t OptionalType) String() string {
+	switch t.Elem.(type) {
+	case FunctionType, UnionType:
+		return "(" + t.Elem.String() + ")?"
+	}
+	return t.Elem.String() + "?"
+}
+
+// optional makes t nullable. Optionals are never nested and Any already
+// holds null.
+func optional(t Type) Type {
+	switch t {
+	case Any, Void, Null:
+		return t
+	}
+	if _, ok := t.(OptionalType); ok {
+		return t
+	}
+	return OptionalType{Elem: t}
+}
+
+// UnionType holds a value of any of Types. Build unions with unionOf,
+// which keeps them flat, without duplicates and without null, which is
+// expressed as an optional union instead.
+type UnionType struct {
+	Types []Type
+}
+
+func (t UnionType) String() string {
+	names := make([]string, 0, len(t.Types))
+	for _, member := range t.Types {
+		names = append(names, member.String())
+	}
+	return strings.Join(names, " | ")
+}
+
+func unionOf(types []Type) Type {
+	flat := make([]Type, 0, len(types))
+	nullable := false
+	for _, t := range types {
+		for _, member := range members(t) {
+			if member == Null {
+				nullable = true
+				continue
+			}
+			if member == Any {
+				return Any
+			}
+			duplicate := false
+			for _, existing := range flat {
+				if Identical(existing, member) {
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				flat = append(flat, member)
+			}
+		}
+	}
+
+	var union Type
+	switch len(flat) {
+	case 0:
+		return Null
+	case 1:
+		union = flat[0]
+	default:
+		union = UnionType{Types: flat}
+	}
+	if nullable {
+		return optional(union)
+	}
+	return union
+}
+
+// members lists the types a value of type t can have at run time, with
+// null as a member of optionals.
+func members(t Type) []Type {
+	switch t := t.(type) {
+	case UnionType:
+		return t.Types
+	case OptionalType:
+		return append(append([]Type{}, members(t.Elem)...), Null)
+	default:
+		return []Type{t}
+	}
+}
+
+// TypeofName is what typeof returns at run time for values of type t.
+func TypeofName(t Type) string {
+	switch t := t.(type) {
+	case ArrayType:
+		return "array"
+	case MapType:
+		return "map"
+	case FunctionType:
+		return "function"
+	case RangeType:
+		return "range"
+	case StructType:
+		if t.Name == "" {
+			return "struct"
+		}
+		return t.Name
+	case *ClassType:
+		return t.Name
+	default:
+		return t.String()
+	}
+}
+
+// RangeType is the type of a..b. Ranges only hold integers today, Elem
+// exists so iteration and membership do not have to assume it.
+type RangeType struct {
+	Elem Type
+}
+
+func (t RangeType) String() string { return "range" }
+
+var Range = RangeType{Elem: Int}
+
+type StructField struct {
+	Name string
+	Type Type
+}
+
+// StructType is nominal when Name is set: two named struct types are only
+// identical if they share a name.
+type StructType struct {
+	Name   string
+	Fields []StructField
+}
+
+func (t StructType) String() string {
+	if t.Name != "" {
+		return t.Name
+	}
+
+	fields := make([]string, 0, len(t.Fields))
+	for _, field := range t.Fields {
+		fields = append(fields, field.Name+": "+field.Type.String())
+	}
+	return "{ " + strings.Join(fields, ", ") + " }"
+}
+
+func (t StructType) Field(name string) (Type, bool) {
+	for _, field := range t.Fields {
+		if field.Name == name {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+// FunctionType describes a callable. When Variadic is set the last
+// parameter may be repeated any number of times, including zero. A generic
+// function lists its TypeParams, which calls instantiate.
+type FunctionType struct {
+	TypeParams []*TypeParam
+	Params     []Type
+	Return     Type
+	Variadic   bool
+}
+
+func (t FunctionType) String() string {
+	params := make([]string, 0, len(t.Params))
+	for i, param := range t.Params {
+		if t.Variadic && i == len(t.Params)-1 {
+			params = append(params, "..."+param.String())
+			continue
+		}
+		params = append(params, param.String())
+	}
+	return "fn" + typeParamList(t.TypeParams) + "(" + strings.Join(params, ", ") + "): " + t.Return.String()
+}
+
+// NamespaceType is the type of a built-in module such as fs or time.
+type NamespaceType struct {
+	Name    string
+	Members map[string]Type
+}
+
+func (t NamespaceType) String() string { return t.Name }
+
+// ClassType is shared by pointer so methods can refer to their own class
+// while it is still being declared. An instantiation such as Box<int> has
+// no members of its own: it reads those of generic with args substituted.
+type ClassType struct {
+	Name       string
+	TypeParams []*TypeParam
+	Fields     []StructField
+	Methods    map[string]FunctionType
+
+	generic *ClassType
+	args    []Type
+}
+
+func (t *ClassType) String() string {
+	if t.generic == nil {
+		return t.Name
+	}
+	args := make([]string, 0, len(t.args))
+	for _, arg := range t.args {
+		args = append(args, arg.String())
+	}
+	return t.Name + "<" + strings.Join(args, ", ") + ">"
+}
+
+func (t *ClassType) Field(name string) (Type, bool) {
+	if t.generic != nil {
+		field, exists := t.generic.Field(name)
+		if !exists {
+			return nil, false
+		}
+		return substitute(field, t.generic.bindings(t.args)), true
+	}
+	for _, field := range t.Fields {
+		if field.Name == name {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+func (t *ClassType) Method(name string) (FunctionType, bool) {
+	if t.generic != nil {
+		method, exists := t.generic.Method(name)
+		if !exists {
+			return FunctionType{}, false
+		}
+		return substitute(method, t.generic.bindings(t.args)).(FunctionType), true
+	}
+	method, exists := t.Methods[name]
+	return method, exists
+}
+
+// InterfaceType is satisfied structurally: any class or interface with
+// methods of the same names and types conforms, whether or not the class
+// declares implements.
+type InterfaceType struct {
+	Name    string
+	Methods map[string]FunctionType
+	// order of declaration, for messages
+	names []string
+}
+
+func (t *InterfaceType) String() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	methods := make([]string, 0, len(t.names))
+	for _, name := range t.names {
+		methods = append(methods, name+strings.TrimPrefix(t.Methods[name].String(), "fn"))
+	}
+	return "interface { " + strings.Join(methods, "; ") + " }"
+}
+
+// conforms reports why t does not conform to iface, or nil if it does.
+func conforms(iface *InterfaceType, t Type) error {
+	for _, name := range iface.names {
+		want := iface.Methods[name]
+		var got FunctionType
+		var exists bool
+		switch t := t.(type) {
+		case *ClassType:
+			got, exists = t.Method(name)
+		case *InterfaceType:
+			got, exists = t.Methods[name]
+		default:
+			return fmt.Errorf("%s has no methods", t)
+		}
+		if !exists {
+			return fmt.Errorf("missing method %s", name)
+		}
+		if !Identical(want, got) {
+			return fmt.Errorf("method %s has type %s, want %s", name, got, want)
+		}
+	}
+	return nil
+}
+
+type MapType struct {
+	Key   Type
+	Value Type
+}
+
+func (t MapType) String() string { return "map[" + t.Key.String() + "]" + t.Value.String() }
+
+func isNumeric(t Type) bool {
+	return t == Int || t == Float || t == Any
+}
+
+func isTimeType(t Type) bool {
+	return t == Time || t == Duration
+}
+
+// timeOperatorType types arithmetic and comparisons between Time and
+// Duration values: Time - Time is a Duration, Time ± Duration is a Time.
+func timeOperatorType(operator lexer.TokenKind, left, right Type) (Type, bool) {
+	switch operator {
+	case lexer.PLUS:
+		switch {
+		case left == Time && right == Duration, left == Duration && right == Time:
+			return Time, true
+		case left == Duration && right == Duration:
+			return Duration, true
+		}
+	case lexer.DASH:
+		switch {
+		case left == Time && right == Time:
+			return Duration, true
+		case left == Time && right == Duration:
+			return Time, true
+		case left == Duration && right == Duration:
+			return Duration, true
+		}
+	case lexer.STAR:
+		if left == Duration && (right == Int || right == Float) || right == Duration && (left == Int || left == Float) {
+			return Duration, true
+		}
+	case lexer.LESS, lexer.LESS_EQUALS, lexer.GREATER, lexer.GREATER_EQUALS, lexer.EQUALS, lexer.NOT_EQUALS:
+		if left == right {
+			return Bool, true
+		}
+	}
+	return nil, false
+}
+
+// isMember reports whether x in container is valid: elements of arrays
+// and ranges, keys of maps and substrings of strings.
+func isMember(element, container Type) bool {
+	switch t := container.(type) {
+	case ArrayType:
+		_, ok := commonType(t.Elem, element)
+		return ok
+	case RangeType:
+		return IsAssignable(t.Elem, element)
+	case MapType:
+		return IsAssignable(t.Key, element)
+	}
+	if container == String {
+		return IsAssignable(String, element)
+	}
+	return container == Any
+}
+
+func isComparable(t Type) bool {
+	if union, ok := t.(UnionType); ok {
+		for _, member := range union.Types {
+			if !isComparable(member) {
+				return false
+			}
+		}
+		return true
+	}
+	// Variants with a payload are distinct values, only plain enums can key maps
+	if enum, ok := t.(*EnumType); ok {
+		return !enum.hasPayload()
+	}
+	return t == Int || t == Float || t == String || t == Bool || t == Any
+}
+
+func Identical(a, b Type) bool {
+	return a.String() == b.String()
+}
+
+// IsAssignable reports whether a value of type from can be stored where
+// a value of type to is expected.
+func IsAssignable(to, from Type) bool {
+	if to == Any || from == Any {
+		return true
+	}
+	if to == Float && from == Int {
+		return true
+	}
+	// A T can be used wherever every type allowed by its constraint can
+	if param, ok := from.(*TypeParam); ok && param.Constraint != nil && !Identical(to, from) {
+		return IsAssignable(to, param.Constraint)
+	}
+	if fromUnion, ok := from.(UnionType); ok {
+		for _, member := range fromUnion.Types {
+			if !IsAssignable(to, member) {
+				return false
+			}
+		}
+		return true
+	}
+	if toUnion, ok := to.(UnionType); ok {
+		for _, member := range toUnion.Types {
+			if IsAssignable(member, from) {
+				return true
+			}
+		}
+		return false
+	}
+	if toOpt, ok := to.(OptionalType); ok {
+		if from == Null {
+			return true
+		}
+		if fromOpt, ok := from.(OptionalType); ok {
+			return IsAssignable(toOpt.Elem, fromOpt.Elem)
+		}
+		return IsAssignable(toOpt.Elem, from)
+	}
+	// Arrays are invariant: a []int used as a []float could be given a
+	// float through one and read as an int through the other
+	if toArr, ok := to.(ArrayType); ok {
+		if fromArr, ok := from.(ArrayType); ok {
+			return equivalent(toArr.Elem, fromArr.Elem)
+		}
+		return false
+	}
+	if iface, ok := to.(*InterfaceType); ok {
+		switch from.(type) {
+		case *ClassType, *InterfaceType:
+			return conforms(iface, from) == nil
+		}
+		return false
+	}
+	// Maps are invariant for the same reason as arrays
+	if toMap, ok := to.(MapType); ok {
+		if fromMap, ok := from.(MapType); ok {
+			return equivalent(toMap.Key, fromMap.Key) && equivalent(toMap.Value, fromMap.Value)
+		}
+		return false
+	}
+	return Identical(to, from)
+}
+
+// equivalent reports whether a and b hold the same values, which allows
+// any to stand for either and the members of a union to be in any order.
+func equivalent(a, b Type) bool {
+	return IsAssignable(a, b) && IsAssignable(b, a)
+}
+
+// assignable is IsAssignable for the value of expr, of type from. An
+// array or map literal is not shared with anything yet, so it takes the
+// type expected of it when each of its elements can: the literal of
+// let a: []float = [1, 2] holds floats.
+func (c *Checker) assignable(to Type, expr ast.Expr, from Type) bool {
+	if IsAssignable(to, from) {
+		return true
+	}
+	if opt, ok := to.(OptionalType); ok {
+		to = opt.Elem
+	}
+
+	fits := func(to Type, expr ast.Expr) bool {
+		t, checked := c.Types[expr.Span()]
+		return checked && c.assignable(to, expr, t)
+	}
+	switch literal := expr.(type) {
+	case ast.ArrayLiteralExpr:
+		array, ok := to.(ArrayType)
+		if !ok {
+			return false
+		}
+		for _, element := range literal.Elements {
+			if !fits(array.Elem, element) {
+				return false
+			}
+		}
+	case ast.MapLiteralExpr:
+		m, ok := to.(MapType)
+		if !ok {
+			return false
+		}
+		for _, entry := range literal.Entries {
+			if !fits(m.Key, entry.Key) || !fits(m.Value, entry.Value) {
+				return false
+			}
+		}
+	default:
+		return false
+	}
+	c.record(expr, to)
+	return true
+}
+
+// commonType returns the type both operands can be widened to.
+func commonType(a, b Type) (Type, bool) {
+	switch {
+	case a == Any || b == Any:
+		return Any, true
+	case a == Null && b != Null:
+		return optional(b), true
+	case b == Null && a != Null:
+		return optional(a), true
+	case IsAssignable(a, b):
+		return a, true
+	case IsAssignable(b, a):
+		return b, true
+	default:
+		return nil, false
+	}
+}
+
+// ResolveType converts a parsed type expression into a checker type
+// using the types visible in the global scope.
+func (c *Checker) ResolveType(t ast.Type) (Type, error) {
+	return c.resolveType(t)
+}
+
+func (c *Checker) resolveType(t ast.Type) (Type, error) {
+	switch t := t.(type) {
+	case ast.SymbolType:
+		switch t.Name {
+		case "int":
+			return Int, nil
+		case "float":
+			return Float, nil
+		case "string":
+			return String, nil
+		case "bool":
+			return Bool, nil
+		case "Time":
+			return Time, nil
+		case "Duration":
+			return Duration, nil
+		case "range":
+			return Range, nil
+		default:
+			declared, exists := c.scope.lookupType(t.Name)
+			if !exists {
+				return nil, fmt.Errorf("unknown type: %s", t.Name)
+			}
+			if class, ok := declared.(*ClassType); ok && len(class.TypeParams) > 0 {
+				return nil, fmt.Errorf("generic type %s needs type arguments, as in %s%s", t.Name, t.Name, typeParamList(class.TypeParams))
+			}
+			return declared, nil
+		}
+	case ast.GenericType:
+		return c.resolveGenericType(t)
+	case ast.InterfaceType:
+		iface := &InterfaceType{Methods: make(map[string]FunctionType)}
+		return iface, c.declareInterfaceMethods(iface, t)
+	case ast.ArrayType:
+		elem, err := c.resolveType(t.Underlying)
+		if err != nil {
+			return nil, err
+		}
+		return ArrayType{Elem: elem}, nil
+	case ast.MapType:
+		key, err := c.resolveType(t.Key)
+		if err != nil {
+			return nil, err
+		}
+		if !isComparable(key) {
+			return nil, fmt.Errorf("invalid map key type: %s", key)
+		}
+		value, err := c.resolveType(t.Value)
+		if err != nil {
+			return nil, err
+		}
+		return MapType{Key: key, Value: value}, nil
+	case ast.StructType:
+		fields := make([]StructField, 0, len(t.Fields))
+		seen := make(map[string]bool)
+		for _, field := range t.Fields {
+			if seen[field.Name] {
+				return nil, fmt.Errorf("duplicate field %s", field.Name)
+			}
+			seen[field.Name] = true
+
+			fieldType, err := c.resolveType(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, StructField{Name: field.Name, Type: fieldType})
+		}
+		return StructType{Fields: fields}, nil
+	case ast.FunctionType:
+		params := make([]Type, 0, len(t.Params))
+		for _, param := range t.Params {
+			paramType, err := c.resolveType(param)
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, paramType)
+		}
+
+		var result Type = Void
+		if t.Return != nil {
+			returnType, err := c.resolveType(t.Return)
+			if err != nil {
+				return nil, err
+			}
+			result = returnType
+		}
+		return FunctionType{Params: params, Return: result}, nil
+	case ast.OptionalType:
+		elem, err := c.resolveType(t.Underlying)
+		if err != nil {
+			return nil, err
+		}
+		return optional(elem), nil
+	case ast.UnionType:
+		types := make([]Type, 0, len(t.Types))
+		for _, member := range t.Types {
+			memberType, err := c.resolveType(member)
+			if err != nil {
+				return nil, err
+			}
+			if memberType == Void {
+				return nil, fmt.Errorf("void cannot be part of a union")
+			}
+			types = append(types, memberType)
+		}
+		return unionOf(types), nil
+	default:
+		return nil, fmt.Errorf("unknown type expression: %T", t)
+	}
+}
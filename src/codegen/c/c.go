@@ -0,0 +1,660 @@
+// Package c translates IR modules to C99 meant to be read as well as
+// compiled. Control flow comes out as if, while and for statements
+// recovered by ir.Structure, with goto only where a jump crosses more
+// than one of them. Values are variables named after their IR value, so
+// the C reads alongside the --dump-ir output, while constants and values
+// used once right where they are computed are written inline.
+//
+// The generated file includes RuntimeHeader, which must be written next
+// to it with the contents of Runtime. Signed arithmetic wraps around in
+// the language, so the C must be compiled with -fwrapv.
+package c
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/RyanOliveira00/go-compiler/src/ir"
+)
+
+// RuntimeHeader names the header the generated code includes.
+const RuntimeHeader = "lang_runtime.h"
+
+// Runtime is the contents of RuntimeHeader.
+//
+//go:embed lang_runtime.h
+var Runtime string
+
+// Generate translates m to C. source names the program in the comment
+// heading the output.
+func Generate(m *ir.Module, source string) (string, error) {
+	var out strings.Builder
+	fmt.Fprintf(&out, "/* Generated from %s. */\n\n#include \"%s\"\n", source, RuntimeHeader)
+
+	if len(m.Globals) > 0 {
+		out.WriteString("\n")
+		for _, global := range m.Globals {
+			fmt.Fprintf(&out, "static %s = %s;\n", declarator(global.Type, "g_"+global.Name), zero(global.Type))
+		}
+	}
+	if len(m.Funcs) > 0 {
+		out.WriteString("\n")
+		for _, f := range m.Funcs {
+			fmt.Fprintf(&out, "%s;\n", signature(f))
+		}
+	}
+
+	for _, f := range m.AllFuncs() {
+		stmts, err := ir.Structure(f)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString("\n")
+		newFunction(f).write(&out, stmts)
+	}
+	return out.String(), nil
+}
+
+func signature(f *ir.Func) string {
+	if f.Name == ir.MainName {
+		return "int main(void)"
+	}
+
+	params := make([]string, 0, len(f.Params))
+	for _, param := range f.Params {
+		params = append(params, declarator(param.Type, param.String()))
+	}
+	if len(params) == 0 {
+		params = append(params, "void")
+	}
+
+	result := "void"
+	if f.Return != ir.Void {
+		result = typeName(f.Return)
+	}
+	return fmt.Sprintf("static %s fn_%s(%s)", result, f.Name, strings.Join(params, ", "))
+}
+
+func typeName(t ir.Type) string {
+	switch t {
+	case ir.Int:
+		return "int64_t"
+	case ir.Float:
+		return "double"
+	case ir.Bool:
+		return "bool"
+	case ir.String:
+		return "const char *"
+	}
+	panic(fmt.Sprintf("c: no C type for %s", t))
+}
+
+// declarator declares name as a t.
+func declarator(t ir.Type, name string) string {
+	if t == ir.String {
+		return "const char *" + name
+	}
+	return typeName(t) + " " + name
+}
+
+func zero(t ir.Type) string {
+	switch t {
+	case ir.Float:
+		return "0.0"
+	case ir.Bool:
+		return "false"
+	case ir.String:
+		return `""`
+	default:
+		return "0"
+	}
+}
+
+// suffix names the runtime functions handling values of type t.
+func suffix(t ir.Type) string {
+	return t.String()
+}
+
+// line is a line of output. Lines with a label are only written when
+// some goto jumps to it.
+type line struct {
+	depth int
+	text  string
+	label *ir.Block
+}
+
+// exit says where control goes when it falls off the end of statements:
+// the code of block, reached by a jump of kind, or nowhere for a nil
+// block.
+type exit struct {
+	block *ir.Block
+	kind  ir.JumpKind
+}
+
+// loop is a C loop being written: continue restarts header, break goes
+// to after.
+type loop struct {
+	header *ir.Block
+	after  exit
+}
+
+type function struct {
+	f      *ir.Func
+	uses   []int
+	inline []bool
+
+	lines  []line
+	depth  int
+	loops  []loop
+	labels map[*ir.Block]bool // the targets of a goto
+	vars   map[*ir.Value]bool // the values declared as variables
+	temps  map[ir.Type]bool
+}
+
+func newFunction(f *ir.Func) *function {
+	fn := &function{
+		f:      f,
+		depth:  1,
+		labels: make(map[*ir.Block]bool),
+		vars:   make(map[*ir.Value]bool),
+		temps:  make(map[ir.Type]bool),
+	}
+	fn.uses, fn.inline = ir.Inline(f)
+	return fn
+}
+
+// write writes the function, with the variables it uses declared first.
+func (fn *function) write(out *strings.Builder, stmts []ir.Stmt) {
+	fn.stmts(stmts, exit{})
+
+	fmt.Fprintf(out, "%s {\n", signature(fn.f))
+	if decls := fn.declarations(); len(decls) > 0 {
+		for _, decl := range decls {
+			fmt.Fprintf(out, "    %s;\n", decl)
+		}
+		out.WriteString("\n")
+	}
+	// A void function returns at its end anyway
+	if last := len(fn.lines) - 1; last >= 0 && fn.lines[last] == (line{depth: 1, text: "return;"}) {
+		fn.lines = fn.lines[:last]
+	}
+
+	written := make(map[*ir.Block]bool)
+	for _, l := range fn.lines {
+		if l.label != nil {
+			if !fn.labels[l.label] || written[l.label] {
+				continue
+			}
+			written[l.label] = true
+			fmt.Fprintf(out, "%s%s:;\n", strings.Repeat("    ", l.depth), l.label)
+			continue
+		}
+		fmt.Fprintf(out, "%s%s\n", strings.Repeat("    ", l.depth), l.text)
+	}
+	out.WriteString("}\n")
+}
+
+func (fn *function) declarations() []string {
+	byType := make(map[ir.Type][]*ir.Value)
+	for v := range fn.vars {
+		byType[v.Type] = append(byType[v.Type], v)
+	}
+
+	var decls []string
+	for _, t := range []ir.Type{ir.Int, ir.Float, ir.Bool, ir.String} {
+		values := byType[t]
+		sort.Slice(values, func(i, j int) bool { return values[i].ID < values[j].ID })
+		var names []string
+		for _, v := range values {
+			names = append(names, v.String())
+		}
+		if fn.temps[t] {
+			names = append(names, "tmp_"+t.String())
+		}
+		if len(names) == 0 {
+			continue
+		}
+		if t == ir.String {
+			decls = append(decls, "const char *"+strings.Join(names, ", *"))
+		} else {
+			decls = append(decls, typeName(t)+" "+strings.Join(names, ", "))
+		}
+	}
+	return decls
+}
+
+func (fn *function) emit(format string, args ...interface{}) {
+	fn.lines = append(fn.lines, line{depth: fn.depth, text: fmt.Sprintf(format, args...)})
+}
+
+func (fn *function) label(b *ir.Block) {
+	fn.lines = append(fn.lines, line{depth: fn.depth, label: b})
+}
+
+// capture returns the lines written by write one level deeper, without
+// adding them to the output.
+func (fn *function) capture(write func()) []line {
+	saved := fn.lines
+	fn.lines = nil
+	fn.depth++
+	write()
+	captured := fn.lines
+	fn.depth--
+	fn.lines = saved
+	return captured
+}
+
+// add adds captured lines to the output, shifted by shift levels.
+func (fn *function) add(lines []line, shift int) {
+	for _, l := range lines {
+		l.depth += shift
+		fn.lines = append(fn.lines, l)
+	}
+}
+
+func (fn *function) stmts(stmts []ir.Stmt, next exit) {
+	for i, stmt := range stmts {
+		// Only the last statement can fall off the end, the others are
+		// Code and Scopes followed by the code of their Follow
+		if i < len(stmts)-1 {
+			fn.stmt(stmt, exit{})
+		} else {
+			fn.stmt(stmt, next)
+		}
+	}
+}
+
+func (fn *function) stmt(stmt ir.Stmt, next exit) {
+	switch s := stmt.(type) {
+	case *ir.Code:
+		fn.code(s.Block)
+	case *ir.If:
+		fn.ifStmt(s, next)
+	case *ir.Loop:
+		fn.loop(s, next)
+	case *ir.Scope:
+		fn.stmts(s.Body, exit{s.Follow, ir.JumpBreak})
+		fn.label(s.Follow)
+	case *ir.Jump:
+		fn.jump(s, next)
+	case *ir.Return:
+		switch {
+		case fn.f.Name == ir.MainName:
+			fn.emit("return 0;")
+		case s.Value == nil:
+			fn.emit("return;")
+		default:
+			fn.emit("return %s;", fn.arg(s.Value))
+		}
+	}
+}
+
+func (fn *function) code(b *ir.Block) {
+	for _, v := range b.Values {
+		switch {
+		case v.Op == ir.OpConst || v.Op == ir.OpPhi || v.Op == ir.OpParam || fn.inline[v.ID]:
+			// written where they are used
+		case v.Op == ir.OpStore:
+			fn.emit("g_%s = %s;", v.Aux, fn.arg(v.Args[0]))
+		case v.Op == ir.OpPrint:
+			fn.print(v.Args)
+		case fn.uses[v.ID] > 0:
+			fn.vars[v] = true
+			fn.emit("%s = %s;", v, fn.expr(v))
+		case v.Op.HasSideEffects():
+			fn.emit("%s;", fn.expr(v))
+		}
+	}
+}
+
+func (fn *function) print(args []*ir.Value) {
+	if len(args) == 0 {
+		fn.emit(`putchar('\n');`)
+		return
+	}
+	for i, arg := range args {
+		if i == len(args)-1 {
+			fn.emit("lang_print_%s(%s);", suffix(arg.Type), fn.arg(arg))
+			break
+		}
+		fn.emit("lang_write_%s(%s);", suffix(arg.Type), fn.arg(arg))
+		fn.emit(`lang_write_string(" ");`)
+	}
+}
+
+func (fn *function) ifStmt(s *ir.If, next exit) {
+	then := fn.capture(func() { fn.stmts(s.Then, next) })
+	otherwise := fn.capture(func() { fn.stmts(s.Else, next) })
+
+	switch {
+	case len(then) == 0 && len(otherwise) == 0:
+	case len(otherwise) == 0:
+		fn.block("if (%s) {", then, fn.arg(s.Cond))
+	case len(then) == 0:
+		fn.block("if (%s) {", otherwise, fn.negation(s.Cond))
+	// A branch ending in a jump reads better on its own, followed by
+	// the other one
+	case leaves(then):
+		fn.block("if (%s) {", then, fn.arg(s.Cond))
+		fn.add(otherwise, -1)
+	case leaves(otherwise):
+		fn.block("if (%s) {", otherwise, fn.negation(s.Cond))
+		fn.add(then, -1)
+	default:
+		fn.emit("if (%s) {", fn.arg(s.Cond))
+		fn.add(then, 0)
+		fn.emit("} else {")
+		fn.add(otherwise, 0)
+		fn.emit("}")
+	}
+}
+
+// block writes a statement opening with header and enclosing body.
+func (fn *function) block(header string, body []line, args ...interface{}) {
+	fn.emit(header, args...)
+	fn.add(body, 0)
+	fn.emit("}")
+}
+
+// leaves reports whether lines end in a jump, never falling off the end.
+func leaves(lines []line) bool {
+	if len(lines) == 0 {
+		return false
+	}
+	last := lines[len(lines)-1]
+	if last.depth != lines[0].depth {
+		return false
+	}
+	for _, jump := range []string{"return", "break;", "continue;", "goto "} {
+		if strings.HasPrefix(last.text, jump) {
+			return true
+		}
+	}
+	return false
+}
+
+func (fn *function) loop(s *ir.Loop, next exit) {
+	fn.loops = append(fn.loops, loop{header: s.Header, after: next})
+	defer func() { fn.loops = fn.loops[:len(fn.loops)-1] }()
+
+	fn.label(s.Header)
+	if cond, body, ok := fn.whileForm(s, next); ok {
+		lines := fn.capture(func() { fn.stmts(body, exit{s.Header, ir.JumpContinue}) })
+		fn.block("while (%s) {", lines, cond)
+		return
+	}
+	lines := fn.capture(func() { fn.stmts(s.Body, exit{s.Header, ir.JumpContinue}) })
+	fn.block("for (;;) {", lines)
+}
+
+// whileForm recognizes loops whose header only tests a condition, leaving
+// the loop when it fails, and returns the condition and the rest of the
+// body.
+func (fn *function) whileForm(s *ir.Loop, next exit) (string, []ir.Stmt, bool) {
+	if len(s.Body) != 2 {
+		return "", nil, false
+	}
+	code, ok := s.Body[0].(*ir.Code)
+	if !ok || len(fn.capture(func() { fn.code(code.Block) })) > 0 {
+		return "", nil, false
+	}
+	branch, ok := s.Body[1].(*ir.If)
+	if !ok {
+		return "", nil, false
+	}
+
+	leaving := func(stmts []ir.Stmt) bool {
+		if len(stmts) != 1 {
+			return false
+		}
+		jump, ok := stmts[0].(*ir.Jump)
+		return ok && jump.Kind == ir.JumpBreak && next == exit{jump.To, ir.JumpBreak} &&
+			len(moves(jump.From, jump.To)) == 0
+	}
+	switch {
+	case leaving(branch.Else):
+		return fn.arg(branch.Cond), branch.Then, true
+	case leaving(branch.Then):
+		return fn.negation(branch.Cond), branch.Else, true
+	}
+	return "", nil, false
+}
+
+func (fn *function) jump(j *ir.Jump, next exit) {
+	fn.moves(j.From, j.To)
+	if j.Kind == ir.JumpNext || next == (exit{j.To, j.Kind}) {
+		return
+	}
+
+	if len(fn.loops) > 0 {
+		inner := fn.loops[len(fn.loops)-1]
+		if j.Kind == ir.JumpContinue && inner.header == j.To {
+			fn.emit("continue;")
+			return
+		}
+		if j.Kind == ir.JumpBreak && inner.after == (exit{j.To, ir.JumpBreak}) {
+			fn.emit("break;")
+			return
+		}
+	}
+	fn.labels[j.To] = true
+	fn.emit("goto %s;", j.To)
+}
+
+// move sets the phi dst to src, which is a value or, when value is nil,
+// the temporary holding it.
+type move struct {
+	dst   *ir.Value
+	value *ir.Value
+	src   string
+}
+
+func moves(from, to *ir.Block) []move {
+	i := to.PredIndex(from)
+	var moves []move
+	for _, phi := range ir.PhiMoves(from, to) {
+		moves = append(moves, move{dst: phi, value: phi.Args[i]})
+	}
+	return moves
+}
+
+// moves assigns the phis of to for the edge from from, all at once: a
+// phi read by another assignment is only set after it, using a
+// temporary when phis read each other in a cycle.
+func (fn *function) moves(from, to *ir.Block) {
+	pending := moves(from, to)
+	for i := range pending {
+		fn.vars[pending[i].dst] = true
+		pending[i].src = fn.arg(pending[i].value)
+	}
+
+	for len(pending) > 0 {
+		ready := -1
+		for i, m := range pending {
+			if !readBy(pending, i, m.dst) {
+				ready = i
+				break
+			}
+		}
+		if ready < 0 {
+			// Every remaining phi is read by another, save one of them
+			m := pending[0]
+			temp := "tmp_" + m.dst.Type.String()
+			fn.temps[m.dst.Type] = true
+			fn.emit("%s = %s;", temp, m.dst)
+			for i := range pending {
+				if pending[i].value == m.dst {
+					pending[i].value, pending[i].src = nil, temp
+				}
+			}
+			continue
+		}
+
+		m := pending[ready]
+		fn.emit("%s = %s;", m.dst, m.src)
+		pending = append(pending[:ready], pending[ready+1:]...)
+	}
+}
+
+// readBy reports whether a move other than the i-th one reads v.
+func readBy(pending []move, i int, v *ir.Value) bool {
+	for j, m := range pending {
+		if j != i && m.value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// arg writes v where no parentheses are needed around it, such as a
+// function argument.
+func (fn *function) arg(v *ir.Value) string {
+	switch {
+	case v.Op == ir.OpConst:
+		return literal(v)
+	case fn.inline[v.ID]:
+		return fn.expr(v)
+	default:
+		return v.String()
+	}
+}
+
+// operand writes v as the operand of an operator.
+func (fn *function) operand(v *ir.Value) string {
+	text := fn.arg(v)
+	switch {
+	case fn.inline[v.ID] && (v.Op != ir.OpConcat && v.Op != ir.OpIntToFloat && v.Op != ir.OpCopy):
+		return "(" + text + ")"
+	case v.Op == ir.OpConst && strings.HasPrefix(text, "-"):
+		return "(" + text + ")"
+	}
+	return text
+}
+
+var operators = map[ir.Op]string{
+	ir.OpAdd: "+",
+	ir.OpSub: "-",
+	ir.OpMul: "*",
+	ir.OpEq:  "==",
+	ir.OpNe:  "!=",
+	ir.OpLt:  "<",
+	ir.OpLe:  "<=",
+	ir.OpGt:  ">",
+	ir.OpGe:  ">=",
+}
+
+// expr writes the operation computing v.
+func (fn *function) expr(v *ir.Value) string {
+	args := v.Args
+	switch v.Op {
+	case ir.OpConst:
+		return literal(v)
+	case ir.OpCopy:
+		return fn.arg(args[0])
+	case ir.OpAdd, ir.OpSub, ir.OpMul:
+		return fn.operand(args[0]) + " " + operators[v.Op] + " " + fn.operand(args[1])
+	case ir.OpDiv, ir.OpMod:
+		name := map[ir.Op]string{ir.OpDiv: "div", ir.OpMod: "mod"}[v.Op]
+		if v.Type == ir.Float {
+			name = "f" + name
+		}
+		return fmt.Sprintf("lang_%s(%s, %s)", name, fn.arg(args[0]), fn.arg(args[1]))
+	case ir.OpNeg:
+		return "-" + fn.operand(args[0])
+	case ir.OpConcat:
+		return fmt.Sprintf("lang_concat(%s, %s)", fn.arg(args[0]), fn.arg(args[1]))
+	case ir.OpIntToFloat:
+		return "(double)" + fn.operand(args[0])
+	case ir.OpEq, ir.OpNe, ir.OpLt, ir.OpLe, ir.OpGt, ir.OpGe:
+		if args[0].Type == ir.String {
+			return fmt.Sprintf("strcmp(%s, %s) %s 0", fn.arg(args[0]), fn.arg(args[1]), operators[v.Op])
+		}
+		return fn.operand(args[0]) + " " + operators[v.Op] + " " + fn.operand(args[1])
+	case ir.OpNot:
+		return "!" + fn.operand(args[0])
+	case ir.OpCall:
+		list := make([]string, 0, len(args))
+		for _, arg := range args {
+			list = append(list, fn.arg(arg))
+		}
+		return fmt.Sprintf("fn_%s(%s)", v.Aux, strings.Join(list, ", "))
+	case ir.OpLoad:
+		return "g_" + v.Aux
+	case ir.OpRead:
+		return fmt.Sprintf("lang_read_%s()", suffix(v.Type))
+	}
+	panic(fmt.Sprintf("c: cannot write %s", v.LongString()))
+}
+
+// negation writes the negation of the bool v, flipping a comparison
+// written inline.
+func (fn *function) negation(v *ir.Value) string {
+	if flipped, ok := ir.Negation(v); ok && fn.inline[v.ID] {
+		return fn.expr(flipped)
+	}
+	if v.Op == ir.OpNot && fn.inline[v.ID] {
+		return fn.arg(v.Args[0])
+	}
+	return "!" + fn.operand(v)
+}
+
+func literal(v *ir.Value) string {
+	switch v.Type {
+	case ir.Float:
+		f := v.AuxFloat
+		switch {
+		case math.IsNaN(f):
+			return "NAN"
+		case math.IsInf(f, 1):
+			return "INFINITY"
+		case math.IsInf(f, -1):
+			return "-INFINITY"
+		}
+		text := strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(text, ".e") {
+			text += ".0"
+		}
+		return text
+	case ir.Bool:
+		return strconv.FormatBool(v.AuxInt != 0)
+	case ir.String:
+		return quote(v.AuxString)
+	default:
+		if v.AuxInt == math.MinInt64 {
+			return "INT64_MIN"
+		}
+		return strconv.FormatInt(v.AuxInt, 10)
+	}
+}
+
+// quote writes s as a C string literal, escaping everything but printable
+// ASCII. Octal escapes have three digits so a digit following one is not
+// read as part of it.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c < ' ' || c > '~':
+			fmt.Fprintf(&b, "\\%03o", c)
+		case c == '?':
+			// avoids trigraphs
+			b.WriteString(`\?`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package c_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cgen "github.com/RyanOliveira00/go-compiler/src/codegen/c"
+	"github.com/RyanOliveira00/go-compiler/src/internal/langtest"
+	"github.com/RyanOliveira00/go-compiler/src/ir"
+)
+
+// TestGenerate compares the translations of the programs of the IR with
+// the .c files of testdata.
+func TestGenerate(t *testing.T) {
+	for _, p := range langtest.Programs(t, "") {
+		t.Run(p.Name, func(t *testing.T) {
+			source, err := cgen.Generate(langtest.Lower(t, p, ir.NewPassManager()), p.Name+".lang")
+			if err != nil {
+				t.Fatal(err)
+			}
+			langtest.Golden(t, filepath.Join("testdata", p.Name+".c"), source)
+		})
+	}
+}
+
+// TestRun compiles the translations of the programs of the IR with $CC,
+// or cc, when there is one, optimized by every pass and by none, and
+// compares what they print with the interpreter.
+func TestRun(t *testing.T) {
+	cc := os.Getenv("CC")
+	if cc == "" {
+		cc = "cc"
+	}
+	if _, err := exec.LookPath(cc); err != nil {
+		t.Skipf("%s is not installed", cc)
+	}
+
+	for _, p := range langtest.Programs(t, "") {
+		want := langtest.Interpret(t, p)
+		for _, optimized := range []bool{true, false} {
+			name := p.Name + "/optimized"
+			if !optimized {
+				name = p.Name + "/unoptimized"
+			}
+			t.Run(name, func(t *testing.T) {
+				pm := ir.NewPassManager()
+				for _, pass := range ir.PassNames() {
+					pm.Enable(pass, optimized)
+				}
+				source, err := cgen.Generate(langtest.Lower(t, p, pm), p.Name+".lang")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				dir := t.TempDir()
+				file, executable := filepath.Join(dir, p.Name+".c"), filepath.Join(dir, p.Name)
+				if err := os.WriteFile(file, []byte(source), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, cgen.RuntimeHeader), []byte(cgen.Runtime), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				compile := exec.Command(cc, "-std=c99", "-O2", "-fwrapv", "-o", executable, file, "-lm")
+				if out, err := compile.CombinedOutput(); err != nil {
+					t.Fatalf("%s: %s\n%s", cc, err, out)
+				}
+
+				cmd := exec.Command(executable)
+				cmd.Stdin = strings.NewReader(p.Input)
+				got, err := cmd.CombinedOutput()
+				if err != nil {
+					t.Fatalf("%s\n%s", err, got)
+				}
+				if string(got) != want {
+					t.Errorf("output differs from the interpreter's:\n%s", langtest.Diff(want, string(got)))
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,200 @@
+/*
+ * Runtime of the C code generated from .lang programs: printing values
+ * the way the interpreter does, reading input, string concatenation and
+ * the checks of operations failing at run time.
+ *
+ * Strings are never freed, like every value of the language.
+ */
+#ifndef LANG_RUNTIME_H
+#define LANG_RUNTIME_H
+
+#include <ctype.h>
+#include <errno.h>
+#include <inttypes.h>
+#include <math.h>
+#include <stdbool.h>
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+static inline void lang_fail(const char *message) {
+    fflush(stdout);
+    fprintf(stderr, "Error: %s\n", message);
+    exit(1);
+}
+
+/* Int division truncates; dividing the smallest int by -1 wraps. */
+static inline int64_t lang_div(int64_t a, int64_t b) {
+    if (b == 0) {
+        lang_fail("division by zero");
+    }
+    if (b == -1) {
+        return (int64_t)(0 - (uint64_t)a);
+    }
+    return a / b;
+}
+
+static inline int64_t lang_mod(int64_t a, int64_t b) {
+    if (b == 0) {
+        lang_fail("division by zero");
+    }
+    if (b == -1) {
+        return 0;
+    }
+    return a % b;
+}
+
+static inline double lang_fdiv(double a, double b) {
+    if (b == 0) {
+        lang_fail("division by zero");
+    }
+    return a / b;
+}
+
+static inline double lang_fmod(double a, double b) {
+    if (b == 0) {
+        lang_fail("division by zero");
+    }
+    return fmod(a, b);
+}
+
+static inline const char *lang_concat(const char *a, const char *b) {
+    size_t n = strlen(a), m = strlen(b);
+    char *s = malloc(n + m + 1);
+    if (s == NULL) {
+        lang_fail("out of memory");
+    }
+    memcpy(s, a, n);
+    memcpy(s + n, b, m + 1);
+    return s;
+}
+
+static inline void lang_write_int(int64_t x) {
+    printf("%" PRId64, x);
+}
+
+/*
+ * Floats print with the fewest digits reading back as the same value,
+ * in exponent notation below 1e-4 and from 1e6 on.
+ */
+static inline void lang_write_float(double x) {
+    char text[32];
+    int digits, exponent;
+
+    if (isnan(x)) {
+        fputs("NaN", stdout);
+        return;
+    }
+    if (isinf(x)) {
+        fputs(x > 0 ? "+Inf" : "-Inf", stdout);
+        return;
+    }
+    for (digits = 1; digits < 17; digits++) {
+        snprintf(text, sizeof text, "%.*e", digits - 1, x);
+        if (strtod(text, NULL) == x) {
+            break;
+        }
+    }
+    snprintf(text, sizeof text, "%.*e", digits - 1, x);
+    exponent = atoi(strchr(text, 'e') + 1);
+    if (exponent < -4 || exponent >= 6) {
+        fputs(text, stdout);
+    } else {
+        printf("%.*f", digits - 1 > exponent ? digits - 1 - exponent : 0, x);
+    }
+}
+
+static inline void lang_write_bool(bool x) {
+    fputs(x ? "true" : "false", stdout);
+}
+
+static inline void lang_write_string(const char *s) {
+    fputs(s, stdout);
+}
+
+static inline void lang_print_int(int64_t x) {
+    lang_write_int(x);
+    putchar('\n');
+}
+
+static inline void lang_print_float(double x) {
+    lang_write_float(x);
+    putchar('\n');
+}
+
+static inline void lang_print_bool(bool x) {
+    lang_write_bool(x);
+    putchar('\n');
+}
+
+static inline void lang_print_string(const char *s) {
+    lang_write_string(s);
+    putchar('\n');
+}
+
+/* lang_read_word reads a line and returns its first word. */
+static inline char *lang_read_word(void) {
+    static char line[4096];
+    char *word, *end;
+
+    if (fgets(line, sizeof line, stdin) == NULL) {
+        line[0] = '\0';
+    }
+    for (word = line; isspace((unsigned char)*word); word++) {
+    }
+    for (end = word; *end != '\0' && !isspace((unsigned char)*end); end++) {
+    }
+    *end = '\0';
+    return word;
+}
+
+static inline int64_t lang_read_int(void) {
+    const char *word = lang_read_word();
+    char *end;
+    long long x;
+
+    errno = 0;
+    x = strtoll(word, &end, 10);
+    if (*word == '\0' || *end != '\0' || errno == ERANGE) {
+        lang_fail("invalid input for type");
+    }
+    return (int64_t)x;
+}
+
+static inline double lang_read_float(void) {
+    const char *word = lang_read_word();
+    char *end;
+    double x;
+
+    errno = 0;
+    x = strtod(word, &end);
+    if (*word == '\0' || *end != '\0' || errno == ERANGE) {
+        lang_fail("invalid input for type");
+    }
+    return x;
+}
+
+static inline bool lang_read_bool(void) {
+    static const char *const truths[] = {"1", "t", "T", "true", "TRUE", "True"};
+    static const char *const lies[] = {"0", "f", "F", "false", "FALSE", "False"};
+    const char *word = lang_read_word();
+    size_t i;
+
+    for (i = 0; i < sizeof truths / sizeof truths[0]; i++) {
+        if (strcmp(word, truths[i]) == 0) {
+            return true;
+        }
+        if (strcmp(word, lies[i]) == 0) {
+            return false;
+        }
+    }
+    lang_fail("invalid input for type");
+    return false;
+}
+
+static inline const char *lang_read_string(void) {
+    return lang_concat(lang_read_word(), "");
+}
+
+#endif
@@ -0,0 +1,17 @@
+/* Generated from copyprop.lang. */
+
+#include "lang_runtime.h"
+
+int main(void) {
+    lang_write_int(7);
+    lang_write_string(" ");
+    lang_write_string("concat");
+    lang_write_string(" ");
+    lang_write_float(3.5);
+    lang_write_string(" ");
+    lang_print_int(-2);
+    lang_write_int(4);
+    lang_write_string(" ");
+    lang_print_bool(true);
+    return 0;
+}
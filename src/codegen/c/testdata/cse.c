@@ -0,0 +1,28 @@
+/* Generated from cse.lang. */
+
+#include "lang_runtime.h"
+
+static int64_t fn_area(int64_t v0, int64_t v1);
+
+static int64_t fn_area(int64_t v0, int64_t v1) {
+    int64_t v2, v4, v7;
+
+    v2 = v0 * v1;
+    v4 = v2 + 1;
+    v7 = v2 + 2;
+    if (v0 > v1) {
+        return (v4 * v7) - v2;
+    }
+    return v4 + v7;
+}
+
+int main(void) {
+    int64_t v2, v5;
+
+    v2 = fn_area(3, 4);
+    v5 = fn_area(5, 2);
+    lang_write_int(v2);
+    lang_write_string(" ");
+    lang_print_int(v5);
+    return 0;
+}
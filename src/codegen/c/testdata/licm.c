@@ -0,0 +1,38 @@
+/* Generated from licm.lang. */
+
+#include "lang_runtime.h"
+
+static int64_t g_g = 0;
+
+static int64_t fn_bump(void);
+
+static int64_t fn_bump(void) {
+    int64_t v0, v4, v8;
+
+    v0 = g_g;
+    g_g = v0 + 1;
+    v4 = g_g;
+    g_g = v4 + 1;
+    v8 = g_g;
+    return v8;
+}
+
+int main(void) {
+    int64_t v7, v20, v22, v25, v31;
+
+    g_g = 0;
+    v20 = 0;
+    v7 = 0;
+    while (v7 < 3) {
+        v22 = v20 + 31;
+        v25 = v7 + 1;
+        v20 = v22;
+        v7 = v25;
+    }
+    g_g = 5;
+    v31 = fn_bump();
+    lang_write_int(v31);
+    lang_write_string(" ");
+    lang_print_int(v20);
+    return 0;
+}
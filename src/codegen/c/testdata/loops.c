@@ -0,0 +1,107 @@
+/* Generated from loops.lang. */
+
+#include "lang_runtime.h"
+
+static int64_t g_n = 0;
+
+static int64_t fn_fib(int64_t v0);
+static int64_t fn_slow(int64_t v0);
+
+static int64_t fn_fib(int64_t v0) {
+    int64_t v4, v7, v8, v9, v11;
+
+    v8 = 1;
+    v7 = 0;
+    v4 = 0;
+    while (v4 < v0) {
+        v9 = v7 + v8;
+        v11 = v4 + 1;
+        v7 = v8;
+        v8 = v9;
+        v4 = v11;
+    }
+    return v7;
+}
+
+static int64_t fn_slow(int64_t v0) {
+    int64_t v5, v8;
+
+    if (v0 < 2) {
+        return v0;
+    }
+    v5 = fn_slow(v0 - 1);
+    v8 = fn_slow(v0 - 2);
+    return v5 + v8;
+}
+
+int main(void) {
+    int64_t v2, v4, v5, v15, v30, v36, v44, v47, v51, v55, v56, v58, v59, v61, v69;
+    double v63, v65;
+    bool v25, v26;
+
+    v2 = 0;
+    while (v2 <= 10) {
+        v4 = fn_fib(v2);
+        v5 = fn_slow(v2);
+        lang_write_int(v2);
+        lang_write_string(" ");
+        lang_write_int(v4);
+        lang_write_string(" ");
+        lang_print_int(v5);
+        v2 = v2 + 5;
+    }
+    g_n = 0;
+    v63 = 1.0;
+    v15 = 10;
+    while (v15 > 0) {
+        if (v15 == 4) {
+            v26 = true;
+        } else {
+            if (v15 > 7) {
+                v25 = v15 < 9;
+            } else {
+                v25 = false;
+            }
+            v26 = v25;
+        }
+        if (v26) {
+            v65 = (double)v15;
+        } else {
+            v65 = v63;
+        }
+        v30 = g_n;
+        if (v15 > 5) {
+            v36 = 1;
+        } else {
+            v36 = 2;
+        }
+        g_n = v30 + v36;
+        v44 = v15 + (-3);
+        v63 = v65;
+        v15 = v44;
+    }
+    v59 = 0;
+    v47 = 0;
+    while (v47 < 4) {
+        v55 = v59;
+        v51 = 0;
+        while (v51 < v47) {
+            v56 = v55 + 1;
+            v58 = v51 + 1;
+            v55 = v56;
+            v51 = v58;
+        }
+        v61 = v47 + 1;
+        v59 = v55;
+        v47 = v61;
+    }
+    v69 = g_n;
+    lang_write_float(v63);
+    lang_write_string(" ");
+    lang_write_int(v69);
+    lang_write_string(" ");
+    lang_write_int(v59);
+    lang_write_string(" ");
+    lang_print_string("float");
+    return 0;
+}
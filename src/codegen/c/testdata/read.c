@@ -0,0 +1,30 @@
+/* Generated from read.lang. */
+
+#include "lang_runtime.h"
+
+int main(void) {
+    int64_t v4;
+    double v5, v12;
+    bool v6;
+    const char *v7;
+
+    v4 = lang_read_int();
+    v5 = lang_read_float();
+    v6 = lang_read_bool();
+    v7 = lang_read_string();
+    v12 = lang_fdiv(v5, 2.0);
+    lang_write_int(v4 * 2);
+    lang_write_string(" ");
+    lang_write_float(v12);
+    lang_write_string(" ");
+    lang_write_bool(!v6);
+    lang_write_string(" ");
+    lang_write_string(lang_concat(v7, "!"));
+    lang_write_string(" ");
+    lang_write_bool(strcmp("a", v7) < 0);
+    lang_write_string(" ");
+    lang_write_int(-1);
+    lang_write_string(" ");
+    lang_print_int(-3);
+    return 0;
+}
@@ -0,0 +1,739 @@
+package js
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/checker"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+// Precedence of JavaScript expressions: an operand binding more loosely
+// than where it is written is parenthesized.
+const (
+	precLowest = iota
+	precAssign // also arrow functions
+	precTernary
+	precNullish
+	precOr
+	precAnd
+	precEquality
+	precRelational
+	precAdditive
+	precMultiplicative
+	precPrefix
+	precPostfix // calls, members and new
+	precPrimary
+)
+
+var binaryOperators = map[lexer.TokenKind]struct {
+	text string
+	prec int
+}{
+	lexer.NULLISH:        {"??", precNullish},
+	lexer.OR:             {"||", precOr},
+	lexer.AND:            {"&&", precAnd},
+	lexer.EQUALS:         {"===", precEquality},
+	lexer.NOT_EQUALS:     {"!==", precEquality},
+	lexer.LESS:           {"<", precRelational},
+	lexer.LESS_EQUALS:    {"<=", precRelational},
+	lexer.GREATER:        {">", precRelational},
+	lexer.GREATER_EQUALS: {">=", precRelational},
+	lexer.PLUS:           {"+", precAdditive},
+	lexer.DASH:           {"-", precAdditive},
+	lexer.STAR:           {"*", precMultiplicative},
+	lexer.SLASH:          {"/", precMultiplicative},
+	lexer.PERCENT:        {"%", precMultiplicative},
+}
+
+// precedence is the precedence of the JavaScript expr is written as.
+func (g *generator) precedence(expr ast.Expr) int {
+	switch e := expr.(type) {
+	case ast.BinaryExpr:
+		if e.Operator.Kind == lexer.IN {
+			if g.rangeBounds(e) {
+				return precAnd
+			}
+			return precPostfix
+		}
+		if g.intDivision(e) {
+			return precPostfix
+		}
+		if g.structural(e) {
+			if e.Operator.Kind == lexer.NOT_EQUALS {
+				return precPrefix
+			}
+			return precPostfix
+		}
+		return binaryOperators[e.Operator.Kind].prec
+	case ast.PrefixExpr:
+		if e.Operator.Kind == lexer.TYPEOF && g.staticTypeof(e.RightExpr) != "" {
+			return precPrimary
+		}
+		return precPrefix
+	case ast.AssignmentExpr:
+		if g.isMapIndex(e.Assigne) {
+			return precPostfix
+		}
+		return precAssign
+	case ast.FunctionExpr:
+		return precAssign
+	case ast.TernaryExpr:
+		return precTernary
+	case ast.MatchExpr:
+		if g.ternaryMatch(e) {
+			return precTernary
+		}
+		return precPostfix
+	case ast.IndexExpr, ast.CallExpr, ast.MemberExpr, ast.NewExpr, ast.RangeExpr, ast.MapLiteralExpr:
+		return precPostfix
+	}
+	return precPrimary
+}
+
+// expr writes expr, in parentheses when its precedence is below prec.
+func (g *generator) expr(expr ast.Expr, prec int) {
+	if g.precedence(expr) < prec {
+		g.write("(")
+		defer g.write(")")
+	}
+	g.mark(expr.Span())
+
+	switch e := expr.(type) {
+	case ast.NumberExpr:
+		if !e.Float {
+			g.write(strconv.FormatInt(e.Int, 10))
+			break
+		}
+		g.write(number(e.Value))
+	case ast.StringExpr:
+		g.write(quote(e.Value))
+	case ast.NullExpr:
+		g.write("null")
+	case ast.SymbolExpr:
+		if ns, ok := g.typeOf(e).(checker.NamespaceType); ok && !g.enums[e.Value] {
+			g.fail(e.Span(), "the %s module is not available in JavaScript", ns.Name)
+		}
+		g.write(jsName(e.Value))
+	case ast.ArrayLiteralExpr:
+		g.write("[")
+		g.values(e.Elements)
+		g.write("]")
+	case ast.MapLiteralExpr:
+		g.write("new Map(")
+		if len(e.Entries) > 0 {
+			g.write("[")
+			for i, entry := range e.Entries {
+				if i > 0 {
+					g.write(", ")
+				}
+				g.write("[")
+				g.expr(entry.Key, precAssign)
+				g.write(", ")
+				g.value(entry.Value)
+				g.write("]")
+			}
+			g.write("]")
+		}
+		g.write(")")
+	case ast.StructLiteralExpr:
+		g.write("{ ")
+		for i, field := range e.Fields {
+			if i > 0 {
+				g.write(", ")
+			}
+			g.write(field.Name + ": ")
+			g.value(field.Value)
+		}
+		g.write(" }")
+	case ast.PrefixExpr:
+		g.prefix(e)
+	case ast.BinaryExpr:
+		g.binary(e)
+	case ast.AssignmentExpr:
+		g.assignment(e)
+	case ast.TernaryExpr:
+		g.expr(e.Condition, precNullish)
+		g.write(" ? ")
+		g.expr(e.Consequent, precAssign)
+		g.write(" : ")
+		g.expr(e.Alternate, precAssign)
+	case ast.IndexExpr:
+		g.index(e)
+	case ast.RangeExpr:
+		g.rangeValue(e)
+	case ast.CallExpr:
+		g.call(e)
+	case ast.MemberExpr:
+		g.expr(e.Object, precPostfix)
+		if e.Optional {
+			g.write("?.")
+		} else {
+			g.write(".")
+		}
+		g.write(e.Property)
+	case ast.NewExpr:
+		g.write("new " + jsName(e.ClassName) + "(")
+		g.values(e.Arguments)
+		g.write(")")
+	case ast.FunctionExpr:
+		g.arrow(e)
+	case ast.MatchExpr:
+		g.matchExpr(e)
+	default:
+		g.fail(expr.Span(), "unknown expression %T", expr)
+	}
+}
+
+// list writes expressions separated by commas.
+func (g *generator) list(exprs []ast.Expr) {
+	for i, expr := range exprs {
+		if i > 0 {
+			g.write(", ")
+		}
+		g.expr(expr, precAssign)
+	}
+}
+
+// values writes expressions separated by commas where they are stored,
+// as the arguments of a call or the elements of an array.
+func (g *generator) values(exprs []ast.Expr) {
+	for i, expr := range exprs {
+		if i > 0 {
+			g.write(", ")
+		}
+		g.value(expr)
+	}
+}
+
+// number writes n as JavaScript does, with an exponent only for the
+// smallest and largest numbers.
+func number(n float64) string {
+	if abs := math.Abs(n); abs == 0 || (abs >= 1e-6 && abs < 1e21) {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	s := strconv.FormatFloat(n, 'e', -1, 64)
+	mantissa, exponent, _ := strings.Cut(s, "e")
+	return mantissa + "e" + exponent[:1] + strings.TrimLeft(exponent[1:], "0")
+}
+
+// quote writes s as a string literal.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\u2028', '\u2029':
+			b.WriteString(`\u` + strconv.FormatInt(int64(r), 16))
+		default:
+			if r < ' ' || r == 0x7f {
+				b.WriteString(`\x`)
+				if r < 0x10 {
+					b.WriteByte('0')
+				}
+				b.WriteString(strconv.FormatInt(int64(r), 16))
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func (g *generator) prefix(e ast.PrefixExpr) {
+	if e.Operator.Kind == lexer.TYPEOF {
+		if name := g.staticTypeof(e.RightExpr); name != "" {
+			g.write(quote(name))
+			return
+		}
+		g.write(g.use("typeOf") + "(")
+		g.expr(e.RightExpr, precAssign)
+		g.write(")")
+		return
+	}
+
+	g.write(e.Operator.Value)
+	// - -x is not --x
+	prec := precPrefix
+	if operand, ok := e.RightExpr.(ast.PrefixExpr); ok && operand.Operator.Kind != lexer.NOT && operand.Operator.Kind != lexer.TYPEOF {
+		prec = precPostfix
+	}
+	g.expr(e.RightExpr, prec)
+}
+
+// staticTypeof is what typeof gives for expr when its type tells, and
+// empty when the value must be asked at run time.
+func (g *generator) staticTypeof(expr ast.Expr) string {
+	switch expr.(type) {
+	case ast.SymbolExpr, ast.MemberExpr, ast.NumberExpr, ast.StringExpr, ast.NullExpr:
+	default:
+		return ""
+	}
+	switch t := g.typeOf(expr).(type) {
+	case nil, checker.UnionType, checker.OptionalType, *checker.TypeParam, *checker.InterfaceType:
+		return ""
+	case checker.BasicType:
+		if t == checker.Any {
+			return ""
+		}
+	}
+	return checker.TypeofName(g.typeOf(expr))
+}
+
+func (g *generator) binary(e ast.BinaryExpr) {
+	switch {
+	case e.Operator.Kind == lexer.IN:
+		g.in(e)
+		return
+	case g.intDivision(e):
+		g.write("Math.trunc(")
+		g.expr(e.Left, precMultiplicative)
+		g.write(" / ")
+		g.expr(e.Right, precMultiplicative+1)
+		g.write(")")
+		return
+	case g.structural(e):
+		if e.Operator.Kind == lexer.NOT_EQUALS {
+			g.write("!")
+		}
+		g.write(g.use("equal") + "(")
+		g.expr(e.Left, precAssign)
+		g.write(", ")
+		g.expr(e.Right, precAssign)
+		g.write(")")
+		return
+	}
+
+	op := binaryOperators[e.Operator.Kind]
+	text := op.text
+	// x == null also holds for the undefined of a?.b
+	if _, ok := e.Right.(ast.NullExpr); ok && op.prec == precEquality {
+		text = text[:2]
+	}
+
+	left, right := op.prec, op.prec+1
+	if op.prec == precNullish {
+		// ?? does not mix with && and || without parentheses
+		left, right = precAnd+1, precAnd+1
+		if inner, ok := e.Left.(ast.BinaryExpr); ok && inner.Operator.Kind == lexer.NULLISH {
+			left = precNullish
+		}
+	}
+	g.expr(e.Left, left)
+	g.write(" " + text + " ")
+	g.expr(e.Right, right)
+}
+
+// intDivision reports whether e divides ints, which truncates.
+func (g *generator) intDivision(e ast.BinaryExpr) bool {
+	return e.Operator.Kind == lexer.SLASH && g.typeOf(e) == checker.Int
+}
+
+// structural reports whether e compares structs or enum values with a
+// payload, which are equal when what they hold is, not when they are the
+// same object.
+func (g *generator) structural(e ast.BinaryExpr) bool {
+	if e.Operator.Kind != lexer.EQUALS && e.Operator.Kind != lexer.NOT_EQUALS {
+		return false
+	}
+	return comparedByValue(g.typeOf(e.Left)) || comparedByValue(g.typeOf(e.Right))
+}
+
+func comparedByValue(t checker.Type) bool {
+	switch t := t.(type) {
+	case checker.StructType:
+		return true
+	case *checker.EnumType:
+		for _, variant := range t.Variants {
+			if variant.Payload != nil {
+				return true
+			}
+		}
+	case checker.OptionalType:
+		return comparedByValue(t.Elem)
+	case checker.UnionType:
+		for _, member := range t.Types {
+			if comparedByValue(member) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// in writes x in c as the membership test of c's type; a range written
+// in place is tested against its bounds.
+func (g *generator) in(e ast.BinaryExpr) {
+	if g.rangeBounds(e) {
+		r := e.Right.(ast.RangeExpr)
+		g.expr(e.Left, precRelational+1)
+		g.write(" >= ")
+		g.expr(r.Start, precRelational+1)
+		g.write(" && ")
+		g.expr(e.Left, precRelational+1)
+		if r.Inclusive {
+			g.write(" <= ")
+		} else {
+			g.write(" < ")
+		}
+		g.expr(r.End, precRelational+1)
+		return
+	}
+
+	g.expr(e.Right, precPostfix)
+	if _, ok := g.typeOf(e.Right).(checker.MapType); ok {
+		g.write(".has(")
+	} else {
+		g.write(".includes(")
+	}
+	g.expr(e.Left, precAssign)
+	g.write(")")
+}
+
+// rangeBounds reports whether x in a..b can compare x to the bounds: the
+// range has the default step and x is a variable, read twice.
+func (g *generator) rangeBounds(e ast.BinaryExpr) bool {
+	r, ok := e.Right.(ast.RangeExpr)
+	if !ok || r.Step != nil {
+		return false
+	}
+	_, ok = e.Left.(ast.SymbolExpr)
+	return ok
+}
+
+func (g *generator) isMapIndex(expr ast.Expr) bool {
+	index, ok := expr.(ast.IndexExpr)
+	if !ok {
+		return false
+	}
+	_, ok = g.typeOf(index.Target).(checker.MapType)
+	return ok
+}
+
+func (g *generator) assignment(e ast.AssignmentExpr) {
+	if g.isMapIndex(e.Assigne) {
+		index := e.Assigne.(ast.IndexExpr)
+		g.expr(index.Target, precPostfix)
+		g.write(".set(")
+		g.expr(index.Index, precAssign)
+		g.write(", ")
+		if e.Operator.Kind == lexer.ASSIGNMENT {
+			g.value(e.Value)
+		} else {
+			op := binaryOperators[lexer.PLUS]
+			switch e.Operator.Kind {
+			case lexer.MINUS_EQUALS:
+				op = binaryOperators[lexer.DASH]
+			case lexer.NULLISH_ASSIGNMENT:
+				op = binaryOperators[lexer.NULLISH]
+			}
+			g.expr(index.Target, precPostfix)
+			g.write(".get(")
+			g.expr(index.Index, precAssign)
+			g.write(") " + op.text + " ")
+			g.expr(e.Value, op.prec+1)
+		}
+		g.write(")")
+		return
+	}
+
+	g.expr(e.Assigne, precPostfix)
+	g.write(" " + e.Operator.Value + " ")
+	if e.Operator.Kind == lexer.ASSIGNMENT {
+		g.value(e.Value)
+	} else {
+		g.expr(e.Value, precAssign)
+	}
+}
+
+func (g *generator) index(e ast.IndexExpr) {
+	if _, ok := g.typeOf(e.Index).(checker.RangeType); ok {
+		g.slice(e)
+		return
+	}
+	g.expr(e.Target, precPostfix)
+	if _, ok := g.typeOf(e.Target).(checker.MapType); ok {
+		g.write(".get(")
+		g.expr(e.Index, precAssign)
+		g.write(")")
+		return
+	}
+	g.write("[")
+	g.expr(e.Index, precLowest)
+	g.write("]")
+}
+
+// slice writes xs[a..b], the elements of an array or the bytes of a
+// string at the indexes of the range.
+func (g *generator) slice(e ast.IndexExpr) {
+	if r, ok := e.Index.(ast.RangeExpr); ok && r.Step == nil {
+		g.expr(e.Target, precPostfix)
+		g.write(".slice(")
+		g.expr(r.Start, precAssign)
+		g.write(", ")
+		if r.Inclusive {
+			g.expr(r.End, precAdditive)
+			g.write(" + 1")
+		} else {
+			g.expr(r.End, precAssign)
+		}
+		g.write(")")
+		return
+	}
+	g.write(g.use("slice") + "(")
+	g.expr(e.Target, precAssign)
+	g.write(", ")
+	g.expr(e.Index, precAssign)
+	g.write(")")
+}
+
+// rangeValue writes a range as the array of its numbers.
+func (g *generator) rangeValue(r ast.RangeExpr) {
+	g.write(g.use("range") + "(")
+	g.expr(r.Start, precAssign)
+	g.write(", ")
+	g.expr(r.End, precAssign)
+	if r.Step != nil || r.Inclusive {
+		g.write(", ")
+		if r.Step != nil {
+			g.expr(r.Step, precAssign)
+		} else {
+			g.write("1")
+		}
+	}
+	if r.Inclusive {
+		g.write(", true")
+	}
+	g.write(")")
+}
+
+// call writes a call, the built-in functions as the JavaScript doing the
+// same. The checker gives no type to a built-in function's name.
+func (g *generator) call(e ast.CallExpr) {
+	if callee, ok := e.Callee.(ast.SymbolExpr); ok && g.typeOf(callee) == nil {
+		args := e.Arguments
+		switch callee.Value {
+		case "len":
+			g.expr(args[0], precPostfix)
+			if _, ok := g.typeOf(args[0]).(checker.MapType); ok {
+				g.write(".size")
+			} else {
+				g.write(".length")
+			}
+			return
+		case "has", "delete":
+			g.expr(args[0], precPostfix)
+			g.write("." + callee.Value + "(")
+			g.expr(args[1], precAssign)
+			g.write(")")
+			return
+		case "println":
+			g.write("console.log(")
+			g.list(args)
+			g.write(")")
+			return
+		case "error":
+			g.write("new Error(")
+			g.list(args)
+			g.write(")")
+			return
+		}
+	}
+
+	g.expr(e.Callee, precPostfix)
+	g.write("(")
+	g.values(e.Arguments)
+	g.write(")")
+}
+
+// arrow writes a function expression as an arrow function, whose body is
+// an expression when it only returns one.
+func (g *generator) arrow(e ast.FunctionExpr) {
+	g.write("(" + paramList(e.Parameters) + ") => ")
+	if len(e.Body.Body) == 1 {
+		if ret, ok := e.Body.Body[0].(ast.ReturnStmt); ok && ret.Value != nil {
+			// A body starting with { would be a block
+			if _, ok := ret.Value.(ast.StructLiteralExpr); ok {
+				g.write("(")
+				g.expr(ret.Value, precLowest)
+				g.write(")")
+			} else {
+				g.expr(ret.Value, precAssign)
+			}
+			return
+		}
+	}
+	g.block(e.Body)
+}
+
+// ternaryMatch reports whether a match expression can be a chain of
+// conditional expressions: its arms bind nothing, and the subject is a
+// variable its conditions may read more than once.
+func (g *generator) ternaryMatch(e ast.MatchExpr) bool {
+	if g.subject(e.Subject) == "" {
+		return false
+	}
+	for _, arm := range e.Arms {
+		if hasBindings(arm) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchExpr writes a match as conditional expressions, or as an arrow
+// function called with the subject, whose arms return their value. Its
+// last arm is reached when no other matches, the checker having made
+// sure the arms cover every value.
+func (g *generator) matchExpr(e ast.MatchExpr) {
+	optional := isOptional(g.typeOf(e.Subject))
+	if g.ternaryMatch(e) {
+		subject := g.subject(e.Subject)
+		for i, arm := range e.Arms {
+			if i < len(e.Arms)-1 && !matchesAll(arm) {
+				g.armCondition(arm, subject, optional)
+				g.write(" ? ")
+				g.expr(arm.Value, precAssign)
+				g.write(" : ")
+				continue
+			}
+			g.expr(arm.Value, precAssign)
+			break
+		}
+		return
+	}
+
+	subject := g.newSubject()
+	g.write("((" + subject + ") => {\n")
+	g.depth++
+	for i, arm := range e.Arms {
+		if i == len(e.Arms)-1 || matchesAll(arm) {
+			g.bindings(arm, subject)
+			g.indent()
+			g.mark(arm.Value.Span())
+			g.write("return ")
+			g.expr(arm.Value, precLowest)
+			g.write(";\n")
+			break
+		}
+		g.indent()
+		g.write("if (")
+		g.armCondition(arm, subject, optional)
+		g.write(") {\n")
+		g.depth++
+		g.bindings(arm, subject)
+		g.indent()
+		g.mark(arm.Value.Span())
+		g.write("return ")
+		g.expr(arm.Value, precLowest)
+		g.write(";\n")
+		g.depth--
+		g.indent()
+		g.write("}\n")
+	}
+	g.depth--
+	g.indent()
+	g.write("})(")
+	g.expr(e.Subject, precAssign)
+	g.write(")")
+}
+
+// matchesAll reports whether arm matches any value.
+func matchesAll(arm ast.MatchArm) bool {
+	for _, pattern := range arm.Patterns {
+		switch pattern.(type) {
+		case ast.WildcardPattern, ast.BindingPattern:
+			return true
+		}
+	}
+	return false
+}
+
+func hasBindings(arm ast.MatchArm) bool {
+	for _, pattern := range arm.Patterns {
+		switch p := pattern.(type) {
+		case ast.BindingPattern:
+			return true
+		case ast.VariantPattern:
+			for _, name := range p.Bindings {
+				if name != "_" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// armCondition writes the test of the subject for the patterns of arm.
+// Enum values are told apart by their tag, read with ?. when the subject
+// may be null.
+func (g *generator) armCondition(arm ast.MatchArm, subject string, optional bool) {
+	for i, pattern := range arm.Patterns {
+		if i > 0 {
+			g.write(" || ")
+		}
+		switch p := pattern.(type) {
+		case ast.LiteralPattern:
+			if _, ok := p.Value.(ast.NullExpr); ok {
+				g.write(subject + " == null")
+				break
+			}
+			g.write(subject + " === ")
+			g.expr(p.Value, precEquality+1)
+		case ast.RangePattern:
+			g.write(subject + " >= ")
+			g.expr(p.Start, precRelational+1)
+			if p.Inclusive {
+				g.write(" && " + subject + " <= ")
+			} else {
+				g.write(" && " + subject + " < ")
+			}
+			g.expr(p.End, precRelational+1)
+		case ast.VariantPattern:
+			if optional {
+				g.write(subject + "?.tag === " + quote(p.Variant))
+			} else {
+				g.write(subject + ".tag === " + quote(p.Variant))
+			}
+		}
+	}
+}
+
+// bindings declares, on a line, the names the patterns of arm bind.
+func (g *generator) bindings(arm ast.MatchArm, subject string) {
+	if !hasBindings(arm) {
+		return
+	}
+	for _, pattern := range arm.Patterns {
+		switch p := pattern.(type) {
+		case ast.BindingPattern:
+			g.indent()
+			g.write("let " + jsName(p.Name) + " = " + subject + ";\n")
+			return
+		case ast.VariantPattern:
+			names := make([]string, len(p.Bindings))
+			for i, name := range p.Bindings {
+				if name != "_" {
+					names[i] = jsName(name)
+				}
+			}
+			g.indent()
+			g.write("let [" + strings.Join(names, ", ") + "] = " + subject + ".values;\n")
+			return
+		}
+	}
+}
@@ -0,0 +1,482 @@
+// Package js translates checked programs to JavaScript, each file of the
+// program to an ES module meant to be read and reused as well as run.
+// Declarations keep their names and shape: let and const stay what they
+// are, functions and classes become their JavaScript counterparts,
+// foreach loops for...of and print console.log. A source map written
+// with each module points its code back to the .lang file.
+//
+// Values are JavaScript's own, so ints are exact up to 2^53 only and
+// dividing them truncates with Math.trunc. Maps are Maps, structs plain
+// objects and enums classes. Structs are copied where the language
+// copies them and compared field by field; arrays, maps and instances of
+// classes are shared, as they are in the language. read statements call
+// the host's prompt function. Modules import the functions they need
+// from RuntimeModule, which must be written next to the program's module
+// with the contents of Runtime. The built-in modules, such as fs, are not available.
+package js
+
+import (
+	_ "embed"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/checker"
+	"github.com/RyanOliveira00/go-compiler/src/compiler"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+// RuntimeModule names the module the generated code imports its runtime
+// from.
+const RuntimeModule = "lang_runtime.js"
+
+// Runtime is the contents of RuntimeModule.
+//
+//go:embed lang_runtime.js
+var Runtime string
+
+// File is a translated module, to be written to Path with its source map
+// next to it, at Path + ".map".
+type File struct {
+	Path      string
+	Code      string
+	SourceMap string
+}
+
+// Generate translates the modules of a program, as compiler.CheckModules
+// returns them. The program's own module is written to output and the
+// others where their sources are relative to the program's, with a .js
+// extension instead, so the modules import each other by the same paths.
+func Generate(modules []*compiler.CheckedModule, output string) ([]File, error) {
+	if len(modules) == 0 {
+		return nil, nil
+	}
+	output, err := filepath.Abs(output)
+	if err != nil {
+		return nil, err
+	}
+	entry := modules[len(modules)-1]
+	root := filepath.Dir(entry.Path)
+	runtime := filepath.Join(filepath.Dir(output), RuntimeModule)
+
+	byPath := make(map[string]*compiler.CheckedModule, len(modules))
+	for _, m := range modules {
+		byPath[m.Path] = m
+	}
+
+	files := make([]File, 0, len(modules))
+	for _, m := range modules {
+		rel, err := filepath.Rel(root, m.Path)
+		if err != nil {
+			return nil, err
+		}
+		path := output
+		if m != entry {
+			path = filepath.Join(filepath.Dir(output), jsPath(rel))
+		}
+
+		g := newGenerator(m, byPath)
+		body := g.program()
+		if g.err != nil {
+			return nil, &compiler.ModuleError{Path: rel, Err: g.err}
+		}
+
+		// The imports of the runtime are only known once the rest is
+		// written, the mappings move down past them
+		var header strings.Builder
+		fmt.Fprintf(&header, "// Generated from %s.\n", filepath.Base(m.Path))
+		if len(g.runtime) > 0 {
+			names := make([]string, 0, len(g.runtime))
+			for name := range g.runtime {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			fmt.Fprintf(&header, "import { %s } from %q;\n", strings.Join(names, ", "), importPath(filepath.Dir(path), runtime))
+		}
+		header.WriteString("\n")
+		offset := strings.Count(header.String(), "\n")
+		for i := range g.mappings {
+			g.mappings[i].line += offset
+		}
+
+		source, err := filepath.Rel(filepath.Dir(path), m.Path)
+		if err != nil {
+			source = m.Path
+		}
+		sourceMap := sourceMap{
+			Version:        3,
+			File:           filepath.Base(path),
+			Sources:        []string{filepath.ToSlash(source)},
+			SourcesContent: []string{m.Source},
+			Names:          []string{},
+			Mappings:       encodeMappings(g.mappings),
+		}
+		files = append(files, File{
+			Path:      path,
+			Code:      header.String() + body + "//# sourceMappingURL=" + filepath.Base(path) + ".map\n",
+			SourceMap: sourceMap.encode(),
+		})
+	}
+	return files, nil
+}
+
+// jsPath is the path of the module translating the file at path.
+func jsPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".js"
+}
+
+// importPath is how a module in dir imports the one at path.
+func importPath(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		rel = path
+	}
+	rel = filepath.ToSlash(rel)
+	if !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel
+}
+
+// reserved are the names JavaScript does not let a program declare, and
+// the ones the generated code refers to; variables named so get an
+// underscore appended.
+var reserved = map[string]bool{
+	"arguments": true, "await": true, "break": true, "case": true, "catch": true,
+	"class": true, "const": true, "continue": true, "debugger": true, "default": true,
+	"delete": true, "do": true, "else": true, "enum": true, "eval": true,
+	"export": true, "extends": true, "false": true, "finally": true, "for": true,
+	"function": true, "if": true, "implements": true, "import": true, "in": true,
+	"instanceof": true, "interface": true, "let": true, "new": true, "null": true,
+	"package": true, "private": true, "protected": true, "public": true, "return": true,
+	"static": true, "super": true, "switch": true, "throw": true, "true": true,
+	"try": true, "typeof": true, "undefined": true, "var": true, "void": true,
+	"while": true, "with": true, "yield": true, "NaN": true, "Infinity": true,
+
+	"Array": true, "Error": true, "Map": true, "Math": true, "Number": true,
+	"Object": true, "String": true, "console": true, "globalThis": true, "prompt": true,
+
+	"copy": true, "equal": true, "range": true, "slice": true, "typeOf": true,
+	"readInt": true, "readFloat": true, "readBool": true, "readString": true,
+}
+
+// jsName is the name of a variable, function or type in the generated
+// code.
+func jsName(name string) string {
+	if reserved[name] {
+		return name + "_"
+	}
+	return name
+}
+
+type generator struct {
+	module  *compiler.CheckedModule
+	modules map[string]*compiler.CheckedModule
+
+	// types holds the struct types and aliases a zero value may be made
+	// of, enums the names of the enums in scope
+	types map[string]ast.Type
+	enums map[string]bool
+
+	// runtime holds the functions of the runtime the module uses
+	runtime map[string]bool
+	// subjects counts the variables holding the subject of a match
+	subjects int
+
+	code         strings.Builder
+	line, column int
+	mappings     []mapping
+	depth        int
+
+	// err is the first construct found that JavaScript cannot express
+	err error
+}
+
+func newGenerator(m *compiler.CheckedModule, modules map[string]*compiler.CheckedModule) *generator {
+	g := &generator{
+		module:  m,
+		modules: modules,
+		types:   make(map[string]ast.Type),
+		enums:   make(map[string]bool),
+		runtime: make(map[string]bool),
+	}
+	g.declareTypes(m.Program.Body)
+	for _, stmt := range m.Program.Body {
+		imp, ok := stmt.(ast.ImportStmt)
+		if !ok {
+			continue
+		}
+		imported := g.imported(imp.Path)
+		if imported == nil {
+			continue
+		}
+		local := &generator{types: make(map[string]ast.Type), enums: make(map[string]bool)}
+		local.declareTypes(imported.Program.Body)
+		for _, name := range imp.Names {
+			if t, exists := local.types[name]; exists {
+				g.types[name] = t
+			}
+			if local.enums[name] {
+				g.enums[name] = true
+			}
+		}
+	}
+	return g
+}
+
+// declareTypes collects the types and enums declared by body.
+func (g *generator) declareTypes(body []ast.Stmt) {
+	for _, stmt := range body {
+		if export, ok := stmt.(ast.ExportStmt); ok {
+			stmt = export.Declaration
+		}
+		switch s := stmt.(type) {
+		case ast.TypeDeclStmt:
+			g.types[s.Name] = s.Type
+		case ast.EnumDeclStmt:
+			g.enums[s.Name] = true
+		}
+	}
+}
+
+// imported returns the module imported from path, relative to the
+// module being translated.
+func (g *generator) imported(path string) *compiler.CheckedModule {
+	return g.modules[filepath.Join(filepath.Dir(g.module.Path), path)]
+}
+
+// fail records that the construct at span cannot be translated.
+func (g *generator) fail(span lexer.Span, format string, args ...interface{}) {
+	if g.err == nil {
+		g.err = fmt.Errorf("%d:%d: %s", span.Start.Line, span.Start.Column, fmt.Sprintf(format, args...))
+	}
+}
+
+// typeOf is the type the checker found for expr, nil when it has none.
+func (g *generator) typeOf(expr ast.Expr) checker.Type {
+	return g.module.Types[expr.Span()]
+}
+
+func (g *generator) use(name string) string {
+	g.runtime[name] = true
+	return name
+}
+
+// write appends s to the code, following the position in the code as
+// source maps count it, in UTF-16 units.
+func (g *generator) write(s string) {
+	g.code.WriteString(s)
+	for _, r := range s {
+		if r == '\n' {
+			g.line++
+			g.column = 0
+		} else {
+			g.column += len(utf16.Encode([]rune{r}))
+		}
+	}
+}
+
+func (g *generator) indent() {
+	g.write(strings.Repeat("  ", g.depth))
+}
+
+// mark maps the current position of the code to the start of span.
+// Expressions made up by the parser have no span.
+func (g *generator) mark(span lexer.Span) {
+	if span.Start.Line == 0 {
+		return
+	}
+	g.mappings = append(g.mappings, mapping{
+		line:         g.line,
+		column:       g.column,
+		sourceLine:   span.Start.Line - 1,
+		sourceColumn: span.Start.Column - 1,
+	})
+}
+
+func (g *generator) program() string {
+	var previous ast.Stmt
+	for _, stmt := range g.module.Program.Body {
+		if erased(stmt) {
+			continue
+		}
+		// Declarations are set apart from what surrounds them
+		if previous != nil && (isDeclaration(stmt) || isDeclaration(previous)) {
+			g.write("\n")
+		}
+		g.stmt(stmt)
+		previous = stmt
+	}
+	return g.code.String()
+}
+
+// erased reports whether stmt declares a type only, which has no code.
+func erased(stmt ast.Stmt) bool {
+	if export, ok := stmt.(ast.ExportStmt); ok {
+		stmt = export.Declaration
+	}
+	_, ok := stmt.(ast.TypeDeclStmt)
+	return ok
+}
+
+func isDeclaration(stmt ast.Stmt) bool {
+	if export, ok := stmt.(ast.ExportStmt); ok {
+		stmt = export.Declaration
+	}
+	switch stmt.(type) {
+	case ast.FunctionDeclStmt, ast.ClassDeclStmt, ast.EnumDeclStmt:
+		return true
+	}
+	return false
+}
+
+// firstExpr is the expression a statement starts with, which stands for
+// the statement in the source map since statements have no span.
+func firstExpr(stmt ast.Stmt) ast.Expr {
+	switch s := stmt.(type) {
+	case ast.ExprStmt:
+		return s.Expression
+	case ast.VarDeclStmt:
+		return s.AssignedValue
+	case ast.IfStmt:
+		return s.Condition
+	case ast.WhileStmt:
+		return s.Condition
+	case ast.ForeachStmt:
+		return s.Iterable
+	case ast.PrintStmt:
+		return s.Expression
+	case ast.ReadStmt:
+		return s.Target
+	case ast.ReturnStmt:
+		return s.Value
+	case ast.ThrowStmt:
+		return s.Value
+	case ast.MatchStmt:
+		return s.Subject
+	case ast.ExportStmt:
+		return firstExpr(s.Declaration)
+	}
+	return nil
+}
+
+func (g *generator) block(block ast.BlockStmt) {
+	g.write("{\n")
+	g.depth++
+	for _, stmt := range block.Body {
+		g.stmt(stmt)
+	}
+	g.depth--
+	g.indent()
+	g.write("}")
+}
+
+// stmt writes stmt on lines of its own.
+func (g *generator) stmt(stmt ast.Stmt) {
+	if erased(stmt) {
+		return
+	}
+	declaration := stmt
+	export, exported := stmt.(ast.ExportStmt)
+	if exported {
+		declaration = export.Declaration
+	}
+
+	g.indent()
+	if expr := firstExpr(stmt); expr != nil {
+		g.mark(expr.Span())
+	}
+	if exported {
+		g.write("export ")
+	}
+	g.stmtContents(declaration)
+	g.write("\n")
+}
+
+// stmtContents writes stmt from the current position, without the line
+// break following it.
+func (g *generator) stmtContents(stmt ast.Stmt) {
+	switch s := stmt.(type) {
+	case ast.ExprStmt:
+		// A statement starting with { would be a block
+		if _, ok := s.Expression.(ast.StructLiteralExpr); ok {
+			g.write("(")
+			g.expr(s.Expression, precLowest)
+			g.write(")")
+		} else {
+			g.expr(s.Expression, precLowest)
+		}
+		g.write(";")
+	case ast.VarDeclStmt:
+		g.varDecl(s)
+	case ast.IfStmt:
+		g.ifStmt(s)
+	case ast.WhileStmt:
+		g.write("while (")
+		g.expr(s.Condition, precLowest)
+		g.write(") ")
+		g.block(s.Body)
+	case ast.ForeachStmt:
+		g.foreach(s)
+	case ast.PrintStmt:
+		g.write("console.log(")
+		g.expr(s.Expression, precAssign)
+		g.write(");")
+	case ast.ReadStmt:
+		g.read(s)
+	case ast.BlockStmt:
+		g.block(s)
+	case ast.FunctionDeclStmt:
+		g.write("function " + jsName(s.Name))
+		g.function(s.Parameters, s.Body)
+	case ast.ReturnStmt:
+		if s.Value == nil {
+			g.write("return;")
+			break
+		}
+		g.write("return ")
+		g.expr(s.Value, precLowest)
+		g.write(";")
+	case ast.ThrowStmt:
+		g.write("throw ")
+		// The language throws strings as errors with that message
+		if g.typeOf(s.Value) == checker.String {
+			g.write("new Error(")
+			g.expr(s.Value, precAssign)
+			g.write(")")
+		} else {
+			g.expr(s.Value, precLowest)
+		}
+		g.write(";")
+	case ast.TryStmt:
+		g.write("try ")
+		g.block(s.Body)
+		if s.Catch != nil {
+			if s.CatchName != "" {
+				g.write(" catch (" + jsName(s.CatchName) + ") ")
+			} else {
+				g.write(" catch ")
+			}
+			g.block(*s.Catch)
+		}
+		if s.Finally != nil {
+			g.write(" finally ")
+			g.block(*s.Finally)
+		}
+	case ast.ClassDeclStmt:
+		g.class(s)
+	case ast.EnumDeclStmt:
+		g.enum(s)
+	case ast.MatchStmt:
+		g.matchStmt(s)
+	case ast.ImportStmt:
+		g.importStmt(s)
+	default:
+		if g.err == nil {
+			g.err = fmt.Errorf("unknown statement %T", stmt)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package js_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RyanOliveira00/go-compiler/src/codegen/js"
+	"github.com/RyanOliveira00/go-compiler/src/compiler"
+	"github.com/RyanOliveira00/go-compiler/src/internal/langtest"
+)
+
+// host runs the module given as argument under node, with prompt reading
+// the lines of the standard input.
+const host = `import { readFileSync } from "node:fs";
+import { pathToFileURL } from "node:url";
+const lines = readFileSync(0, "utf8").split("\n");
+globalThis.prompt = () => lines.shift() ?? null;
+await import(pathToFileURL(process.argv[2]).href);
+`
+
+// generate translates p and the modules it imports, the program to
+// output.
+func generate(t *testing.T, p langtest.Program, output string) []js.File {
+	t.Helper()
+	modules, err := compiler.CheckModules(p.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := js.Generate(modules, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// TestGenerate compares the modules and source maps translating the
+// programs of testdata, as if written next to them, with their .golden
+// files.
+func TestGenerate(t *testing.T) {
+	for _, p := range langtest.Programs(t, "testdata") {
+		t.Run(p.Name, func(t *testing.T) {
+			dir, err := filepath.Abs("testdata")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got strings.Builder
+			for _, file := range generate(t, p, filepath.Join(dir, p.Name+".js")) {
+				rel, err := filepath.Rel(dir, file.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got.WriteString("==> " + filepath.ToSlash(rel) + " <==\n" + file.Code)
+				got.WriteString("==> " + filepath.ToSlash(rel) + ".map <==\n" + file.SourceMap + "\n")
+			}
+			langtest.Golden(t, filepath.Join("testdata", p.Name+".golden"), got.String())
+		})
+	}
+}
+
+// TestRun runs the translations of the programs of testdata and of the
+// IR with node, when it is installed, and compares what they print with
+// the interpreter.
+func TestRun(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+
+	programs := append(langtest.Programs(t, "testdata"), langtest.Programs(t, "")...)
+	for _, p := range programs {
+		t.Run(p.Name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range generate(t, p, filepath.Join(dir, "program.js")) {
+				if err := os.MkdirAll(filepath.Dir(file.Path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(file.Path, []byte(file.Code), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := os.WriteFile(filepath.Join(dir, js.RuntimeModule), []byte(js.Runtime), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "host.mjs"), []byte(host), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := exec.Command(node, "host.mjs", "program.js")
+			cmd.Dir = dir
+			cmd.Stdin = strings.NewReader(p.Input)
+			got, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("%s\n%s", err, got)
+			}
+			if want := langtest.Interpret(t, p); string(got) != want {
+				t.Errorf("output differs from the interpreter's:\n%s", langtest.Diff(want, string(got)))
+			}
+		})
+	}
+}
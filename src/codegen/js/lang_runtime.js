@@ -0,0 +1,135 @@
+// Runtime of the JavaScript translation of the language. Modules import
+// the functions they use from here.
+
+// isStruct reports whether value is a struct, which are plain objects.
+function isStruct(value) {
+  return value != null && Object.getPrototypeOf(value) === Object.prototype;
+}
+
+// copy copies a struct, which the language assigns by value, and the
+// structs its fields hold. Other values are shared, as in the language.
+export function copy(value) {
+  if (!isStruct(value)) {
+    return value;
+  }
+  return Object.fromEntries(Object.entries(value).map(([name, field]) => [name, copy(field)]));
+}
+
+// equal is == for structs, equal when their fields are, and for enum
+// values, equal when they are the same variant with equal payloads.
+export function equal(a, b) {
+  if (a === b) {
+    return true;
+  }
+  if (isStruct(a) && isStruct(b)) {
+    const names = Object.keys(a);
+    return names.length === Object.keys(b).length && names.every((name) => equal(a[name], b[name]));
+  }
+  if (a instanceof Object && b instanceof Object && a.constructor === b.constructor && Array.isArray(a.values)) {
+    return a.tag === b.tag && a.values.length === b.values.length && a.values.every((value, i) => equal(value, b.values[i]));
+  }
+  return false;
+}
+
+// range lists the numbers of start..end, or start..=end when inclusive,
+// walking down when step is negative.
+export function range(start, end, step = 1, inclusive = false) {
+  if (step === 0) {
+    throw new Error("range step cannot be zero");
+  }
+  const numbers = [];
+  if (step > 0) {
+    for (let n = start; inclusive ? n <= end : n < end; n += step) {
+      numbers.push(n);
+    }
+  } else {
+    for (let n = start; inclusive ? n >= end : n > end; n += step) {
+      numbers.push(n);
+    }
+  }
+  return numbers;
+}
+
+// slice takes the elements of an array, or the characters of a string,
+// at the indexes of a range.
+export function slice(sequence, indexes) {
+  for (const i of indexes) {
+    if (i < 0 || i >= sequence.length) {
+      throw new Error(`slice out of range [0:${sequence.length}]`);
+    }
+  }
+  const elements = indexes.map((i) => sequence[i]);
+  return typeof sequence === "string" ? elements.join("") : elements;
+}
+
+// typeOf is typeof for the values whose type is only known at run time.
+// Numbers cannot tell a whole float from an int, structs do not keep the
+// name of their type.
+export function typeOf(value) {
+  if (value === null || value === undefined) {
+    return "null";
+  }
+  switch (typeof value) {
+    case "number":
+      return Number.isInteger(value) ? "int" : "float";
+    case "string":
+      return "string";
+    case "boolean":
+      return "bool";
+    case "function":
+      return "function";
+  }
+  if (Array.isArray(value)) {
+    return "array";
+  }
+  if (value instanceof Map) {
+    return "map";
+  }
+  if (isStruct(value)) {
+    return "struct";
+  }
+  return value.constructor.name;
+}
+
+// read statements take the first word of a line the host's prompt
+// returns: a browser's own, or one it assigns to globalThis.prompt.
+function readWord() {
+  const line = globalThis.prompt() ?? "";
+  return line.trim().split(/\s+/)[0];
+}
+
+function invalidInput() {
+  return new Error("invalid input for type");
+}
+
+export function readInt() {
+  const word = readWord();
+  if (!/^[+-]?\d+$/.test(word)) {
+    throw invalidInput();
+  }
+  return Number(word);
+}
+
+export function readFloat() {
+  const word = readWord();
+  const n = Number(word);
+  if (word === "" || Number.isNaN(n)) {
+    throw invalidInput();
+  }
+  return n;
+}
+
+export function readBool() {
+  const word = readWord();
+  if (["1", "t", "T", "TRUE", "true", "True"].includes(word)) {
+    return true;
+  }
+  if (["0", "f", "F", "FALSE", "false", "False"].includes(word)) {
+    return false;
+  }
+  throw invalidInput();
+}
+
+export function readString() {
+  return readWord();
+}
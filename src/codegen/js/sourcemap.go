@@ -0,0 +1,79 @@
+package js
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// mapping ties a position of the generated code to one of the source,
+// both counting lines and columns from 0.
+type mapping struct {
+	line, column             int
+	sourceLine, sourceColumn int
+}
+
+// sourceMap is a source map, version 3, for a file generated from a
+// single source.
+type sourceMap struct {
+	Version        int      `json:"version"`
+	File           string   `json:"file"`
+	Sources        []string `json:"sources"`
+	SourcesContent []string `json:"sourcesContent"`
+	Names          []string `json:"names"`
+	Mappings       string   `json:"mappings"`
+}
+
+// encodeMappings writes mappings, ordered by their generated position, as
+// the mappings field: lines separated by semicolons, each a list of
+// segments whose fields are relative to the previous segment's.
+func encodeMappings(mappings []mapping) string {
+	var b strings.Builder
+	line, column, sourceLine, sourceColumn := 0, 0, 0, 0
+	for i, m := range mappings {
+		if i > 0 && m == mappings[i-1] {
+			continue
+		}
+		if m.line > line {
+			b.WriteString(strings.Repeat(";", m.line-line))
+			line, column = m.line, 0
+		} else if i > 0 {
+			b.WriteByte(',')
+		}
+		writeVLQ(&b, m.column-column)
+		writeVLQ(&b, 0) // the only source
+		writeVLQ(&b, m.sourceLine-sourceLine)
+		writeVLQ(&b, m.sourceColumn-sourceColumn)
+		column, sourceLine, sourceColumn = m.column, m.sourceLine, m.sourceColumn
+	}
+	return b.String()
+}
+
+const base64Digits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// writeVLQ writes n in base64 digits of 5 bits, the least significant
+// first, the sign being the lowest bit of the first.
+func writeVLQ(b *strings.Builder, n int) {
+	v := n << 1
+	if n < 0 {
+		v = -n<<1 | 1
+	}
+	for {
+		digit := v & 31
+		v >>= 5
+		if v > 0 {
+			digit |= 32
+		}
+		b.WriteByte(base64Digits[digit])
+		if v == 0 {
+			return
+		}
+	}
+}
+
+func (m sourceMap) encode() string {
+	data, err := json.Marshal(m)
+	if err != nil {
+		panic(err) // strings and ints only
+	}
+	return string(data) + "\n"
+}
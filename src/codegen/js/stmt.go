@@ -0,0 +1,413 @@
+package js
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/checker"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+func (g *generator) varDecl(s ast.VarDeclStmt) {
+	if s.IsConstant {
+		g.write("const ")
+	} else {
+		g.write("let ")
+	}
+	g.write(jsName(s.VariableName) + " = ")
+	if s.AssignedValue == nil {
+		g.zero(s.ExplicitType)
+	} else {
+		g.value(s.AssignedValue)
+	}
+	g.write(";")
+}
+
+// value writes expr where it is stored, copying it when it is a struct
+// another variable holds.
+func (g *generator) value(expr ast.Expr) {
+	switch expr.(type) {
+	case ast.SymbolExpr, ast.MemberExpr, ast.IndexExpr:
+		if isValueType(g.typeOf(expr)) {
+			g.write(g.use("copy") + "(")
+			g.expr(expr, precAssign)
+			g.write(")")
+			return
+		}
+	}
+	g.expr(expr, precAssign)
+}
+
+// isValueType reports whether values of type t are copied when assigned,
+// which only structs are.
+func isValueType(t checker.Type) bool {
+	switch t := t.(type) {
+	case checker.StructType:
+		return true
+	case checker.OptionalType:
+		return isValueType(t.Elem)
+	case checker.UnionType:
+		for _, member := range t.Types {
+			if isValueType(member) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// zero writes the value of a variable declared with type t but without
+// an initializer, as the interpreter gives it.
+func (g *generator) zero(t ast.Type) {
+	switch t := t.(type) {
+	case nil:
+		g.write("0")
+	case ast.ArrayType:
+		g.write("[]")
+	case ast.MapType:
+		g.write("new Map()")
+	case ast.UnionType:
+		g.zero(t.Types[0])
+	case ast.StructType:
+		g.write("{ ")
+		for i, field := range t.Fields {
+			if i > 0 {
+				g.write(", ")
+			}
+			g.write(field.Name + ": ")
+			g.zero(field.Type)
+		}
+		g.write(" }")
+	case ast.SymbolType:
+		switch t.Name {
+		case "int", "float":
+			g.write("0")
+		case "string":
+			g.write(`""`)
+		case "bool":
+			g.write("false")
+		case "range":
+			g.write("[]")
+		default:
+			// Classes, enums and interfaces start out null
+			if declared, exists := g.types[t.Name]; exists {
+				if _, ok := declared.(ast.InterfaceType); !ok {
+					g.zero(declared)
+					return
+				}
+			}
+			g.write("null")
+		}
+	default:
+		// Optionals, functions and type parameters
+		g.write("null")
+	}
+}
+
+func (g *generator) ifStmt(s ast.IfStmt) {
+	g.write("if (")
+	g.expr(s.Condition, precLowest)
+	g.write(") ")
+	g.block(s.Consequence)
+	if s.Alternative == nil {
+		return
+	}
+	g.write(" else ")
+	if len(s.Alternative.Body) == 1 {
+		if elseIf, ok := s.Alternative.Body[0].(ast.IfStmt); ok {
+			g.mark(elseIf.Condition.Span())
+			g.ifStmt(elseIf)
+			return
+		}
+	}
+	g.block(*s.Alternative)
+}
+
+// foreach writes a for...of loop over the elements of arrays and the keys
+// of maps, or their entries when the loop names two variables. Ranges
+// with bounds that cannot change are counted by a plain for loop.
+func (g *generator) foreach(s ast.ForeachStmt) {
+	names := jsName(s.KeyName)
+	if s.ValueName != "" {
+		names = "[" + names + ", " + jsName(s.ValueName) + "]"
+	}
+
+	if r, ok := s.Iterable.(ast.RangeExpr); ok && s.ValueName == "" && g.countedRange(r) {
+		name := jsName(s.KeyName)
+		down := false
+		if step, ok := r.Step.(ast.PrefixExpr); ok {
+			down = true
+			r.Step = step.RightExpr
+		}
+		g.write("for (let " + name + " = ")
+		g.expr(r.Start, precAssign)
+		g.write("; " + name)
+		switch {
+		case down && r.Inclusive:
+			g.write(" >= ")
+		case down:
+			g.write(" > ")
+		case r.Inclusive:
+			g.write(" <= ")
+		default:
+			g.write(" < ")
+		}
+		g.expr(r.End, precRelational+1)
+		g.write("; ")
+		switch {
+		case r.Step == nil:
+			g.write(name + "++")
+		case down:
+			g.write(name + " -= ")
+			g.expr(r.Step, precAssign)
+		default:
+			g.write(name + " += ")
+			g.expr(r.Step, precAssign)
+		}
+		g.write(") ")
+		g.block(s.Body)
+		return
+	}
+
+	g.write("for (let " + names + " of ")
+	g.expr(s.Iterable, precPostfix)
+	switch g.typeOf(s.Iterable).(type) {
+	case checker.MapType:
+		if s.ValueName == "" {
+			g.write(".keys()")
+		}
+	default:
+		if s.ValueName != "" {
+			g.write(".entries()")
+		}
+	}
+	g.write(") ")
+	g.block(s.Body)
+}
+
+// countedRange reports whether a loop over r can count from its start to
+// its end: the end must not change while the loop runs, and the
+// direction of the step must be known.
+func (g *generator) countedRange(r ast.RangeExpr) bool {
+	switch r.End.(type) {
+	case ast.NumberExpr, ast.SymbolExpr:
+	default:
+		return false
+	}
+	switch step := r.Step.(type) {
+	case nil, ast.NumberExpr:
+		return true
+	case ast.PrefixExpr:
+		_, ok := step.RightExpr.(ast.NumberExpr)
+		return ok && step.Operator.Kind == lexer.DASH
+	}
+	return false
+}
+
+// read assigns the first word the host's prompt returns, parsed as the
+// type of the variable.
+func (g *generator) read(s ast.ReadStmt) {
+	if prompt, ok := s.Target.(ast.StringExpr); ok {
+		g.write("prompt(" + quote(prompt.Value) + ");")
+		return
+	}
+	target := s.Target.(ast.SymbolExpr)
+	t := g.typeOf(target)
+	if opt, ok := t.(checker.OptionalType); ok {
+		t = opt.Elem
+	}
+	read := "readString"
+	switch t {
+	case checker.Int:
+		read = "readInt"
+	case checker.Float:
+		read = "readFloat"
+	case checker.Bool:
+		read = "readBool"
+	}
+	g.write(jsName(target.Value) + " = " + g.use(read) + "();")
+}
+
+// function writes the parameters and body of a function or method.
+func (g *generator) function(params []ast.Parameter, body ast.BlockStmt) {
+	g.write("(" + paramList(params) + ") ")
+	g.block(body)
+}
+
+func paramList(params []ast.Parameter) string {
+	names := make([]string, 0, len(params))
+	for _, param := range params {
+		names = append(names, jsName(param.Name))
+	}
+	return strings.Join(names, ", ")
+}
+
+// class writes a class with its fields, set before the constructor runs
+// as in the language, and its methods, one of which may be the
+// constructor.
+func (g *generator) class(s ast.ClassDeclStmt) {
+	g.write("class " + jsName(s.Name) + " {\n")
+	g.depth++
+	for _, field := range s.Fields {
+		g.indent()
+		if field.AssignedValue != nil {
+			g.mark(field.AssignedValue.Span())
+		}
+		g.write(field.VariableName + " = ")
+		if field.AssignedValue == nil {
+			g.zero(field.ExplicitType)
+		} else {
+			g.value(field.AssignedValue)
+		}
+		g.write(";\n")
+	}
+	for i, method := range s.Methods {
+		if i > 0 || len(s.Fields) > 0 {
+			g.write("\n")
+		}
+		g.indent()
+		g.write(method.Name)
+		g.function(method.Parameters, method.Body)
+		g.write("\n")
+	}
+	g.depth--
+	g.indent()
+	g.write("}")
+}
+
+// enum writes a class whose instances are the values of the enum: tag
+// names the variant and values holds the payload. Variants without a
+// payload are a single instance each, so they compare with ===.
+func (g *generator) enum(s ast.EnumDeclStmt) {
+	name := jsName(s.Name)
+	g.write("class " + name + " {\n")
+	g.depth++
+	g.indent()
+	g.write("constructor(tag, values) {\n")
+	g.depth++
+	g.indent()
+	g.write("this.tag = tag;\n")
+	g.indent()
+	g.write("this.values = values;\n")
+	g.depth--
+	g.indent()
+	g.write("}\n\n")
+	for _, variant := range s.Variants {
+		g.indent()
+		if variant.Payload == nil {
+			g.write("static " + variant.Name + " = new " + name + "(" + quote(variant.Name) + ", []);\n")
+		} else {
+			g.write("static " + variant.Name + " = (...values) => new " + name + "(" + quote(variant.Name) + ", values);\n")
+		}
+	}
+	g.depth--
+	g.indent()
+	g.write("}")
+}
+
+// importStmt imports the values a module exports from the module
+// translating it. The types it exports, other than classes and enums,
+// are erased.
+func (g *generator) importStmt(s ast.ImportStmt) {
+	var exports map[string]checker.Export
+	if imported := g.imported(s.Path); imported != nil {
+		exports = imported.Exports
+	}
+
+	var names []string
+	for _, name := range s.Names {
+		export := exports[name]
+		switch export.Type.(type) {
+		case *checker.ClassType, *checker.EnumType:
+		default:
+			if export.IsType {
+				continue
+			}
+		}
+		names = append(names, jsName(name))
+	}
+
+	path := jsPath(s.Path)
+	if len(names) == 0 {
+		// The module still runs first, as it does in the language
+		g.write("import " + quote(path) + ";")
+		return
+	}
+	g.write("import { " + strings.Join(names, ", ") + " } from " + quote(path) + ";")
+}
+
+// matchStmt writes the arms as an if chain testing the subject, which is
+// held in a variable unless it is one already.
+func (g *generator) matchStmt(s ast.MatchStmt) {
+	subject := g.subject(s.Subject)
+	if subject == "" {
+		subject = g.newSubject()
+		g.write("const " + subject + " = ")
+		g.expr(s.Subject, precAssign)
+		g.write(";\n")
+		g.indent()
+	}
+	optional := isOptional(g.typeOf(s.Subject))
+
+	for i, arm := range s.Arms {
+		if i > 0 {
+			g.write(" else ")
+		}
+		// The arms after one matching anything are never reached
+		all := matchesAll(arm)
+		if !all {
+			g.write("if (")
+			g.armCondition(arm, subject, optional)
+			g.write(") ")
+		}
+
+		g.write("{\n")
+		g.depth++
+		g.bindings(arm, subject)
+		if arm.Body != nil {
+			for _, stmt := range arm.Body.Body {
+				g.stmt(stmt)
+			}
+		} else {
+			g.stmt(ast.ExprStmt{Expression: arm.Value})
+		}
+		g.depth--
+		g.indent()
+		g.write("}")
+		if all {
+			break
+		}
+	}
+}
+
+// subject is how the arms of a match refer to a subject that is a
+// variable, and empty for any other expression.
+func (g *generator) subject(expr ast.Expr) string {
+	if symbol, ok := expr.(ast.SymbolExpr); ok && !g.enums[symbol.Value] {
+		return jsName(symbol.Value)
+	}
+	return ""
+}
+
+func (g *generator) newSubject() string {
+	g.subjects++
+	if g.subjects == 1 {
+		return "$subject"
+	}
+	return "$subject" + strconv.Itoa(g.subjects)
+}
+
+func isOptional(t checker.Type) bool {
+	switch t := t.(type) {
+	case checker.OptionalType:
+		return true
+	case checker.UnionType:
+		for _, member := range t.Types {
+			if member == checker.Null {
+				return true
+			}
+		}
+	}
+	return t == checker.Any
+}
@@ -0,0 +1,507 @@
+package wasm
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/RyanOliveira00/go-compiler/src/ir"
+)
+
+// target is where a branch goes: the code of block, reached by a jump of
+// kind. The zero target is the label of an if, which no jump takes.
+type target struct {
+	block *ir.Block
+	kind  ir.JumpKind
+}
+
+type function struct {
+	mod    *Module
+	f      *ir.Func
+	uses   []int
+	inline []bool
+	locals []int64 // the index of the local of each value by ID, -1 for none
+
+	code   []instr
+	labels []target // of the enclosing blocks, loops and ifs, innermost last
+}
+
+func newFunction(mod *Module, f *ir.Func) *function {
+	fn := &function{
+		mod:    mod,
+		f:      f,
+		locals: make([]int64, f.NumValues()),
+	}
+	fn.uses, fn.inline = ir.Inline(f)
+	return fn
+}
+
+// generate translates the function, whose code is stmts.
+func (fn *function) generate(stmts []ir.Stmt) *funcDecl {
+	decl := &funcDecl{name: "fn_" + fn.f.Name}
+	if fn.f.Name == ir.MainName {
+		decl.name, decl.export = "main", "main"
+	}
+	if fn.f.Return != ir.Void {
+		decl.results = []valType{valueType(fn.f.Return)}
+	}
+
+	for i := range fn.locals {
+		fn.locals[i] = -1
+	}
+	for _, param := range fn.f.Params {
+		fn.locals[param.ID] = param.AuxInt
+		decl.params = append(decl.params, local{param.String(), valueType(param.Type)})
+	}
+	// The other locals are grouped by type, which encodes them shorter
+	var values []*ir.Value
+	for _, b := range fn.f.Blocks {
+		for _, v := range b.Values {
+			if v.Op == ir.OpPhi || (fn.uses[v.ID] > 0 && v.Op != ir.OpConst && v.Op != ir.OpParam && !fn.inline[v.ID]) {
+				values = append(values, v)
+			}
+		}
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		return valueType(values[i].Type) > valueType(values[j].Type)
+	})
+	for i, v := range values {
+		fn.locals[v.ID] = int64(len(decl.params) + i)
+		decl.locals = append(decl.locals, local{v.String(), valueType(v.Type)})
+	}
+
+	fn.stmts(stmts, target{})
+	if last := len(fn.code) - 1; last >= 0 && fn.code[last].op == opReturn {
+		// The end of the function returns what is left on the stack
+		fn.code = fn.code[:last]
+	} else if fn.f.Return != ir.Void {
+		// Every path returned before, but the end still needs a result
+		fn.emit(instr{op: opUnreachable})
+	}
+	decl.code = fn.code
+	return decl
+}
+
+func (fn *function) emit(in instr) {
+	fn.code = append(fn.code, in)
+}
+
+func (fn *function) op(op opcode) {
+	fn.emit(instr{op: op})
+}
+
+// capture returns the code written by write, without adding it to the
+// function.
+func (fn *function) capture(write func()) []instr {
+	saved := fn.code
+	fn.code = nil
+	write()
+	captured := fn.code
+	fn.code = saved
+	return captured
+}
+
+// stmts writes stmts, after which control goes to next: the code falling
+// off the end of them goes there without a branch.
+func (fn *function) stmts(stmts []ir.Stmt, next target) {
+	for i, stmt := range stmts {
+		// Only the last statement can fall off the end, the others are
+		// Code and Scopes followed by the code of their Follow
+		if i < len(stmts)-1 {
+			fn.stmt(stmt, target{})
+		} else {
+			fn.stmt(stmt, next)
+		}
+	}
+}
+
+func (fn *function) stmt(stmt ir.Stmt, next target) {
+	switch s := stmt.(type) {
+	case *ir.Code:
+		fn.block(s.Block)
+	case *ir.If:
+		fn.ifStmt(s, next)
+	case *ir.Loop:
+		// Falling off the end of a loop leaves it
+		fn.emit(instr{op: opLoop, name: s.Header.String()})
+		fn.labels = append(fn.labels, target{s.Header, ir.JumpContinue})
+		fn.stmts(s.Body, next)
+		fn.labels = fn.labels[:len(fn.labels)-1]
+		fn.op(opEnd)
+	case *ir.Scope:
+		fn.labels = append(fn.labels, target{s.Follow, ir.JumpBreak})
+		body := fn.capture(func() { fn.stmts(s.Body, target{s.Follow, ir.JumpBreak}) })
+		fn.labels = fn.labels[:len(fn.labels)-1]
+		if !branchesTo(body, s.Follow.String()) {
+			// Reaching the follow falls off the end of the body
+			fn.code = append(fn.code, unnest(body)...)
+			break
+		}
+		fn.emit(instr{op: opBlock, name: s.Follow.String()})
+		fn.code = append(fn.code, body...)
+		fn.op(opEnd)
+	case *ir.Jump:
+		fn.moves(s.From, s.To)
+		if s.Kind != ir.JumpNext && next != (target{s.To, s.Kind}) {
+			fn.br(target{s.To, s.Kind})
+		}
+	case *ir.Return:
+		if s.Value != nil {
+			fn.push(s.Value)
+		}
+		fn.op(opReturn)
+	}
+}
+
+func (fn *function) block(b *ir.Block) {
+	for _, v := range b.Values {
+		switch {
+		case v.Op == ir.OpConst || v.Op == ir.OpPhi || v.Op == ir.OpParam || fn.inline[v.ID]:
+			// pushed where they are used
+		case v.Op == ir.OpStore:
+			fn.push(v.Args[0])
+			fn.emit(instr{op: opGlobalSet, name: "g_" + v.Aux})
+		case v.Op == ir.OpPrint:
+			fn.print(v.Args)
+		case fn.uses[v.ID] > 0:
+			fn.expr(v)
+			fn.emit(fn.local(opLocalSet, v))
+		case v.Op.HasSideEffects():
+			fn.expr(v)
+			if v.Type != ir.Void {
+				fn.op(opDrop)
+			}
+		}
+	}
+}
+
+func (fn *function) print(args []*ir.Value) {
+	for i, arg := range args {
+		if i > 0 {
+			fn.emit(fn.mod.stringConst(" "))
+			fn.call("write_string")
+		}
+		fn.push(arg)
+		fn.call("write_" + arg.Type.String())
+	}
+	fn.call("newline")
+}
+
+func (fn *function) ifStmt(s *ir.If, next target) {
+	fn.labels = append(fn.labels, target{})
+	then := fn.capture(func() { fn.stmts(s.Then, next) })
+	otherwise := fn.capture(func() { fn.stmts(s.Else, next) })
+	// A branch falling through to a label while the other one leaves is
+	// made explicit, to become a br_if below: a loop then reads as a
+	// while loop, testing its condition and going on with its body
+	if fn.labelled(next) {
+		switch {
+		case len(then) == 0 && leaves(otherwise):
+			then = fn.capture(func() { fn.br(next) })
+		case len(otherwise) == 0 && leaves(then):
+			otherwise = fn.capture(func() { fn.br(next) })
+		}
+	}
+	fn.labels = fn.labels[:len(fn.labels)-1]
+
+	// A branch that only leaves reads better as a br_if, or an if without
+	// an else, followed by the other one
+	switch {
+	case len(then) == 0 && len(otherwise) == 0:
+	case isBr(then):
+		fn.push(s.Cond)
+		fn.emit(unnest(then)[0].withOp(opBrIf))
+		fn.code = append(fn.code, unnest(otherwise)...)
+	case isBr(otherwise):
+		fn.negation(s.Cond)
+		fn.emit(unnest(otherwise)[0].withOp(opBrIf))
+		fn.code = append(fn.code, unnest(then)...)
+	case leaves(then):
+		fn.push(s.Cond)
+		fn.op(opIf)
+		fn.code = append(fn.code, then...)
+		fn.op(opEnd)
+		fn.code = append(fn.code, unnest(otherwise)...)
+	case leaves(otherwise):
+		fn.negation(s.Cond)
+		fn.op(opIf)
+		fn.code = append(fn.code, otherwise...)
+		fn.op(opEnd)
+		fn.code = append(fn.code, unnest(then)...)
+	case len(otherwise) == 0:
+		fn.push(s.Cond)
+		fn.op(opIf)
+		fn.code = append(fn.code, then...)
+		fn.op(opEnd)
+	case len(then) == 0:
+		fn.negation(s.Cond)
+		fn.op(opIf)
+		fn.code = append(fn.code, otherwise...)
+		fn.op(opEnd)
+	default:
+		fn.push(s.Cond)
+		fn.op(opIf)
+		fn.code = append(fn.code, then...)
+		fn.op(opElse)
+		fn.code = append(fn.code, otherwise...)
+		fn.op(opEnd)
+	}
+}
+
+func isBr(code []instr) bool {
+	return len(code) == 1 && code[0].op == opBr
+}
+
+// leaves reports whether code ends in a branch or a return, never falling
+// off the end.
+func leaves(code []instr) bool {
+	if len(code) == 0 {
+		return false
+	}
+	switch code[len(code)-1].op {
+	case opBr, opReturn, opUnreachable:
+		return true
+	}
+	return false
+}
+
+// branchesTo reports whether code branches to the label named label.
+func branchesTo(code []instr, label string) bool {
+	for _, in := range code {
+		if (in.op == opBr || in.op == opBrIf) && in.name == label {
+			return true
+		}
+	}
+	return false
+}
+
+func (in instr) withOp(op opcode) instr {
+	in.op = op
+	return in
+}
+
+// unnest moves code written inside an if out of it, so its branches to
+// labels outside of the if have one less label to cross.
+func unnest(code []instr) []instr {
+	moved := make([]instr, len(code))
+	depth := int64(0)
+	for i, in := range code {
+		switch in.op {
+		case opBlock, opLoop, opIf:
+			depth++
+		case opEnd:
+			depth--
+		case opBr, opBrIf:
+			if in.imm >= depth {
+				in.imm--
+			}
+		}
+		moved[i] = in
+	}
+	return moved
+}
+
+// labelled reports whether a label of the enclosing code leads to t.
+func (fn *function) labelled(t target) bool {
+	for _, label := range fn.labels {
+		if label == t && t.block != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// br branches to t, a label enclosing the code.
+func (fn *function) br(t target) {
+	for depth := len(fn.labels) - 1; depth >= 0; depth-- {
+		if fn.labels[depth] == t {
+			fn.emit(instr{op: opBr, imm: int64(len(fn.labels) - 1 - depth), name: t.block.String()})
+			return
+		}
+	}
+	panic(fmt.Sprintf("wasm: %s: no label for %s", fn.f.Name, t.block))
+}
+
+// moves sets the phis of to for the edge from from. When a phi is read
+// by the argument of another one, all arguments are pushed before any phi
+// is set, so phis reading each other need no temporaries.
+func (fn *function) moves(from, to *ir.Block) {
+	i := to.PredIndex(from)
+	var phis []*ir.Value
+	set := make(map[*ir.Value]bool)
+	for _, v := range to.Values {
+		if v.Op == ir.OpPhi && v.Args[i] != v {
+			phis = append(phis, v)
+			set[v] = true
+		}
+	}
+
+	parallel := false
+	for _, phi := range phis {
+		parallel = parallel || fn.reads(phi.Args[i], set)
+	}
+	if !parallel {
+		for _, phi := range phis {
+			fn.push(phi.Args[i])
+			fn.emit(fn.local(opLocalSet, phi))
+		}
+		return
+	}
+	for _, phi := range phis {
+		fn.push(phi.Args[i])
+	}
+	for j := len(phis) - 1; j >= 0; j-- {
+		fn.emit(fn.local(opLocalSet, phis[j]))
+	}
+}
+
+// reads reports whether pushing v reads one of values.
+func (fn *function) reads(v *ir.Value, values map[*ir.Value]bool) bool {
+	if values[v] {
+		return true
+	}
+	if fn.inline[v.ID] {
+		for _, arg := range v.Args {
+			if fn.reads(arg, values) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (fn *function) local(op opcode, v *ir.Value) instr {
+	return instr{op: op, imm: fn.locals[v.ID], name: v.String()}
+}
+
+func (fn *function) call(name string) {
+	fn.emit(instr{op: opCall, name: name})
+}
+
+// push pushes the value of v on the stack.
+func (fn *function) push(v *ir.Value) {
+	switch {
+	case v.Op == ir.OpConst:
+		switch v.Type {
+		case ir.Int:
+			fn.emit(instr{op: opI64Const, imm: v.AuxInt})
+		case ir.Float:
+			fn.emit(instr{op: opF64Const, f: v.AuxFloat})
+		case ir.Bool:
+			fn.emit(instr{op: opI32Const, imm: v.AuxInt})
+		case ir.String:
+			fn.emit(fn.mod.stringConst(v.AuxString))
+		}
+	case fn.inline[v.ID]:
+		fn.expr(v)
+	default:
+		fn.emit(fn.local(opLocalGet, v))
+	}
+}
+
+// Instructions of the operators by type of their operands
+var (
+	intOps = map[ir.Op]opcode{
+		ir.OpAdd: opI64Add,
+		ir.OpSub: opI64Sub,
+		ir.OpMul: opI64Mul,
+		ir.OpEq:  opI64Eq,
+		ir.OpNe:  opI64Ne,
+		ir.OpLt:  opI64LtS,
+		ir.OpLe:  opI64LeS,
+		ir.OpGt:  opI64GtS,
+		ir.OpGe:  opI64GeS,
+	}
+	floatOps = map[ir.Op]opcode{
+		ir.OpAdd: opF64Add,
+		ir.OpSub: opF64Sub,
+		ir.OpMul: opF64Mul,
+		ir.OpEq:  opF64Eq,
+		ir.OpNe:  opF64Ne,
+		ir.OpLt:  opF64Lt,
+		ir.OpLe:  opF64Le,
+		ir.OpGt:  opF64Gt,
+		ir.OpGe:  opF64Ge,
+	}
+	// for bools, and for strings comparing the result of compare to 0
+	i32Ops = map[ir.Op]opcode{
+		ir.OpEq: opI32Eq,
+		ir.OpNe: opI32Ne,
+		ir.OpLt: opI32LtS,
+		ir.OpLe: opI32LeS,
+		ir.OpGt: opI32GtS,
+		ir.OpGe: opI32GeS,
+	}
+)
+
+// expr pushes the result of the operation computing v.
+func (fn *function) expr(v *ir.Value) {
+	args := v.Args
+	switch v.Op {
+	case ir.OpCopy:
+		fn.push(args[0])
+	case ir.OpAdd, ir.OpSub, ir.OpMul, ir.OpEq, ir.OpNe, ir.OpLt, ir.OpLe, ir.OpGt, ir.OpGe:
+		fn.push(args[0])
+		fn.push(args[1])
+		switch args[0].Type {
+		case ir.Int:
+			fn.op(intOps[v.Op])
+		case ir.Float:
+			fn.op(floatOps[v.Op])
+		case ir.Bool:
+			fn.op(i32Ops[v.Op])
+		case ir.String:
+			fn.call("compare")
+			fn.emit(instr{op: opI32Const})
+			fn.op(i32Ops[v.Op])
+		}
+	case ir.OpDiv, ir.OpMod:
+		fn.push(args[0])
+		fn.push(args[1])
+		fn.call(map[ir.Op]string{ir.OpDiv: "div_", ir.OpMod: "mod_"}[v.Op] + v.Type.String())
+	case ir.OpNeg:
+		if v.Type == ir.Float {
+			fn.push(args[0])
+			fn.op(opF64Neg)
+			break
+		}
+		fn.emit(instr{op: opI64Const})
+		fn.push(args[0])
+		fn.op(opI64Sub)
+	case ir.OpConcat:
+		fn.push(args[0])
+		fn.push(args[1])
+		fn.call("concat")
+	case ir.OpIntToFloat:
+		fn.push(args[0])
+		fn.op(opF64ConvertI64S)
+	case ir.OpNot:
+		fn.push(args[0])
+		fn.op(opI32Eqz)
+	case ir.OpCall:
+		for _, arg := range args {
+			fn.push(arg)
+		}
+		fn.call("fn_" + v.Aux)
+	case ir.OpLoad:
+		fn.emit(instr{op: opGlobalGet, name: "g_" + v.Aux})
+	case ir.OpRead:
+		fn.call("read_" + v.Type.String())
+	default:
+		panic(fmt.Sprintf("wasm: cannot translate %s", v.LongString()))
+	}
+}
+
+// negation pushes the negation of the bool v, flipping a comparison
+// computed in place.
+func (fn *function) negation(v *ir.Value) {
+	if flipped, ok := ir.Negation(v); ok && fn.inline[v.ID] {
+		fn.expr(flipped)
+		return
+	}
+	if v.Op == ir.OpNot && fn.inline[v.ID] {
+		fn.push(v.Args[0])
+		return
+	}
+	fn.push(v)
+	fn.op(opI32Eqz)
+}
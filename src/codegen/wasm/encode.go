@@ -0,0 +1,305 @@
+package wasm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Section IDs of the binary format
+const (
+	sectionCustom   = 0
+	sectionType     = 1
+	sectionImport   = 2
+	sectionFunction = 3
+	sectionMemory   = 5
+	sectionGlobal   = 6
+	sectionExport   = 7
+	sectionCode     = 10
+	sectionData     = 11
+)
+
+// Kinds of imports and exports
+const (
+	externFunc   = 0x00
+	externMemory = 0x02
+)
+
+// encoder appends to a byte slice in the encodings of the binary format.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) byte(b byte) {
+	e.buf = append(e.buf, b)
+}
+
+func (e *encoder) u32(n uint64) {
+	e.buf = binary.AppendUvarint(e.buf, n)
+}
+
+// s64 writes n in signed LEB128, which binary.AppendVarint's zigzag
+// encoding is not.
+func (e *encoder) s64(n int64) {
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if (n == 0 && b&0x40 == 0) || (n == -1 && b&0x40 != 0) {
+			e.byte(b)
+			return
+		}
+		e.byte(b | 0x80)
+	}
+}
+
+func (e *encoder) name(s string) {
+	e.u32(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) types(types []valType) {
+	e.u32(uint64(len(types)))
+	for _, t := range types {
+		e.byte(byte(t))
+	}
+}
+
+// section writes the section id with the contents written by write,
+// preceded by their size.
+func (e *encoder) section(id byte, write func(*encoder)) {
+	var contents encoder
+	write(&contents)
+	e.byte(id)
+	e.u32(uint64(len(contents.buf)))
+	e.buf = append(e.buf, contents.buf...)
+}
+
+func (e *encoder) instr(in instr) {
+	e.byte(byte(in.op))
+	switch in.op {
+	case opBlock, opLoop, opIf:
+		e.byte(0x40) // no result
+	case opBr, opBrIf, opCall, opLocalGet, opLocalSet, opLocalTee, opGlobalGet, opGlobalSet:
+		e.u32(uint64(in.imm))
+	case opI32Const:
+		e.s64(int64(int32(in.imm)))
+	case opI64Const:
+		e.s64(in.imm)
+	case opF64Const:
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(in.f))
+	case opI32Load, opI32Load8U, opI32Store, opI32Store8:
+		e.u32(in.op.align())
+		e.u32(uint64(in.imm))
+	case opMemorySize, opMemoryGrow:
+		e.byte(0x00) // the memory
+	}
+}
+
+// Binary encodes mod in the binary format, the contents of a .wasm file.
+func (mod *Module) Binary() []byte {
+	e := &encoder{buf: []byte("\x00asm\x01\x00\x00\x00")}
+
+	all := append(append([]*funcDecl{}, mod.imports...), mod.funcs...)
+	var signatures []*funcDecl
+	typeIndex := make(map[string]uint64)
+	for _, f := range all {
+		if _, exists := typeIndex[f.signature()]; !exists {
+			typeIndex[f.signature()] = uint64(len(signatures))
+			signatures = append(signatures, f)
+		}
+	}
+
+	e.section(sectionType, func(e *encoder) {
+		e.u32(uint64(len(signatures)))
+		for _, f := range signatures {
+			e.byte(0x60)
+			params := make([]valType, 0, len(f.params))
+			for _, param := range f.params {
+				params = append(params, param.typ)
+			}
+			e.types(params)
+			e.types(f.results)
+		}
+	})
+	if len(mod.imports) > 0 {
+		e.section(sectionImport, func(e *encoder) {
+			e.u32(uint64(len(mod.imports)))
+			for _, f := range mod.imports {
+				e.name("lang")
+				e.name(f.name)
+				e.byte(externFunc)
+				e.u32(typeIndex[f.signature()])
+			}
+		})
+	}
+	e.section(sectionFunction, func(e *encoder) {
+		e.u32(uint64(len(mod.funcs)))
+		for _, f := range mod.funcs {
+			e.u32(typeIndex[f.signature()])
+		}
+	})
+	e.section(sectionMemory, func(e *encoder) {
+		e.u32(1)
+		e.byte(0x00) // no maximum
+		e.u32(mod.pages())
+	})
+	if len(mod.globals) > 0 {
+		e.section(sectionGlobal, func(e *encoder) {
+			e.u32(uint64(len(mod.globals)))
+			for _, g := range mod.globals {
+				e.byte(byte(g.typ))
+				e.byte(0x01) // mutable
+				e.instr(g.init)
+				e.byte(byte(opEnd))
+			}
+		})
+	}
+	e.section(sectionExport, func(e *encoder) {
+		exports := make(map[string]uint64)
+		var names []string
+		for i, f := range mod.funcs {
+			if f.export != "" {
+				exports[f.export] = uint64(len(mod.imports) + i)
+				names = append(names, f.export)
+			}
+		}
+		e.u32(uint64(len(names) + 1))
+		e.name("memory")
+		e.byte(externMemory)
+		e.u32(0)
+		for _, name := range names {
+			e.name(name)
+			e.byte(externFunc)
+			e.u32(exports[name])
+		}
+	})
+	e.section(sectionCode, func(e *encoder) {
+		e.u32(uint64(len(mod.funcs)))
+		for _, f := range mod.funcs {
+			var body encoder
+			// Locals are declared in runs of the same type
+			type run struct {
+				count uint64
+				typ   valType
+			}
+			var runs []run
+			for _, l := range f.locals {
+				if n := len(runs); n > 0 && runs[n-1].typ == l.typ {
+					runs[n-1].count++
+				} else {
+					runs = append(runs, run{1, l.typ})
+				}
+			}
+			body.u32(uint64(len(runs)))
+			for _, r := range runs {
+				body.u32(r.count)
+				body.byte(byte(r.typ))
+			}
+			for _, in := range f.code {
+				body.instr(in)
+			}
+			body.byte(byte(opEnd))
+
+			e.u32(uint64(len(body.buf)))
+			e.buf = append(e.buf, body.buf...)
+		}
+	})
+	if len(mod.data) > 0 {
+		e.section(sectionData, func(e *encoder) {
+			e.u32(1)
+			e.byte(0x00) // active, in memory 0
+			e.instr(instr{op: opI32Const, imm: dataStart})
+			e.byte(byte(opEnd))
+			e.u32(uint64(len(mod.data)))
+			e.buf = append(e.buf, mod.data...)
+		})
+	}
+
+	// The name section gives the functions their names in stack traces
+	e.section(sectionCustom, func(e *encoder) {
+		e.name("name")
+		e.section(1, func(e *encoder) {
+			e.u32(uint64(len(all)))
+			for i, f := range all {
+				e.u32(uint64(i))
+				e.name(f.name)
+			}
+		})
+	})
+	return e.buf
+}
+
+// Text writes mod in the text format, the contents of a .wat file.
+func (mod *Module) Text() string {
+	var b strings.Builder
+	b.WriteString("(module\n")
+	for _, f := range mod.imports {
+		fmt.Fprintf(&b, "  (import \"lang\" %q (func $%s%s))\n", f.name, f.name, f.typeText())
+	}
+	fmt.Fprintf(&b, "  (memory (export \"memory\") %d)\n", mod.pages())
+	for _, g := range mod.globals {
+		fmt.Fprintf(&b, "  (global $%s (mut %s) (%s))\n", g.name, g.typ, g.init)
+	}
+
+	for _, f := range mod.funcs {
+		b.WriteString("\n  (func $" + f.name)
+		if f.export != "" {
+			fmt.Fprintf(&b, " (export %q)", f.export)
+		}
+		b.WriteString(f.typeText() + "\n")
+		for _, l := range f.locals {
+			fmt.Fprintf(&b, "    (local $%s %s)\n", l.name, l.typ)
+		}
+		depth := 2
+		for _, in := range f.code {
+			if in.op == opEnd || in.op == opElse {
+				depth--
+			}
+			b.WriteString(strings.Repeat("  ", depth) + in.String())
+			if in.comment != "" {
+				b.WriteString(" ;; " + in.comment)
+			}
+			b.WriteString("\n")
+			if in.op == opBlock || in.op == opLoop || in.op == opIf || in.op == opElse {
+				depth++
+			}
+		}
+		b.WriteString("  )\n")
+	}
+
+	if len(mod.data) > 0 {
+		fmt.Fprintf(&b, "\n  (data (i32.const %d) \"%s\")\n", dataStart, escape(mod.data))
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// typeText writes the params and results of f.
+func (f *funcDecl) typeText() string {
+	var b strings.Builder
+	for _, param := range f.params {
+		if param.name != "" {
+			fmt.Fprintf(&b, " (param $%s %s)", param.name, param.typ)
+		} else {
+			fmt.Fprintf(&b, " (param %s)", param.typ)
+		}
+	}
+	for _, result := range f.results {
+		fmt.Fprintf(&b, " (result %s)", result)
+	}
+	return b.String()
+}
+
+// escape writes data as the contents of a string of the text format.
+func escape(data []byte) string {
+	var b strings.Builder
+	for _, c := range data {
+		if c < ' ' || c > '~' || c == '"' || c == '\\' {
+			fmt.Fprintf(&b, "\\%02x", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,316 @@
+package wasm
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// opcode is the byte encoding a WebAssembly instruction.
+type opcode byte
+
+const (
+	opUnreachable opcode = 0x00
+	opBlock       opcode = 0x02
+	opLoop        opcode = 0x03
+	opIf          opcode = 0x04
+	opElse        opcode = 0x05
+	opEnd         opcode = 0x0b
+	opBr          opcode = 0x0c
+	opBrIf        opcode = 0x0d
+	opReturn      opcode = 0x0f
+	opCall        opcode = 0x10
+	opDrop        opcode = 0x1a
+	opSelect      opcode = 0x1b
+
+	opLocalGet  opcode = 0x20
+	opLocalSet  opcode = 0x21
+	opLocalTee  opcode = 0x22
+	opGlobalGet opcode = 0x23
+	opGlobalSet opcode = 0x24
+
+	opI32Load    opcode = 0x28
+	opI32Load8U  opcode = 0x2d
+	opI32Store   opcode = 0x36
+	opI32Store8  opcode = 0x3a
+	opMemorySize opcode = 0x3f
+	opMemoryGrow opcode = 0x40
+
+	opI32Const opcode = 0x41
+	opI64Const opcode = 0x42
+	opF64Const opcode = 0x44
+
+	opI32Eqz opcode = 0x45
+	opI32Eq  opcode = 0x46
+	opI32Ne  opcode = 0x47
+	opI32LtS opcode = 0x48
+	opI32LtU opcode = 0x49
+	opI32GtS opcode = 0x4a
+	opI32GtU opcode = 0x4b
+	opI32LeS opcode = 0x4c
+	opI32LeU opcode = 0x4d
+	opI32GeS opcode = 0x4e
+	opI32GeU opcode = 0x4f
+
+	opI64Eqz opcode = 0x50
+	opI64Eq  opcode = 0x51
+	opI64Ne  opcode = 0x52
+	opI64LtS opcode = 0x53
+	opI64GtS opcode = 0x55
+	opI64LeS opcode = 0x57
+	opI64GeS opcode = 0x59
+
+	opF64Eq opcode = 0x61
+	opF64Ne opcode = 0x62
+	opF64Lt opcode = 0x63
+	opF64Gt opcode = 0x64
+	opF64Le opcode = 0x65
+	opF64Ge opcode = 0x66
+
+	opI32Add  opcode = 0x6a
+	opI32Sub  opcode = 0x6b
+	opI32And  opcode = 0x71
+	opI32Or   opcode = 0x72
+	opI32Shl  opcode = 0x74
+	opI32ShrU opcode = 0x76
+
+	opI64Add  opcode = 0x7c
+	opI64Sub  opcode = 0x7d
+	opI64Mul  opcode = 0x7e
+	opI64DivS opcode = 0x7f
+	opI64RemS opcode = 0x81
+
+	opF64Abs      opcode = 0x99
+	opF64Neg      opcode = 0x9a
+	opF64Add      opcode = 0xa0
+	opF64Sub      opcode = 0xa1
+	opF64Mul      opcode = 0xa2
+	opF64Div      opcode = 0xa3
+	opF64Copysign opcode = 0xa6
+
+	opF64ConvertI64S opcode = 0xb9
+)
+
+var opNames = map[opcode]string{
+	opUnreachable: "unreachable",
+	opBlock:       "block",
+	opLoop:        "loop",
+	opIf:          "if",
+	opElse:        "else",
+	opEnd:         "end",
+	opBr:          "br",
+	opBrIf:        "br_if",
+	opReturn:      "return",
+	opCall:        "call",
+	opDrop:        "drop",
+	opSelect:      "select",
+
+	opLocalGet:  "local.get",
+	opLocalSet:  "local.set",
+	opLocalTee:  "local.tee",
+	opGlobalGet: "global.get",
+	opGlobalSet: "global.set",
+
+	opI32Load:    "i32.load",
+	opI32Load8U:  "i32.load8_u",
+	opI32Store:   "i32.store",
+	opI32Store8:  "i32.store8",
+	opMemorySize: "memory.size",
+	opMemoryGrow: "memory.grow",
+
+	opI32Const: "i32.const",
+	opI64Const: "i64.const",
+	opF64Const: "f64.const",
+
+	opI32Eqz: "i32.eqz",
+	opI32Eq:  "i32.eq",
+	opI32Ne:  "i32.ne",
+	opI32LtS: "i32.lt_s",
+	opI32LtU: "i32.lt_u",
+	opI32GtS: "i32.gt_s",
+	opI32GtU: "i32.gt_u",
+	opI32LeS: "i32.le_s",
+	opI32LeU: "i32.le_u",
+	opI32GeS: "i32.ge_s",
+	opI32GeU: "i32.ge_u",
+
+	opI64Eqz: "i64.eqz",
+	opI64Eq:  "i64.eq",
+	opI64Ne:  "i64.ne",
+	opI64LtS: "i64.lt_s",
+	opI64GtS: "i64.gt_s",
+	opI64LeS: "i64.le_s",
+	opI64GeS: "i64.ge_s",
+
+	opF64Eq: "f64.eq",
+	opF64Ne: "f64.ne",
+	opF64Lt: "f64.lt",
+	opF64Gt: "f64.gt",
+	opF64Le: "f64.le",
+	opF64Ge: "f64.ge",
+
+	opI32Add:  "i32.add",
+	opI32Sub:  "i32.sub",
+	opI32And:  "i32.and",
+	opI32Or:   "i32.or",
+	opI32Shl:  "i32.shl",
+	opI32ShrU: "i32.shr_u",
+
+	opI64Add:  "i64.add",
+	opI64Sub:  "i64.sub",
+	opI64Mul:  "i64.mul",
+	opI64DivS: "i64.div_s",
+	opI64RemS: "i64.rem_s",
+
+	opF64Abs:      "f64.abs",
+	opF64Neg:      "f64.neg",
+	opF64Add:      "f64.add",
+	opF64Sub:      "f64.sub",
+	opF64Mul:      "f64.mul",
+	opF64Div:      "f64.div",
+	opF64Copysign: "f64.copysign",
+
+	opF64ConvertI64S: "f64.convert_i64_s",
+}
+
+func (op opcode) String() string {
+	if name, exists := opNames[op]; exists {
+		return name
+	}
+	return fmt.Sprintf("opcode(%#x)", byte(op))
+}
+
+// align is the log2 of the natural alignment of a memory access.
+func (op opcode) align() uint64 {
+	switch op {
+	case opI32Load, opI32Store:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// instr is an instruction with its immediate, if it has one.
+type instr struct {
+	op opcode
+
+	// imm is the index, label depth, memory offset or integer constant
+	// the instruction takes, and f the constant of f64.const
+	imm int64
+	f   float64
+
+	// name is how the text format refers to what imm indexes: a local, a
+	// global, a function or the label of a block or loop. Functions and
+	// globals are only known by name until the module is linked.
+	name string
+
+	// comment follows the instruction in the text format, which String
+	// leaves out
+	comment string
+}
+
+func (in instr) String() string {
+	text := in.op.String()
+	switch in.op {
+	case opBlock, opLoop, opBr, opBrIf:
+		if in.name != "" {
+			text += " $" + in.name
+		} else if in.op == opBr || in.op == opBrIf {
+			text += " " + strconv.FormatInt(in.imm, 10)
+		}
+	case opCall, opLocalGet, opLocalSet, opLocalTee, opGlobalGet, opGlobalSet:
+		text += " $" + in.name
+	case opI32Const, opI64Const:
+		text += " " + strconv.FormatInt(in.imm, 10)
+	case opF64Const:
+		text += " " + floatText(in.f)
+	case opI32Load, opI32Load8U, opI32Store, opI32Store8:
+		if in.imm != 0 {
+			text += " offset=" + strconv.FormatInt(in.imm, 10)
+		}
+	}
+	return text
+}
+
+// floatText writes f as a float literal of the text format.
+func floatText(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// assemble translates code written in the text format, one instruction
+// per line, for functions of the runtime. Operands naming a local are
+// resolved against locals; a quoted string stands for the address of the
+// string in memory, as the operand of i32.const. Blocks and loops are not
+// named, branches give the depth of their label.
+func (mod *Module) assemble(code string, locals []local) []instr {
+	ops := make(map[string]opcode, len(opNames))
+	for op, name := range opNames {
+		ops[name] = op
+	}
+
+	var instrs []instr
+	for _, text := range strings.Split(code, "\n") {
+		if i := strings.Index(text, ";;"); i >= 0 {
+			text = text[:i]
+		}
+		name, operand, _ := strings.Cut(strings.TrimSpace(text), " ")
+		if name == "" {
+			continue
+		}
+		op, exists := ops[name]
+		if !exists {
+			panic(fmt.Sprintf("wasm: unknown instruction %s", name))
+		}
+
+		in := instr{op: op}
+		var err error
+		switch op {
+		case opLocalGet, opLocalSet, opLocalTee:
+			in.name = strings.TrimPrefix(operand, "$")
+			in.imm = -1
+			for i, l := range locals {
+				if l.name == in.name {
+					in.imm = int64(i)
+				}
+			}
+			if in.imm < 0 {
+				err = fmt.Errorf("unknown local %s", operand)
+			}
+		case opCall, opGlobalGet, opGlobalSet:
+			in.name = strings.TrimPrefix(operand, "$")
+		case opBr, opBrIf:
+			in.imm, err = strconv.ParseInt(operand, 10, 32)
+		case opI32Const:
+			if strings.HasPrefix(operand, `"`) {
+				var s string
+				if s, err = strconv.Unquote(operand); err == nil {
+					in = mod.stringConst(s)
+				}
+				break
+			}
+			in.imm, err = strconv.ParseInt(operand, 10, 32)
+		case opI64Const:
+			in.imm, err = strconv.ParseInt(operand, 10, 64)
+		case opF64Const:
+			in.f, err = strconv.ParseFloat(operand, 64)
+		case opI32Load, opI32Load8U, opI32Store, opI32Store8:
+			if operand != "" {
+				in.imm, err = strconv.ParseInt(strings.TrimPrefix(operand, "offset="), 10, 32)
+			}
+		}
+		if err != nil {
+			panic(fmt.Sprintf("wasm: %s: %s", text, err))
+		}
+		instrs = append(instrs, in)
+	}
+	return instrs
+}
@@ -0,0 +1,372 @@
+package wasm
+
+// hostFunc is a function the host provides, imported from the module
+// "lang". needs lists the functions of the runtime it calls back.
+type hostFunc struct {
+	name    string
+	params  []valType
+	results []valType
+	needs   []string
+}
+
+// hostFuncs are the functions a module may import, only the ones its code
+// calls being imported. Strings are passed as their address in the
+// exported memory, where a 32-bit little-endian length precedes their
+// UTF-8 bytes.
+var hostFuncs = []hostFunc{
+	// write_* write a value without a newline; newline ends the line
+	{name: "write_int", params: []valType{i64}},
+	{name: "write_float", params: []valType{f64}},
+	{name: "write_bool", params: []valType{i32}},
+	{name: "write_string", params: []valType{i32}},
+	{name: "newline"},
+
+	// read_* read a word from the input as a value of their type and must
+	// not return when it is not one. read_string stores the word in
+	// memory allocated with the exported alloc.
+	{name: "read_int", results: []valType{i64}},
+	{name: "read_float", results: []valType{f64}},
+	{name: "read_bool", results: []valType{i32}},
+	{name: "read_string", results: []valType{i32}, needs: []string{"alloc"}},
+
+	// fail stops the program with a message and must not return
+	{name: "fail", params: []valType{i32}},
+}
+
+// helper is a function of the runtime written in the text format.
+type helper struct {
+	name    string
+	params  []local
+	results []valType
+	locals  []local
+	code    string
+}
+
+// helpers are the functions of the runtime compiled into a module when
+// its code calls them.
+var helpers = []helper{
+	{
+		// alloc returns size bytes of memory, growing it when needed. The
+		// memory is never freed, like every value of the language.
+		name:    "alloc",
+		params:  []local{{"size", i32}},
+		results: []valType{i32},
+		locals:  []local{{"p", i32}},
+		code: `
+			global.get $heap
+			local.set $p
+			;; keeps the heap aligned to 8 bytes
+			global.get $heap
+			local.get $size
+			i32.const 7
+			i32.add
+			i32.const -8
+			i32.and
+			i32.add
+			global.set $heap
+			block
+			  global.get $heap
+			  memory.size
+			  i32.const 16
+			  i32.shl
+			  i32.le_u
+			  br_if 0
+			  ;; the pages missing, rounded up
+			  global.get $heap
+			  memory.size
+			  i32.const 16
+			  i32.shl
+			  i32.sub
+			  i32.const 65535
+			  i32.add
+			  i32.const 16
+			  i32.shr_u
+			  memory.grow
+			  i32.const -1
+			  i32.ne
+			  br_if 0
+			  i32.const "out of memory"
+			  call $fail
+			  unreachable
+			end
+			local.get $p`,
+	},
+	{
+		name:    "concat",
+		params:  []local{{"a", i32}, {"b", i32}},
+		results: []valType{i32},
+		locals:  []local{{"n", i32}, {"s", i32}},
+		code: `
+			local.get $a
+			i32.load
+			local.get $b
+			i32.load
+			i32.add
+			local.set $n
+			local.get $n
+			i32.const 4
+			i32.add
+			call $alloc
+			local.tee $s
+			local.get $n
+			i32.store
+			local.get $s
+			i32.const 4
+			i32.add
+			local.get $a
+			call $copy
+			local.get $b
+			call $copy
+			drop
+			local.get $s`,
+	},
+	{
+		// copy copies the bytes of the string src to dst and returns the
+		// address following them
+		name:    "copy",
+		params:  []local{{"dst", i32}, {"src", i32}},
+		results: []valType{i32},
+		locals:  []local{{"end", i32}},
+		code: `
+			local.get $src
+			i32.load
+			local.get $src
+			i32.const 4
+			i32.add
+			local.tee $src
+			i32.add
+			local.set $end
+			block
+			  loop
+			    local.get $src
+			    local.get $end
+			    i32.ge_u
+			    br_if 1
+			    local.get $dst
+			    local.get $src
+			    i32.load8_u
+			    i32.store8
+			    local.get $dst
+			    i32.const 1
+			    i32.add
+			    local.set $dst
+			    local.get $src
+			    i32.const 1
+			    i32.add
+			    local.set $src
+			    br 0
+			  end
+			end
+			local.get $dst`,
+	},
+	{
+		// compare compares the bytes of two strings, returning -1, 0 or 1
+		name:    "compare",
+		params:  []local{{"a", i32}, {"b", i32}},
+		results: []valType{i32},
+		locals:  []local{{"i", i32}, {"n", i32}, {"x", i32}, {"y", i32}},
+		code: `
+			;; the length of the shorter one
+			local.get $a
+			i32.load
+			local.get $b
+			i32.load
+			local.get $a
+			i32.load
+			local.get $b
+			i32.load
+			i32.lt_u
+			select
+			local.set $n
+			block
+			  loop
+			    local.get $i
+			    local.get $n
+			    i32.ge_u
+			    br_if 1
+			    local.get $a
+			    local.get $i
+			    i32.add
+			    i32.load8_u offset=4
+			    local.tee $x
+			    local.get $b
+			    local.get $i
+			    i32.add
+			    i32.load8_u offset=4
+			    local.tee $y
+			    i32.ne
+			    if
+			      local.get $x
+			      local.get $y
+			      i32.gt_u
+			      local.get $x
+			      local.get $y
+			      i32.lt_u
+			      i32.sub
+			      return
+			    end
+			    local.get $i
+			    i32.const 1
+			    i32.add
+			    local.set $i
+			    br 0
+			  end
+			end
+			;; a prefix comes first
+			local.get $a
+			i32.load
+			local.get $b
+			i32.load
+			i32.gt_u
+			local.get $a
+			i32.load
+			local.get $b
+			i32.load
+			i32.lt_u
+			i32.sub`,
+	},
+	{
+		// div_int truncates; dividing the smallest int by -1 wraps, where
+		// i64.div_s traps
+		name:    "div_int",
+		params:  []local{{"a", i64}, {"b", i64}},
+		results: []valType{i64},
+		code: `
+			local.get $b
+			i64.eqz
+			if
+			  i32.const "division by zero"
+			  call $fail
+			  unreachable
+			end
+			local.get $b
+			i64.const -1
+			i64.eq
+			if
+			  i64.const 0
+			  local.get $a
+			  i64.sub
+			  return
+			end
+			local.get $a
+			local.get $b
+			i64.div_s`,
+	},
+	{
+		name:    "mod_int",
+		params:  []local{{"a", i64}, {"b", i64}},
+		results: []valType{i64},
+		code: `
+			local.get $b
+			i64.eqz
+			if
+			  i32.const "division by zero"
+			  call $fail
+			  unreachable
+			end
+			local.get $a
+			local.get $b
+			i64.rem_s`,
+	},
+	{
+		name:    "div_float",
+		params:  []local{{"a", f64}, {"b", f64}},
+		results: []valType{f64},
+		code: `
+			local.get $b
+			f64.const 0
+			f64.eq
+			if
+			  i32.const "division by zero"
+			  call $fail
+			  unreachable
+			end
+			local.get $a
+			local.get $b
+			f64.div`,
+	},
+	{
+		// mod_float is math.Mod, which WebAssembly has no instruction for.
+		// It takes the largest |y| * 2^k not above |x| and subtracts it
+		// and each halving of it that fits from |x|, which is exact.
+		name:    "mod_float",
+		params:  []local{{"x", f64}, {"y", f64}},
+		results: []valType{f64},
+		locals:  []local{{"r", f64}, {"t", f64}},
+		code: `
+			local.get $y
+			f64.const 0
+			f64.eq
+			if
+			  i32.const "division by zero"
+			  call $fail
+			  unreachable
+			end
+			;; NaN when x is infinite or either is NaN
+			local.get $x
+			local.get $x
+			f64.sub
+			f64.const 0
+			f64.ne
+			local.get $y
+			local.get $y
+			f64.ne
+			i32.or
+			if
+			  f64.const nan
+			  return
+			end
+			local.get $x
+			f64.abs
+			local.set $r
+			local.get $y
+			f64.abs
+			local.tee $t
+			local.get $r
+			f64.gt
+			if
+			  local.get $x
+			  return
+			end
+			block
+			  loop
+			    local.get $t
+			    f64.const 2
+			    f64.mul
+			    local.get $r
+			    f64.gt
+			    br_if 1
+			    local.get $t
+			    f64.const 2
+			    f64.mul
+			    local.set $t
+			    br 0
+			  end
+			end
+			loop
+			  local.get $r
+			  local.get $t
+			  f64.ge
+			  if
+			    local.get $r
+			    local.get $t
+			    f64.sub
+			    local.set $r
+			  end
+			  local.get $t
+			  local.get $y
+			  f64.abs
+			  f64.ne
+			  if
+			    local.get $t
+			    f64.const 0.5
+			    f64.mul
+			    local.set $t
+			    br 1
+			  end
+			end
+			local.get $r
+			local.get $x
+			f64.copysign`,
+	},
+}
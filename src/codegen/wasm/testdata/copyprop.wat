@@ -0,0 +1,35 @@
+(module
+  (import "lang" "write_int" (func $write_int (param i64)))
+  (import "lang" "write_float" (func $write_float (param f64)))
+  (import "lang" "write_bool" (func $write_bool (param i32)))
+  (import "lang" "write_string" (func $write_string (param i32)))
+  (import "lang" "newline" (func $newline))
+  (memory (export "memory") 1)
+
+  (func $main (export "main")
+    i64.const 7
+    call $write_int
+    i32.const 8 ;; " "
+    call $write_string
+    i32.const 16 ;; "concat"
+    call $write_string
+    i32.const 8 ;; " "
+    call $write_string
+    f64.const 3.5
+    call $write_float
+    i32.const 8 ;; " "
+    call $write_string
+    i64.const -2
+    call $write_int
+    call $newline
+    i64.const 4
+    call $write_int
+    i32.const 8 ;; " "
+    call $write_string
+    i32.const 1
+    call $write_bool
+    call $newline
+  )
+
+  (data (i32.const 8) "\01\00\00\00 \00\00\00\06\00\00\00concat")
+)
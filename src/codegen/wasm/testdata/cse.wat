@@ -0,0 +1,60 @@
+(module
+  (import "lang" "write_int" (func $write_int (param i64)))
+  (import "lang" "write_string" (func $write_string (param i32)))
+  (import "lang" "newline" (func $newline))
+  (memory (export "memory") 1)
+
+  (func $fn_area (param $v0 i64) (param $v1 i64) (result i64)
+    (local $v2 i64)
+    (local $v4 i64)
+    (local $v7 i64)
+    local.get $v0
+    local.get $v1
+    i64.mul
+    local.set $v2
+    local.get $v2
+    i64.const 1
+    i64.add
+    local.set $v4
+    local.get $v2
+    i64.const 2
+    i64.add
+    local.set $v7
+    local.get $v0
+    local.get $v1
+    i64.gt_s
+    if
+      local.get $v4
+      local.get $v7
+      i64.mul
+      local.get $v2
+      i64.sub
+      return
+    end
+    local.get $v4
+    local.get $v7
+    i64.add
+  )
+
+  (func $main (export "main")
+    (local $v2 i64)
+    (local $v5 i64)
+    i64.const 3
+    i64.const 4
+    call $fn_area
+    local.set $v2
+    i64.const 5
+    i64.const 2
+    call $fn_area
+    local.set $v5
+    local.get $v2
+    call $write_int
+    i32.const 8 ;; " "
+    call $write_string
+    local.get $v5
+    call $write_int
+    call $newline
+  )
+
+  (data (i32.const 8) "\01\00\00\00 ")
+)
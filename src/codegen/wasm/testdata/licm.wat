@@ -0,0 +1,76 @@
+(module
+  (import "lang" "write_int" (func $write_int (param i64)))
+  (import "lang" "write_string" (func $write_string (param i32)))
+  (import "lang" "newline" (func $newline))
+  (memory (export "memory") 1)
+  (global $g_g (mut i64) (i64.const 0))
+
+  (func $fn_bump (result i64)
+    (local $v0 i64)
+    (local $v4 i64)
+    (local $v8 i64)
+    global.get $g_g
+    local.set $v0
+    local.get $v0
+    i64.const 1
+    i64.add
+    global.set $g_g
+    global.get $g_g
+    local.set $v4
+    local.get $v4
+    i64.const 1
+    i64.add
+    global.set $g_g
+    global.get $g_g
+    local.set $v8
+    local.get $v8
+  )
+
+  (func $main (export "main")
+    (local $v20 i64)
+    (local $v7 i64)
+    (local $v31 i64)
+    (local $v22 i64)
+    (local $v25 i64)
+    i64.const 0
+    global.set $g_g
+    i64.const 0
+    local.set $v20
+    i64.const 0
+    local.set $v7
+    block $b3
+      loop $b1
+        local.get $v7
+        i64.const 3
+        i64.ge_s
+        br_if $b3
+        local.get $v20
+        i64.const 31
+        i64.add
+        local.set $v22
+        local.get $v7
+        i64.const 1
+        i64.add
+        local.set $v25
+        local.get $v22
+        local.set $v20
+        local.get $v25
+        local.set $v7
+        br $b1
+      end
+    end
+    i64.const 5
+    global.set $g_g
+    call $fn_bump
+    local.set $v31
+    local.get $v31
+    call $write_int
+    i32.const 8 ;; " "
+    call $write_string
+    local.get $v20
+    call $write_int
+    call $newline
+  )
+
+  (data (i32.const 8) "\01\00\00\00 ")
+)
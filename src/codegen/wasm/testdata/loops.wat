@@ -0,0 +1,260 @@
+(module
+  (import "lang" "write_int" (func $write_int (param i64)))
+  (import "lang" "write_float" (func $write_float (param f64)))
+  (import "lang" "write_string" (func $write_string (param i32)))
+  (import "lang" "newline" (func $newline))
+  (memory (export "memory") 1)
+  (global $g_n (mut i64) (i64.const 0))
+
+  (func $fn_fib (param $v0 i64) (result i64)
+    (local $v8 i64)
+    (local $v7 i64)
+    (local $v4 i64)
+    (local $v9 i64)
+    (local $v11 i64)
+    i64.const 1
+    local.set $v8
+    i64.const 0
+    local.set $v7
+    i64.const 0
+    local.set $v4
+    block $b3
+      loop $b1
+        local.get $v4
+        local.get $v0
+        i64.ge_s
+        br_if $b3
+        local.get $v7
+        local.get $v8
+        i64.add
+        local.set $v9
+        local.get $v4
+        i64.const 1
+        i64.add
+        local.set $v11
+        local.get $v9
+        local.get $v8
+        local.get $v11
+        local.set $v4
+        local.set $v7
+        local.set $v8
+        br $b1
+      end
+    end
+    local.get $v7
+  )
+
+  (func $fn_slow (param $v0 i64) (result i64)
+    (local $v5 i64)
+    (local $v8 i64)
+    local.get $v0
+    i64.const 2
+    i64.lt_s
+    if
+      local.get $v0
+      return
+    end
+    local.get $v0
+    i64.const 1
+    i64.sub
+    call $fn_slow
+    local.set $v5
+    local.get $v0
+    i64.const 2
+    i64.sub
+    call $fn_slow
+    local.set $v8
+    local.get $v5
+    local.get $v8
+    i64.add
+  )
+
+  (func $main (export "main")
+    (local $v26 i32)
+    (local $v25 i32)
+    (local $v2 i64)
+    (local $v4 i64)
+    (local $v5 i64)
+    (local $v15 i64)
+    (local $v30 i64)
+    (local $v36 i64)
+    (local $v44 i64)
+    (local $v59 i64)
+    (local $v47 i64)
+    (local $v69 i64)
+    (local $v55 i64)
+    (local $v51 i64)
+    (local $v56 i64)
+    (local $v58 i64)
+    (local $v61 i64)
+    (local $v63 f64)
+    (local $v65 f64)
+    i64.const 0
+    local.set $v2
+    block $b3
+      loop $b1
+        local.get $v2
+        i64.const 10
+        i64.gt_s
+        br_if $b3
+        local.get $v2
+        call $fn_fib
+        local.set $v4
+        local.get $v2
+        call $fn_slow
+        local.set $v5
+        local.get $v2
+        call $write_int
+        i32.const 8 ;; " "
+        call $write_string
+        local.get $v4
+        call $write_int
+        i32.const 8 ;; " "
+        call $write_string
+        local.get $v5
+        call $write_int
+        call $newline
+        local.get $v2
+        i64.const 5
+        i64.add
+        local.set $v2
+        br $b1
+      end
+    end
+    i64.const 0
+    global.set $g_n
+    f64.const 1
+    local.set $v63
+    i64.const 10
+    local.set $v15
+    block $b6
+      loop $b4
+        local.get $v15
+        i64.const 0
+        i64.le_s
+        br_if $b6
+        local.get $v15
+        i64.const 4
+        i64.eq
+        if
+          i32.const 1
+          local.set $v26
+        else
+          local.get $v15
+          i64.const 7
+          i64.gt_s
+          if
+            local.get $v15
+            i64.const 9
+            i64.lt_s
+            local.set $v25
+          else
+            i32.const 0
+            local.set $v25
+          end
+          local.get $v25
+          local.set $v26
+        end
+        local.get $v26
+        if
+          local.get $v15
+          f64.convert_i64_s
+          local.set $v65
+        else
+          local.get $v63
+          local.set $v65
+        end
+        global.get $g_n
+        local.set $v30
+        local.get $v15
+        i64.const 5
+        i64.gt_s
+        if
+          i64.const 1
+          local.set $v36
+        else
+          i64.const 2
+          local.set $v36
+        end
+        local.get $v30
+        local.get $v36
+        i64.add
+        global.set $g_n
+        local.get $v15
+        i64.const -3
+        i64.add
+        local.set $v44
+        local.get $v65
+        local.set $v63
+        local.get $v44
+        local.set $v15
+        br $b4
+      end
+    end
+    i64.const 0
+    local.set $v59
+    i64.const 0
+    local.set $v47
+    block $b18
+      loop $b16
+        local.get $v47
+        i64.const 4
+        i64.ge_s
+        br_if $b18
+        local.get $v59
+        local.set $v55
+        i64.const 0
+        local.set $v51
+        block $b21
+          loop $b19
+            local.get $v51
+            local.get $v47
+            i64.ge_s
+            br_if $b21
+            local.get $v55
+            i64.const 1
+            i64.add
+            local.set $v56
+            local.get $v51
+            i64.const 1
+            i64.add
+            local.set $v58
+            local.get $v56
+            local.set $v55
+            local.get $v58
+            local.set $v51
+            br $b19
+          end
+        end
+        local.get $v47
+        i64.const 1
+        i64.add
+        local.set $v61
+        local.get $v55
+        local.set $v59
+        local.get $v61
+        local.set $v47
+        br $b16
+      end
+    end
+    global.get $g_n
+    local.set $v69
+    local.get $v63
+    call $write_float
+    i32.const 8 ;; " "
+    call $write_string
+    local.get $v69
+    call $write_int
+    i32.const 8 ;; " "
+    call $write_string
+    local.get $v59
+    call $write_int
+    i32.const 8 ;; " "
+    call $write_string
+    i32.const 16 ;; "float"
+    call $write_string
+    call $newline
+  )
+
+  (data (i32.const 8) "\01\00\00\00 \00\00\00\05\00\00\00float")
+)
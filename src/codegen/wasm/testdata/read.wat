@@ -0,0 +1,249 @@
+(module
+  (import "lang" "write_int" (func $write_int (param i64)))
+  (import "lang" "write_float" (func $write_float (param f64)))
+  (import "lang" "write_bool" (func $write_bool (param i32)))
+  (import "lang" "write_string" (func $write_string (param i32)))
+  (import "lang" "newline" (func $newline))
+  (import "lang" "read_int" (func $read_int (result i64)))
+  (import "lang" "read_float" (func $read_float (result f64)))
+  (import "lang" "read_bool" (func $read_bool (result i32)))
+  (import "lang" "read_string" (func $read_string (result i32)))
+  (import "lang" "fail" (func $fail (param i32)))
+  (memory (export "memory") 1)
+  (global $heap (mut i32) (i32.const 72))
+
+  (func $main (export "main")
+    (local $v6 i32)
+    (local $v7 i32)
+    (local $v4 i64)
+    (local $v5 f64)
+    (local $v12 f64)
+    call $read_int
+    local.set $v4
+    call $read_float
+    local.set $v5
+    call $read_bool
+    local.set $v6
+    call $read_string
+    local.set $v7
+    local.get $v5
+    f64.const 2
+    call $div_float
+    local.set $v12
+    local.get $v4
+    i64.const 2
+    i64.mul
+    call $write_int
+    i32.const 8 ;; " "
+    call $write_string
+    local.get $v12
+    call $write_float
+    i32.const 8 ;; " "
+    call $write_string
+    local.get $v6
+    i32.eqz
+    call $write_bool
+    i32.const 8 ;; " "
+    call $write_string
+    local.get $v7
+    i32.const 16 ;; "!"
+    call $concat
+    call $write_string
+    i32.const 8 ;; " "
+    call $write_string
+    i32.const 24 ;; "a"
+    local.get $v7
+    call $compare
+    i32.const 0
+    i32.lt_s
+    call $write_bool
+    i32.const 8 ;; " "
+    call $write_string
+    i64.const -1
+    call $write_int
+    i32.const 8 ;; " "
+    call $write_string
+    i64.const -3
+    call $write_int
+    call $newline
+  )
+
+  (func $alloc (export "alloc") (param $size i32) (result i32)
+    (local $p i32)
+    global.get $heap
+    local.set $p
+    global.get $heap
+    local.get $size
+    i32.const 7
+    i32.add
+    i32.const -8
+    i32.and
+    i32.add
+    global.set $heap
+    block
+      global.get $heap
+      memory.size
+      i32.const 16
+      i32.shl
+      i32.le_u
+      br_if 0
+      global.get $heap
+      memory.size
+      i32.const 16
+      i32.shl
+      i32.sub
+      i32.const 65535
+      i32.add
+      i32.const 16
+      i32.shr_u
+      memory.grow
+      i32.const -1
+      i32.ne
+      br_if 0
+      i32.const 52 ;; "out of memory"
+      call $fail
+      unreachable
+    end
+    local.get $p
+  )
+
+  (func $concat (param $a i32) (param $b i32) (result i32)
+    (local $n i32)
+    (local $s i32)
+    local.get $a
+    i32.load
+    local.get $b
+    i32.load
+    i32.add
+    local.set $n
+    local.get $n
+    i32.const 4
+    i32.add
+    call $alloc
+    local.tee $s
+    local.get $n
+    i32.store
+    local.get $s
+    i32.const 4
+    i32.add
+    local.get $a
+    call $copy
+    local.get $b
+    call $copy
+    drop
+    local.get $s
+  )
+
+  (func $copy (param $dst i32) (param $src i32) (result i32)
+    (local $end i32)
+    local.get $src
+    i32.load
+    local.get $src
+    i32.const 4
+    i32.add
+    local.tee $src
+    i32.add
+    local.set $end
+    block
+      loop
+        local.get $src
+        local.get $end
+        i32.ge_u
+        br_if 1
+        local.get $dst
+        local.get $src
+        i32.load8_u
+        i32.store8
+        local.get $dst
+        i32.const 1
+        i32.add
+        local.set $dst
+        local.get $src
+        i32.const 1
+        i32.add
+        local.set $src
+        br 0
+      end
+    end
+    local.get $dst
+  )
+
+  (func $compare (param $a i32) (param $b i32) (result i32)
+    (local $i i32)
+    (local $n i32)
+    (local $x i32)
+    (local $y i32)
+    local.get $a
+    i32.load
+    local.get $b
+    i32.load
+    local.get $a
+    i32.load
+    local.get $b
+    i32.load
+    i32.lt_u
+    select
+    local.set $n
+    block
+      loop
+        local.get $i
+        local.get $n
+        i32.ge_u
+        br_if 1
+        local.get $a
+        local.get $i
+        i32.add
+        i32.load8_u offset=4
+        local.tee $x
+        local.get $b
+        local.get $i
+        i32.add
+        i32.load8_u offset=4
+        local.tee $y
+        i32.ne
+        if
+          local.get $x
+          local.get $y
+          i32.gt_u
+          local.get $x
+          local.get $y
+          i32.lt_u
+          i32.sub
+          return
+        end
+        local.get $i
+        i32.const 1
+        i32.add
+        local.set $i
+        br 0
+      end
+    end
+    local.get $a
+    i32.load
+    local.get $b
+    i32.load
+    i32.gt_u
+    local.get $a
+    i32.load
+    local.get $b
+    i32.load
+    i32.lt_u
+    i32.sub
+  )
+
+  (func $div_float (param $a f64) (param $b f64) (result f64)
+    local.get $b
+    f64.const 0
+    f64.eq
+    if
+      i32.const 32 ;; "division by zero"
+      call $fail
+      unreachable
+    end
+    local.get $a
+    local.get $b
+    f64.div
+  )
+
+  (data (i32.const 8) "\01\00\00\00 \00\00\00\01\00\00\00!\00\00\00\01\00\00\00a\00\00\00\10\00\00\00division by zero\0d\00\00\00out of memory")
+)
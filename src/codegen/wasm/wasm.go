@@ -0,0 +1,248 @@
+// Package wasm translates IR modules to WebAssembly, in the binary format
+// run by browsers and other hosts or in the text format, without any tool
+// outside of Go. Ints are i64 and floats f64, bools and strings i32, a
+// string being the address of its length and bytes in the memory of the
+// module. Control flow is the structured code recovered by ir.Structure:
+// Scopes become blocks and Loops loops, left and restarted by br.
+//
+// The host runs a module by calling its exported main function. It must
+// provide the functions printing, reading and failing the module imports
+// from "lang", described by hostFuncs, and can reach its exported memory
+// and alloc function.
+package wasm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"github.com/RyanOliveira00/go-compiler/src/ir"
+)
+
+type valType byte
+
+const (
+	i32 valType = 0x7f
+	i64 valType = 0x7e
+	f64 valType = 0x7c
+)
+
+func (t valType) String() string {
+	switch t {
+	case i32:
+		return "i32"
+	case i64:
+		return "i64"
+	case f64:
+		return "f64"
+	}
+	return fmt.Sprintf("valType(%#x)", byte(t))
+}
+
+// valueType is the type a value of type t is represented by.
+func valueType(t ir.Type) valType {
+	switch t {
+	case ir.Int:
+		return i64
+	case ir.Float:
+		return f64
+	case ir.Bool, ir.String:
+		return i32
+	}
+	panic(fmt.Sprintf("wasm: no value type for %s", t))
+}
+
+type local struct {
+	name string
+	typ  valType
+}
+
+// funcDecl is a function of a module, or one it imports when it has no
+// code.
+type funcDecl struct {
+	name    string // how the text format refers to it
+	export  string
+	params  []local
+	results []valType
+	locals  []local // the locals following the params
+	code    []instr
+}
+
+// signature identifies the type of f.
+func (f *funcDecl) signature() string {
+	var params []valType
+	for _, param := range f.params {
+		params = append(params, param.typ)
+	}
+	return fmt.Sprint(params, f.results)
+}
+
+type global struct {
+	name string
+	typ  valType
+	init instr
+}
+
+// dataStart is where the strings of a module start in memory, leaving the
+// address 0 unused.
+const dataStart = 8
+
+// Module is a WebAssembly module, written out by Binary and Text.
+type Module struct {
+	imports []*funcDecl
+	funcs   []*funcDecl
+	globals []global
+
+	// data holds the strings of the module, from dataStart on
+	data    []byte
+	strings map[string]int64
+}
+
+// Generate translates m to WebAssembly.
+func Generate(m *ir.Module) (*Module, error) {
+	mod := &Module{strings: make(map[string]int64)}
+	for _, g := range m.Globals {
+		t := valueType(g.Type)
+		init := instr{op: opI64Const}
+		switch g.Type {
+		case ir.Float:
+			init = instr{op: opF64Const}
+		case ir.Bool:
+			init = instr{op: opI32Const}
+		case ir.String:
+			init = mod.stringConst("")
+		}
+		mod.globals = append(mod.globals, global{name: "g_" + g.Name, typ: t, init: init})
+	}
+
+	for _, f := range m.AllFuncs() {
+		stmts, err := ir.Structure(f)
+		if err != nil {
+			return nil, err
+		}
+		mod.funcs = append(mod.funcs, newFunction(mod, f).generate(stmts))
+	}
+	if err := mod.link(); err != nil {
+		return nil, err
+	}
+	return mod, nil
+}
+
+// stringConst is the instruction pushing the address of s, which is
+// added to the data of the module the first time.
+func (mod *Module) stringConst(s string) instr {
+	addr, exists := mod.strings[s]
+	if !exists {
+		for len(mod.data)%4 != 0 {
+			mod.data = append(mod.data, 0)
+		}
+		addr = int64(dataStart + len(mod.data))
+		mod.data = binary.LittleEndian.AppendUint32(mod.data, uint32(len(s)))
+		mod.data = append(mod.data, s...)
+		mod.strings[s] = addr
+	}
+	return instr{op: opI32Const, imm: addr, comment: strconv.Quote(s)}
+}
+
+// link adds the host functions and the runtime the code of mod calls, and
+// resolves the functions and globals instructions refer to by name.
+func (mod *Module) link() error {
+	used := make(map[string]bool)
+	var pending []string
+	use := func(name string) {
+		if !used[name] {
+			used[name] = true
+			pending = append(pending, name)
+		}
+	}
+	scan := func(code []instr) {
+		for _, in := range code {
+			if in.op == opCall || in.op == opGlobalGet || in.op == opGlobalSet {
+				use(in.name)
+			}
+		}
+	}
+	for _, f := range mod.funcs {
+		scan(f.code)
+	}
+
+	assembled := make(map[string]*funcDecl)
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		for _, h := range hostFuncs {
+			if h.name == name {
+				for _, need := range h.needs {
+					use(need)
+				}
+			}
+		}
+		for _, h := range helpers {
+			if h.name == name {
+				f := &funcDecl{name: h.name, params: h.params, results: h.results, locals: h.locals}
+				f.code = mod.assemble(h.code, append(append([]local{}, h.params...), h.locals...))
+				assembled[name] = f
+				scan(f.code)
+			}
+		}
+	}
+
+	for _, h := range hostFuncs {
+		if used[h.name] {
+			f := &funcDecl{name: h.name, results: h.results}
+			for _, param := range h.params {
+				f.params = append(f.params, local{typ: param})
+			}
+			mod.imports = append(mod.imports, f)
+		}
+	}
+	for _, h := range helpers {
+		if f := assembled[h.name]; f != nil {
+			if f.name == "alloc" {
+				f.export = "alloc"
+			}
+			mod.funcs = append(mod.funcs, f)
+		}
+	}
+	if used["heap"] {
+		// Allocations start after the data, which no longer grows
+		mod.globals = append(mod.globals, global{
+			name: "heap",
+			typ:  i32,
+			init: instr{op: opI32Const, imm: int64(dataStart+len(mod.data)+7) &^ 7},
+		})
+	}
+
+	funcs := make(map[string]int64)
+	for i, f := range append(append([]*funcDecl{}, mod.imports...), mod.funcs...) {
+		funcs[f.name] = int64(i)
+	}
+	globals := make(map[string]int64)
+	for i, g := range mod.globals {
+		globals[g.name] = int64(i)
+	}
+	for _, f := range mod.funcs {
+		for i, in := range f.code {
+			var index int64
+			var exists bool
+			switch in.op {
+			case opCall:
+				index, exists = funcs[in.name]
+			case opGlobalGet, opGlobalSet:
+				index, exists = globals[in.name]
+			default:
+				continue
+			}
+			if !exists {
+				return fmt.Errorf("wasm: %s: no %s %s", f.name, in.op, in.name)
+			}
+			f.code[i].imm = index
+		}
+	}
+	return nil
+}
+
+// pages is the number of 64 KiB pages of memory the module starts with.
+func (mod *Module) pages() uint64 {
+	return uint64(dataStart+len(mod.data))>>16 + 1
+}
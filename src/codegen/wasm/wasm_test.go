@@ -0,0 +1,162 @@
+package wasm_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"github.com/RyanOliveira00/go-compiler/src/codegen/wasm"
+	"github.com/RyanOliveira00/go-compiler/src/internal/langtest"
+	"github.com/RyanOliveira00/go-compiler/src/ir"
+)
+
+// TestText compares the text format of the programs of the IR with the
+// .wat files of testdata.
+func TestText(t *testing.T) {
+	for _, p := range langtest.Programs(t, "") {
+		t.Run(p.Name, func(t *testing.T) {
+			mod, err := wasm.Generate(langtest.Lower(t, p, ir.NewPassManager()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			langtest.Golden(t, filepath.Join("testdata", p.Name+".wat"), mod.Text())
+		})
+	}
+}
+
+// TestRun validates and runs the binary modules of the programs of the
+// IR, optimized by every pass, by none and by each one alone, and
+// compares what they print with the interpreter.
+func TestRun(t *testing.T) {
+	configs := map[string][]string{"all": ir.PassNames(), "none": nil}
+	for _, name := range ir.PassNames() {
+		configs["only-"+name] = []string{name}
+	}
+
+	for _, p := range langtest.Programs(t, "") {
+		want := langtest.Interpret(t, p)
+		for config, enabled := range configs {
+			t.Run(p.Name+"/"+config, func(t *testing.T) {
+				pm := ir.NewPassManager()
+				for _, name := range ir.PassNames() {
+					pm.Enable(name, false)
+				}
+				for _, name := range enabled {
+					pm.Enable(name, true)
+				}
+				mod, err := wasm.Generate(langtest.Lower(t, p, pm))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got, err := run(mod.Binary(), p.Input)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Errorf("output differs from the interpreter's:\n%s", langtest.Diff(want, got))
+				}
+			})
+		}
+	}
+}
+
+// run runs the module in binary, with a host reading input and writing
+// like the interpreter, and returns what it printed.
+func run(binary []byte, input string) (string, error) {
+	ctx := context.Background()
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+
+	var out strings.Builder
+	lines := bufio.NewScanner(strings.NewReader(input))
+	word := func() string {
+		if !lines.Scan() {
+			return ""
+		}
+		if fields := strings.Fields(lines.Text()); len(fields) > 0 {
+			return fields[0]
+		}
+		return ""
+	}
+	invalid := fmt.Errorf("invalid input for type")
+
+	_, err := r.NewHostModuleBuilder("lang").
+		NewFunctionBuilder().WithFunc(func(x int64) { fmt.Fprint(&out, x) }).Export("write_int").
+		NewFunctionBuilder().WithFunc(func(x float64) { fmt.Fprint(&out, x) }).Export("write_float").
+		NewFunctionBuilder().WithFunc(func(x uint32) { fmt.Fprint(&out, x != 0) }).Export("write_bool").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, addr uint32) {
+		out.WriteString(readString(m, addr))
+	}).Export("write_string").
+		NewFunctionBuilder().WithFunc(func() { out.WriteString("\n") }).Export("newline").
+		NewFunctionBuilder().WithFunc(func() int64 {
+		x, err := strconv.ParseInt(word(), 10, 64)
+		if err != nil {
+			panic(invalid)
+		}
+		return x
+	}).Export("read_int").
+		NewFunctionBuilder().WithFunc(func() float64 {
+		x, err := strconv.ParseFloat(word(), 64)
+		if err != nil {
+			panic(invalid)
+		}
+		return x
+	}).Export("read_float").
+		NewFunctionBuilder().WithFunc(func() uint32 {
+		x, err := strconv.ParseBool(word())
+		if err != nil {
+			panic(invalid)
+		}
+		if x {
+			return 1
+		}
+		return 0
+	}).Export("read_bool").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module) uint32 {
+		s := word()
+		results, err := m.ExportedFunction("alloc").Call(ctx, uint64(4+len(s)))
+		if err != nil {
+			panic(err)
+		}
+		addr := uint32(results[0])
+		m.Memory().WriteUint32Le(addr, uint32(len(s)))
+		m.Memory().WriteString(addr+4, s)
+		return addr
+	}).Export("read_string").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, addr uint32) {
+		panic(fmt.Errorf("%s", readString(m, addr)))
+	}).Export("fail").
+		Instantiate(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// Compiling validates the module
+	compiled, err := r.CompileModule(ctx, binary)
+	if err != nil {
+		return "", err
+	}
+	mod, err := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig())
+	if err != nil {
+		return "", err
+	}
+	if _, err := mod.ExportedFunction("main").Call(ctx); err != nil {
+		return out.String(), err
+	}
+	return out.String(), nil
+}
+
+// readString reads the string at addr, its length followed by its bytes.
+func readString(m api.Module, addr uint32) string {
+	size, _ := m.Memory().ReadUint32Le(addr)
+	bytes, _ := m.Memory().Read(addr+4, size)
+	return string(bytes)
+}
@@ -0,0 +1,127 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+)
+
+// ArrayValue is the runtime representation of []T. Arrays are shared by
+// reference, so push is visible through every variable holding the array.
+type ArrayValue struct {
+	elements []interface{}
+}
+
+func NewArrayValue(elements []interface{}) *ArrayValue {
+	return &ArrayValue{elements: elements}
+}
+
+func (a *ArrayValue) Len() int {
+	return len(a.elements)
+}
+
+func (a *ArrayValue) index(i interface{}) (int, error) {
+	n, ok := toIndex(i)
+	if !ok {
+		return 0, fmt.Errorf("invalid array index: %v", i)
+	}
+	if n < 0 || n >= len(a.elements) {
+		return 0, fmt.Errorf("index %d out of range [0:%d]", n, len(a.elements))
+	}
+	return n, nil
+}
+
+func (a *ArrayValue) Get(i interface{}) (interface{}, error) {
+	n, err := a.index(i)
+	if err != nil {
+		return nil, err
+	}
+	return a.elements[n], nil
+}
+
+func (a *ArrayValue) Set(i interface{}, value interface{}) error {
+	n, err := a.index(i)
+	if err != nil {
+		return err
+	}
+	a.elements[n] = value
+	return nil
+}
+
+func (a *ArrayValue) Push(value interface{}) {
+	a.elements = append(a.elements, value)
+}
+
+func (a *ArrayValue) String() string {
+	parts := make([]string, 0, len(a.elements))
+	for _, element := range a.elements {
+		parts = append(parts, formatValue(element))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// toIndex accepts integral numbers as array indexes.
+func toIndex(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int64:
+		return int(v), true
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), true
+		}
+	}
+	return 0, false
+}
+
+// arrayMethod returns the method name bound to array.
+func (c *Compiler) arrayMethod(array *ArrayValue, name string) (*NativeFunction, bool) {
+	var fn func(args []interface{}) (interface{}, error)
+
+	switch name {
+	case "push":
+		fn = func(args []interface{}) (interface{}, error) {
+			if err := c.limiter.alloc(valueSize); err != nil {
+				return nil, err
+			}
+			array.Push(copyValue(args[0]))
+			return nil, nil
+		}
+	case "map":
+		fn = func(args []interface{}) (interface{}, error) {
+			if err := c.limiter.alloc(int64(valueSize * array.Len())); err != nil {
+				return nil, err
+			}
+			mapped := make([]interface{}, 0, array.Len())
+			for _, element := range array.elements {
+				value, err := c.callValue(args[0], []interface{}{copyValue(element)})
+				if err != nil {
+					return nil, err
+				}
+				mapped = append(mapped, copyValue(value))
+			}
+			return NewArrayValue(mapped), nil
+		}
+	case "filter":
+		fn = func(args []interface{}) (interface{}, error) {
+			filtered := []interface{}{}
+			for _, element := range array.elements {
+				keep, err := c.callValue(args[0], []interface{}{copyValue(element)})
+				if err != nil {
+					return nil, err
+				}
+				if isTruthy(keep) {
+					if err := c.limiter.alloc(valueSize); err != nil {
+						return nil, err
+					}
+					filtered = append(filtered, copyValue(element))
+				}
+			}
+			return NewArrayValue(filtered), nil
+		}
+	default:
+		return nil, false
+	}
+
+	return &NativeFunction{Name: name, Params: []ast.Type{nil}, Fn: fn}, true
+}
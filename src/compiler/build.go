@@ -0,0 +1,65 @@
+package compiler
+
+import (
+	"path/filepath"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/checker"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+// CheckFile parses and checks the program in the file at path, and the
+// modules it imports, without running them, and returns the program
+// optimized, for the code generators working from the IR. The imported
+// modules are not returned: the IR holds a single file, so ir.Lower
+// rejects programs with imports.
+func CheckFile(path string) (ast.BlockStmt, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ast.BlockStmt{}, err
+	}
+	c := newCompiler(abs, nil)
+	if err := c.checkFile(); err != nil {
+		return ast.BlockStmt{}, err
+	}
+	return c.optimized, nil
+}
+
+// CheckedModule is a file of a program checked by CheckModules.
+type CheckedModule struct {
+	Path    string // absolute
+	Source  string
+	Program ast.BlockStmt
+	// Types holds the type of every expression of Program, as recorded
+	// in checker.Checker.Types
+	Types   map[lexer.Span]checker.Type
+	Exports map[string]checker.Export
+}
+
+// CheckModules parses and checks the program in the file at path and
+// every module it imports, for the code generators translating each file
+// on its own. None of them runs, and the programs are the ones parsed,
+// not optimized. Modules come after the ones they import, the program
+// last.
+func CheckModules(path string) ([]*CheckedModule, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	c := newCompiler(abs, nil)
+	if err := c.checkFile(); err != nil {
+		return nil, err
+	}
+
+	modules := make([]*CheckedModule, 0, len(c.modules.order))
+	for _, module := range c.modules.order {
+		modules = append(modules, &CheckedModule{
+			Path:    module.path,
+			Source:  module.source,
+			Program: module.program,
+			Types:   module.checker.Types,
+			Exports: module.checker.Exports(),
+		})
+	}
+	return modules, nil
+}
@@ -0,0 +1,76 @@
+package compiler
+
+import "fmt"
+
+type builtinFunc func(c *Compiler, args []interface{}) (interface{}, error)
+
+var builtins = map[string]builtinFunc{
+	"len":     builtinLen,
+	"has":     builtinHas,
+	"delete":  builtinDelete,
+	"println": builtinPrintln,
+	"error":   builtinError,
+}
+
+func builtinLen(c *Compiler, args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("len expects 1 argument, got %d", len(args))
+	}
+
+	switch v := args[0].(type) {
+	case *MapValue:
+		return int64(v.Len()), nil
+	case *ArrayValue:
+		return int64(v.Len()), nil
+	case *RangeValue:
+		return int64(v.Len()), nil
+	case string:
+		return int64(len(v)), nil
+	default:
+		return nil, fmt.Errorf("invalid argument for len: %s", typeName(args[0]))
+	}
+}
+
+func builtinHas(c *Compiler, args []interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("has expects 2 arguments, got %d", len(args))
+	}
+
+	m, ok := args[0].(*MapValue)
+	if !ok {
+		return nil, fmt.Errorf("invalid argument for has: %s", typeName(args[0]))
+	}
+	return m.Has(args[1]), nil
+}
+
+func builtinDelete(c *Compiler, args []interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("delete expects 2 arguments, got %d", len(args))
+	}
+
+	m, ok := args[0].(*MapValue)
+	if !ok {
+		return nil, fmt.Errorf("invalid argument for delete: %s", typeName(args[0]))
+	}
+	m.Delete(args[1])
+	return nil, nil
+}
+
+func builtinPrintln(c *Compiler, args []interface{}) (interface{}, error) {
+	for i, arg := range args {
+		args[i] = displayValue(arg)
+	}
+	fmt.Fprintln(c.stdout, args...)
+	return nil, nil
+}
+
+func builtinError(c *Compiler, args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("error expects 1 argument, got %d", len(args))
+	}
+	message, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid argument for error: %s", typeName(args[0]))
+	}
+	return newError(message, nil), nil
+}
@@ -0,0 +1,147 @@
+package compiler
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type FSAccess int
+
+const (
+	FSNone FSAccess = iota
+	FSReadOnly
+	FSReadWrite
+)
+
+// Capabilities lists what a program may reach on the host. Every stdlib
+// function that touches the host, and every import, checks them and
+// fails with a PermissionError instead.
+type Capabilities struct {
+	FS FSAccess
+	// Root confines file system access to a directory. Relative paths are
+	// resolved against it and paths leaving it are denied. Empty means no
+	// confinement.
+	Root string
+	// Clock replaces the system clock for the time module when set.
+	Clock func() time.Time
+	// Stdin allows read().
+	Stdin bool
+}
+
+// TrustedCapabilities grants full access, as a program run from the
+// command line has.
+var TrustedCapabilities = Capabilities{FS: FSReadWrite, Stdin: true}
+
+// Profiles are the names accepted by Profile.
+var Profiles = []string{"trusted", "readonly", "sandbox"}
+
+// Profile returns a predefined capability set:
+//
+//	trusted   full access
+//	readonly  read-only file system under root, no stdin
+//	sandbox   no file system, a fixed clock and no stdin
+func Profile(name, root string) (Capabilities, error) {
+	switch name {
+	case "trusted":
+		return TrustedCapabilities, nil
+	case "readonly":
+		return Capabilities{FS: FSReadOnly, Root: root}, nil
+	case "sandbox":
+		return Capabilities{FS: FSNone, Clock: FixedClock(time.Unix(0, 0).UTC())}, nil
+	default:
+		return Capabilities{}, fmt.Errorf("unknown profile %q, expected one of %s", name, strings.Join(Profiles, ", "))
+	}
+}
+
+// FixedClock returns a clock that always reads t, making programs that
+// use the time module deterministic.
+func FixedClock(t time.Time) func() time.Time {
+	return func() time.Time { return t }
+}
+
+// SetCapabilities replaces the capabilities of c and of the modules it
+// imports.
+func (c *Compiler) SetCapabilities(caps Capabilities) {
+	*c.capabilities = caps
+}
+
+// PermissionError reports an operation denied by the capabilities. It
+// unwraps to fs.ErrPermission.
+type PermissionError struct {
+	Op     string
+	Reason string
+}
+
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("%s: permission denied: %s", e.Op, e.Reason)
+}
+
+func (e *PermissionError) Unwrap() error {
+	return fs.ErrPermission
+}
+
+func (caps *Capabilities) now() time.Time {
+	if caps.Clock != nil {
+		return caps.Clock()
+	}
+	return time.Now()
+}
+
+// resolvePath checks that op may access path and returns the path to use
+// on the host.
+func (caps *Capabilities) resolvePath(op, path string, write bool) (string, error) {
+	if caps.FS == FSNone {
+		return "", &PermissionError{Op: op, Reason: "file system access is disabled"}
+	}
+	if write && caps.FS != FSReadWrite {
+		return "", &PermissionError{Op: op, Reason: "file system is read-only"}
+	}
+	if caps.Root == "" {
+		return path, nil
+	}
+
+	root, err := filepath.Abs(caps.Root)
+	if err != nil {
+		return "", err
+	}
+	target := path
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(root, target)
+	}
+	target = filepath.Clean(target)
+
+	outside := &PermissionError{Op: op, Reason: fmt.Sprintf("%s is outside %s", path, caps.Root)}
+	if !within(root, target) {
+		return "", outside
+	}
+
+	// A symlink inside the root may still point outside of it, and so may
+	// one of the directories a file that does not exist yet would be
+	// created in
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return target, nil
+	}
+	for existing := target; ; existing = filepath.Dir(existing) {
+		if _, err := os.Lstat(existing); err == nil {
+			resolved, err := filepath.EvalSymlinks(existing)
+			if err != nil || !within(realRoot, resolved) {
+				return "", outside
+			}
+			break
+		}
+		if existing == filepath.Dir(existing) {
+			break
+		}
+	}
+	return target, nil
+}
+
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,134 @@
+package compiler
+
+import (
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/checker"
+)
+
+// Number literals written without a decimal point evaluate to int64 and
+// the others to float64, the same split the checker makes between int
+// and float. An int stored where a float is expected is converted by
+// coerce, from a declared type, or by convert, from the type the checker
+// found where it is stored, so typeof and division see the static type.
+
+// numberValue is the runtime value of a number literal.
+func numberValue(n ast.NumberExpr) interface{} {
+	if n.Float {
+		return n.Value
+	}
+	return n.Int
+}
+
+// coerce converts v to the representation of a value of type t, resolving
+// type names in env.
+func coerce(env *Environment, t ast.Type, v interface{}) interface{} {
+	switch t := t.(type) {
+	case ast.SymbolType:
+		if t.Name == "float" {
+			return toFloatValue(v)
+		}
+		if declared, exists := env.getType(t.Name); exists {
+			return coerce(env, declared, v)
+		}
+	case ast.OptionalType:
+		if v != nil {
+			return coerce(env, t.Underlying, v)
+		}
+	case ast.UnionType:
+		// Only an int that has nowhere to go but a float member changes
+		if !unionHas(t, "int") && unionHas(t, "float") {
+			return toFloatValue(v)
+		}
+	case ast.ArrayType:
+		return convertElements(v, func(element interface{}) interface{} {
+			return coerce(env, t.Underlying, element)
+		})
+	case ast.MapType:
+		return convertElements(v, func(value interface{}) interface{} {
+			return coerce(env, t.Value, value)
+		})
+	}
+	return v
+}
+
+func unionHas(t ast.UnionType, name string) bool {
+	for _, member := range t.Types {
+		if symbol, ok := member.(ast.SymbolType); ok && symbol.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// convert converts v to the representation of a value of the checker
+// type t.
+func convert(t checker.Type, v interface{}) interface{} {
+	switch t := t.(type) {
+	case checker.BasicType:
+		if t == checker.Float {
+			return toFloatValue(v)
+		}
+	case checker.OptionalType:
+		if v != nil {
+			return convert(t.Elem, v)
+		}
+	case checker.UnionType:
+		hasInt, hasFloat := false, false
+		for _, member := range t.Types {
+			hasInt = hasInt || member == checker.Int
+			hasFloat = hasFloat || member == checker.Float
+		}
+		if !hasInt && hasFloat {
+			return toFloatValue(v)
+		}
+	case checker.ArrayType:
+		return convertElements(v, func(element interface{}) interface{} {
+			return convert(t.Elem, element)
+		})
+	case checker.MapType:
+		return convertElements(v, func(value interface{}) interface{} {
+			return convert(t.Value, value)
+		})
+	}
+	return v
+}
+
+func toFloatValue(v interface{}) interface{} {
+	if n, ok := v.(int64); ok {
+		return float64(n)
+	}
+	return v
+}
+
+// convertElements applies f to the elements of an array or the values of
+// a map. The result is a copy when any of them changes, as the caller
+// may still hold the original with its own type.
+func convertElements(v interface{}, f func(interface{}) interface{}) interface{} {
+	switch v := v.(type) {
+	case *ArrayValue:
+		for i, element := range v.elements {
+			if converted := f(element); converted != element {
+				elements := append([]interface{}{}, v.elements...)
+				elements[i] = converted
+				for j := i + 1; j < len(elements); j++ {
+					elements[j] = f(elements[j])
+				}
+				return NewArrayValue(elements)
+			}
+		}
+	case *MapValue:
+		for i, key := range v.keys {
+			if converted := f(v.entries[key]); converted != v.entries[key] {
+				m := NewMapValue()
+				for _, key := range v.keys[:i] {
+					m.Set(key, v.entries[key])
+				}
+				for _, key := range v.keys[i:] {
+					m.Set(key, f(v.entries[key]))
+				}
+				return m
+			}
+		}
+	}
+	return v
+}
@@ -2,11 +2,20 @@
 package compiler
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"math"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/checker"
+	"github.com/RyanOliveira00/go-compiler/src/ir"
 	"github.com/RyanOliveira00/go-compiler/src/lexer"
+	"github.com/RyanOliveira00/go-compiler/src/optimize"
 )
 
 type ValueType int
@@ -16,35 +25,229 @@ const (
 	ValueTypeFloat
 	ValueTypeString
 	ValueTypeBool
+	ValueTypeMap
+	ValueTypeStruct
+	ValueTypeFunction
+	ValueTypeClass
+	ValueTypeObject
+	ValueTypeArray
+	ValueTypeTime
+	ValueTypeDuration
+	ValueTypeNamespace
+	ValueTypeRange
+	ValueTypeEnum
+	ValueTypeNull
 )
 
+var valueTypeNames = map[ValueType]string{
+	ValueTypeInt:       "int",
+	ValueTypeFloat:     "float",
+	ValueTypeString:    "string",
+	ValueTypeBool:      "bool",
+	ValueTypeMap:       "map",
+	ValueTypeStruct:    "struct",
+	ValueTypeFunction:  "function",
+	ValueTypeClass:     "class",
+	ValueTypeObject:    "object",
+	ValueTypeArray:     "array",
+	ValueTypeTime:      "Time",
+	ValueTypeDuration:  "Duration",
+	ValueTypeNamespace: "namespace",
+	ValueTypeRange:     "range",
+	ValueTypeEnum:      "enum",
+	ValueTypeNull:      "null",
+}
+
+func (t ValueType) String() string {
+	if name, exists := valueTypeNames[t]; exists {
+		return name
+	}
+	return fmt.Sprintf("ValueType(%d)", int(t))
+}
+
 type Value struct {
 	Type  ValueType
 	Value interface{}
 }
 
-type Environment struct {
-	variables map[string]Value
-}
-
 type Compiler struct {
-	env *Environment
+	env     *Environment
+	checker *checker.Checker
+	// path of the module being compiled, empty for the REPL
+	path string
+	// types holds the types the checker found for the expressions of the
+	// code running, those of the program or REPL line declaring it
+	types   map[lexer.Span]checker.Type
+	modules *moduleLoader
+	stdout  io.Writer
+	stdin   io.Reader
+	limiter *limiter
+	// function is the name of the function being executed, empty at the
+	// top level
+	function string
+	// capabilities is shared with imported modules and read by the stdlib
+	// at call time, so SetCapabilities applies to all of them
+	capabilities *Capabilities
+	// dump receives each file's program once optimized, nil unless set
+	// with DumpOptimized
+	dump io.Writer
+	// dumpIR receives each file's program lowered to SSA, nil unless set
+	// with DumpIR
+	dumpIR io.Writer
+	passes *ir.PassManager
+
+	// source and program are those of the file at path, checked but not
+	// yet run when the file is a module another one imports; optimized
+	// is the program that runs, and ran tells whether it has
+	source    string
+	program   ast.BlockStmt
+	optimized ast.BlockStmt
+	ran       bool
 }
 
 func New() *Compiler {
-	return &Compiler{
-		env: &Environment{
-			variables: make(map[string]Value),
-		},
+	return newCompiler("", nil)
+}
+
+// newCompiler creates the compiler of one module. A module shares the
+// loader, streams, limits and capabilities of the program importing it.
+func newCompiler(path string, parent *Compiler) *Compiler {
+	c := &Compiler{
+		env:     NewEnvironment(nil),
+		checker: checker.New(),
+		path:    path,
+	}
+	c.checker.Importer = c
+	c.env.types["Error"] = errorType
+
+	if parent == nil {
+		capabilities := TrustedCapabilities
+		c.modules = newModuleLoader()
+		c.stdout, c.stdin = os.Stdout, os.Stdin
+		c.limiter = newLimiter()
+		c.capabilities = &capabilities
+		c.passes = ir.NewPassManager()
+	} else {
+		c.modules = parent.modules
+		c.stdout, c.stdin = parent.stdout, parent.stdin
+		c.limiter = parent.limiter
+		c.capabilities = parent.capabilities
+		c.dump = parent.dump
+		c.dumpIR = parent.dumpIR
+		c.passes = parent.passes
+	}
+
+	// The stdlib declarations are static, failing here is a bug in them
+	if err := c.installStdlib(); err != nil {
+		panic(err)
 	}
+	return c
 }
 
-func (c *Compiler) Compile(program ast.BlockStmt) (interface{}, error) {
+// Compile checks and runs program. Execution stops with a CanceledError
+// once ctx is done, and with a StepLimitError, CallDepthError or
+// MemoryLimitError when it exceeds the limits set with SetLimits.
+func (c *Compiler) Compile(ctx context.Context, program ast.BlockStmt) (interface{}, error) {
+	defer c.limiter.begin(ctx)()
+	return c.compile(program)
+}
+
+// SetLimits bounds the resources used by each later Compile or RunFile
+// call, including the modules they import.
+func (c *Compiler) SetLimits(limits Limits) {
+	c.limiter.limits = limits
+}
+
+// DumpOptimized writes the source of each program, and of the modules it
+// imports, to w as it will run once optimized.
+func (c *Compiler) DumpOptimized(w io.Writer) {
+	c.dump = w
+}
+
+// DumpIR writes each program, and the modules it imports, to w in the SSA
+// form shared by the code generators. Programs the IR cannot represent
+// get a comment saying why and still run.
+func (c *Compiler) DumpIR(w io.Writer) {
+	c.dumpIR = w
+}
+
+// IRPasses configures the optimizations run on the IR before DumpIR
+// writes it. When its Dump writer is set, the IR is lowered and optimized
+// even without DumpIR.
+func (c *Compiler) IRPasses() *ir.PassManager {
+	return c.passes
+}
+
+func (c *Compiler) writeIR(program ast.BlockStmt) {
+	w := c.dumpIR
+	if w == nil {
+		w = c.passes.Dump
+	}
+	if c.path != "" {
+		fmt.Fprintf(w, "// %s\n", displayPath(c.path))
+	}
+
+	module, err := ir.Lower(program)
+	if err == nil {
+		err = ir.Verify(module)
+	}
+	if err != nil {
+		fmt.Fprintf(w, "// cannot lower: %s\n", err)
+		return
+	}
+	if err := c.passes.Run(module); err != nil {
+		fmt.Fprintf(w, "// cannot optimize: %s\n", err)
+		return
+	}
+	if c.dumpIR != nil {
+		fmt.Fprint(c.dumpIR, module)
+	}
+}
+
+func (c *Compiler) compile(program ast.BlockStmt) (interface{}, error) {
+	program, err := c.check(program)
+	if err != nil {
+		return nil, err
+	}
+	return c.run(program)
+}
+
+// check checks program, and the modules it imports, and returns it
+// optimized.
+func (c *Compiler) check(program ast.BlockStmt) (ast.BlockStmt, error) {
+	c.checker.Types = make(map[lexer.Span]checker.Type)
+	if err := c.checker.Check(program); err != nil {
+		return ast.BlockStmt{}, err
+	}
+	c.types = c.checker.Types
+
+	program = optimize.Program(program, c.types)
+	if c.dump != nil {
+		if c.path != "" {
+			fmt.Fprintf(c.dump, "// %s\n", displayPath(c.path))
+		}
+		fmt.Fprint(c.dump, optimize.Format(program))
+	}
+	if c.dumpIR != nil || c.passes.Dump != nil {
+		c.writeIR(program)
+	}
+	return program, nil
+}
+
+// run runs a checked program, and the modules it imports first.
+func (c *Compiler) run(program ast.BlockStmt) (interface{}, error) {
 	var result interface{}
 	var err error
 
+	if err = c.hoist(program.Body); err != nil {
+		return nil, err
+	}
+
 	for _, stmt := range program.Body {
-		result, err = c.executeStmt(stmt)
+		if err = c.limiter.checkContext(); err != nil {
+			return nil, err
+		}
+		result, err = c.executeTopLevelStmt(stmt)
 		if err != nil {
 			return nil, err
 		}
@@ -53,7 +256,55 @@ func (c *Compiler) Compile(program ast.BlockStmt) (interface{}, error) {
 	return result, nil
 }
 
+// hoist binds imports, types, functions and classes before the program
+// runs, matching the checker which lets them be used ahead of declaration.
+func (c *Compiler) hoist(body []ast.Stmt) error {
+	for _, stmt := range body {
+		switch s := unwrapExport(stmt).(type) {
+		case ast.ImportStmt:
+			if err := c.executeImport(s); err != nil {
+				return err
+			}
+		case ast.TypeDeclStmt:
+			c.env.types[s.Name] = s.Type
+		case ast.FunctionDeclStmt:
+			c.env.define(s.Name, Value{
+				Type:  ValueTypeFunction,
+				Value: &FunctionValue{Decl: s, env: c.env, path: c.path, types: c.types},
+			})
+		case ast.ClassDeclStmt:
+			c.env.define(s.Name, Value{
+				Type:  ValueTypeClass,
+				Value: &ClassValue{Decl: s, env: typeParamEnv(c.env, s.TypeParams), path: c.path, types: c.types},
+			})
+		case ast.EnumDeclStmt:
+			c.env.define(s.Name, Value{Type: ValueTypeEnum, Value: c.newEnumValue(s, c.env)})
+		}
+	}
+	return nil
+}
+
+func (c *Compiler) executeTopLevelStmt(stmt ast.Stmt) (interface{}, error) {
+	switch s := unwrapExport(stmt).(type) {
+	case ast.ImportStmt, ast.TypeDeclStmt, ast.FunctionDeclStmt, ast.ClassDeclStmt, ast.EnumDeclStmt:
+		return nil, nil // already bound by hoist
+	default:
+		return c.executeStmt(s)
+	}
+}
+
+func unwrapExport(stmt ast.Stmt) ast.Stmt {
+	if export, ok := stmt.(ast.ExportStmt); ok {
+		return export.Declaration
+	}
+	return stmt
+}
+
 func (c *Compiler) executeStmt(stmt ast.Stmt) (interface{}, error) {
+	if err := c.limiter.step(); err != nil {
+		return nil, err
+	}
+
 	switch s := stmt.(type) {
 	case ast.ExprStmt:
 		return c.executeExpr(s.Expression)
@@ -63,6 +314,21 @@ func (c *Compiler) executeStmt(stmt ast.Stmt) (interface{}, error) {
 		return c.executeIf(s)
 	case ast.WhileStmt:
 		return c.executeWhile(s)
+	case ast.ForeachStmt:
+		return c.executeForeach(s)
+	case ast.MatchStmt:
+		return c.executeMatch(s.Subject, s.Arms)
+	case ast.BlockStmt:
+		return c.executeBlock(s)
+	case ast.TypeDeclStmt:
+		c.env.types[s.Name] = s.Type
+		return nil, nil
+	case ast.ReturnStmt:
+		return c.executeReturn(s)
+	case ast.ThrowStmt:
+		return c.executeThrow(s)
+	case ast.TryStmt:
+		return c.executeTry(s)
 	case ast.PrintStmt:
 		return c.executePrint(s)
 	case ast.ReadStmt:
@@ -74,91 +340,295 @@ func (c *Compiler) executeStmt(stmt ast.Stmt) (interface{}, error) {
 
 func (c *Compiler) executeVarDecl(stmt ast.VarDeclStmt) (interface{}, error) {
 	varType := ValueTypeFloat
+	var defaultValue interface{} = float64(0)
 	if stmt.ExplicitType != nil {
-		if typeSymbol, ok := stmt.ExplicitType.(ast.SymbolType); ok {
-			switch typeSymbol.Name {
-			case "string":
-				varType = ValueTypeString
-			case "int":
-				varType = ValueTypeInt
-			case "float":
-				varType = ValueTypeFloat
-			case "bool":
-				varType = ValueTypeBool
-			default:
-				return nil, fmt.Errorf("unknown type: %s", typeSymbol.Name)
-			}
+		var err error
+		varType, defaultValue, err = c.zeroValue(stmt.ExplicitType)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	var defaultValue interface{}
-	switch varType {
-	case ValueTypeInt:
-		defaultValue = int64(0)
-	case ValueTypeFloat:
-		defaultValue = float64(0)
-	case ValueTypeString:
-		defaultValue = ""
-	case ValueTypeBool:
-		defaultValue = false
-	}
-
 	if stmt.AssignedValue != nil {
 		val, err := c.executeExpr(stmt.AssignedValue)
 		if err != nil {
 			return nil, err
 		}
-		defaultValue = val
+		defaultValue = copyValue(val)
+		if stmt.ExplicitType == nil {
+			varType = inferValueType(val)
+		} else {
+			defaultValue = coerce(c.env, stmt.ExplicitType, defaultValue)
+		}
 	}
 
-	c.env.variables[stmt.VariableName] = Value{
+	c.env.define(stmt.VariableName, Value{
 		Type:  varType,
 		Value: defaultValue,
-	}
+	})
 
 	return nil, nil
 }
 
+// zeroValue returns the runtime type and initial value of a variable
+// declared with type t but without an initializer.
+func (c *Compiler) zeroValue(t ast.Type) (ValueType, interface{}, error) {
+	switch t := t.(type) {
+	case ast.MapType:
+		return ValueTypeMap, NewMapValue(), nil
+	case ast.ArrayType:
+		return ValueTypeArray, NewArrayValue([]interface{}{}), nil
+	case ast.FunctionType:
+		return ValueTypeFunction, nil, nil
+	case ast.OptionalType:
+		valueType, err := c.valueTypeOf(t.Underlying)
+		return valueType, nil, err
+	case ast.UnionType:
+		return c.zeroValue(t.Types[0])
+	case ast.GenericType, ast.InterfaceType:
+		return ValueTypeObject, nil, nil
+	case ast.TypeParam:
+		// Type arguments are erased, so a T has no zero value to start from
+		return ValueTypeNull, nil, nil
+	case ast.StructType:
+		value, err := c.zeroStruct("struct", t)
+		return ValueTypeStruct, value, err
+	case ast.SymbolType:
+		switch t.Name {
+		case "string":
+			return ValueTypeString, "", nil
+		case "int":
+			return ValueTypeInt, int64(0), nil
+		case "float":
+			return ValueTypeFloat, float64(0), nil
+		case "bool":
+			return ValueTypeBool, false, nil
+		case "Time":
+			return ValueTypeTime, time.Time{}, nil
+		case "Duration":
+			return ValueTypeDuration, time.Duration(0), nil
+		case "range":
+			return ValueTypeRange, &RangeValue{Step: 1}, nil
+		}
+
+		declared, exists := c.env.getType(t.Name)
+		if !exists {
+			// Instances have no zero value, like functions they start out nil
+			if c.isClass(t.Name) {
+				return ValueTypeObject, nil, nil
+			}
+			if c.isEnum(t.Name) {
+				return ValueTypeEnum, nil, nil
+			}
+			return 0, nil, fmt.Errorf("unknown type: %s", t.Name)
+		}
+		if structType, ok := declared.(ast.StructType); ok {
+			value, err := c.zeroStruct(t.Name, structType)
+			return ValueTypeStruct, value, err
+		}
+		return c.zeroValue(declared)
+	default:
+		return ValueTypeFloat, float64(0), nil
+	}
+}
+
+func (c *Compiler) zeroStruct(name string, t ast.StructType) (*StructValue, error) {
+	fields := make([]string, 0, len(t.Fields))
+	for _, field := range t.Fields {
+		fields = append(fields, field.Name)
+	}
+
+	if err := c.limiter.alloc(int64(objectSize + valueSize*len(fields))); err != nil {
+		return nil, err
+	}
+	s := NewStructValue(name, fields)
+	for _, field := range t.Fields {
+		_, value, err := c.zeroValue(field.Type)
+		if err != nil {
+			return nil, err
+		}
+		s.values[field.Name] = value
+	}
+	return s, nil
+}
+
+// typeOf is the type the checker found for expr, nil when it has none.
+func (c *Compiler) typeOf(expr ast.Expr) checker.Type {
+	return c.types[expr.Span()]
+}
+
 func (c *Compiler) executeExpr(expr ast.Expr) (interface{}, error) {
+	value, err := c.evaluate(expr)
+	if err != nil {
+		return nil, c.runtimeError(expr, err)
+	}
+	return value, nil
+}
+
+func (c *Compiler) evaluate(expr ast.Expr) (interface{}, error) {
 	switch e := expr.(type) {
 	case ast.NumberExpr:
-		return e.Value, nil
+		return numberValue(e), nil
+	case ast.NullExpr:
+		return nil, nil
 	case ast.StringExpr:
 		return e.Value, nil
+	case ast.MapLiteralExpr:
+		return c.executeMapLiteralExpr(e)
+	case ast.ArrayLiteralExpr:
+		return c.executeArrayLiteralExpr(e)
+	case ast.StructLiteralExpr:
+		return c.executeStructLiteralExpr(e)
 	case ast.SymbolExpr:
-		if value, exists := c.env.variables[e.Value]; exists {
+		if value, exists := c.env.get(e.Value); exists {
 			return value.Value, nil
 		}
 		return nil, fmt.Errorf("undefined variable: %s", e.Value)
+	case ast.PrefixExpr:
+		return c.executePrefixExpr(e)
 	case ast.BinaryExpr:
 		return c.executeBinaryExpr(e)
 	case ast.AssignmentExpr:
 		return c.executeAssignment(e)
+	case ast.TernaryExpr:
+		return c.executeTernaryExpr(e)
+	case ast.NewExpr:
+		return c.executeNewExpr(e)
+	case ast.IndexExpr:
+		return c.executeIndexExpr(e)
+	case ast.RangeExpr:
+		return c.executeRangeExpr(e)
+	case ast.MemberExpr:
+		return c.executeMemberExpr(e)
+	case ast.CallExpr:
+		return c.executeCallExpr(e)
+	case ast.FunctionExpr:
+		return c.executeFunctionExpr(e), nil
+	case ast.MatchExpr:
+		value, err := c.executeMatch(e.Subject, e.Arms)
+		if err != nil {
+			return nil, err
+		}
+		return convert(c.typeOf(e), value), nil
 	default:
 		return nil, fmt.Errorf("unknown expression type: %T", expr)
 	}
 }
 
+func (c *Compiler) executePrefixExpr(expr ast.PrefixExpr) (interface{}, error) {
+	right, err := c.executeExpr(expr.RightExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	switch expr.Operator.Kind {
+	case lexer.DASH:
+		switch v := right.(type) {
+		case int64:
+			return -v, nil
+		case float64:
+			return -v, nil
+		}
+		return nil, fmt.Errorf("cannot negate %s", typeName(right))
+	case lexer.PLUS:
+		switch right.(type) {
+		case int64, float64:
+			return right, nil
+		}
+		return nil, fmt.Errorf("cannot apply unary + to %s", typeName(right))
+	case lexer.NOT:
+		if b, ok := right.(bool); ok {
+			return !b, nil
+		}
+		return nil, fmt.Errorf("cannot apply ! to %s", typeName(right))
+	case lexer.TYPEOF:
+		return typeName(right), nil
+	default:
+		return nil, fmt.Errorf("unknown prefix operator: %v", expr.Operator)
+	}
+}
+
 func (c *Compiler) executeBinaryExpr(expr ast.BinaryExpr) (interface{}, error) {
 	left, err := c.executeExpr(expr.Left)
 	if err != nil {
 		return nil, err
 	}
 
+	// &&, || and ?? only evaluate the right operand when needed
+	switch expr.Operator.Kind {
+	case lexer.AND:
+		if !isTruthy(left) {
+			return false, nil
+		}
+		right, err := c.executeExpr(expr.Right)
+		return err == nil && isTruthy(right), err
+	case lexer.OR:
+		if isTruthy(left) {
+			return true, nil
+		}
+		right, err := c.executeExpr(expr.Right)
+		return err == nil && isTruthy(right), err
+	case lexer.NULLISH:
+		if left != nil {
+			return left, nil
+		}
+		return c.executeExpr(expr.Right)
+	}
+
 	right, err := c.executeExpr(expr.Right)
 	if err != nil {
 		return nil, err
 	}
 
+	value, err := c.applyOperator(expr.Operator, left, right)
+	if err != nil {
+		return nil, c.runtimeError(expr, err, Operand{"left", left}, Operand{"right", right})
+	}
+	return value, nil
+}
+
+func (c *Compiler) applyOperator(operator lexer.Token, left, right interface{}) (interface{}, error) {
+	switch operator.Kind {
+	case lexer.EQUALS:
+		return c.valuesEqual(left, right), nil
+	case lexer.NOT_EQUALS:
+		return !c.valuesEqual(left, right), nil
+	case lexer.IN:
+		return c.contains(right, left)
+	}
+
+	if isTimeValue(left) || isTimeValue(right) {
+		return applyTimeOperator(operator, left, right)
+	}
+
 	if lstr, lok := left.(string); lok {
 		if rstr, rok := right.(string); rok {
-			if expr.Operator.Kind == lexer.PLUS {
+			if operator.Kind == lexer.PLUS {
+				if err := c.limiter.alloc(int64(len(lstr) + len(rstr))); err != nil {
+					return nil, err
+				}
 				return lstr + rstr, nil
 			}
+			switch operator.Kind {
+			case lexer.LESS:
+				return lstr < rstr, nil
+			case lexer.LESS_EQUALS:
+				return lstr <= rstr, nil
+			case lexer.GREATER:
+				return lstr > rstr, nil
+			case lexer.GREATER_EQUALS:
+				return lstr >= rstr, nil
+			}
 			return nil, fmt.Errorf("invalid operation for strings")
 		}
 	}
 
+	if l, lok := left.(int64); lok {
+		if r, rok := right.(int64); rok {
+			return applyIntOperator(operator, l, r)
+		}
+	}
+
 	leftNum, err := c.toNumber(left)
 	if err != nil {
 		return nil, err
@@ -169,7 +639,7 @@ func (c *Compiler) executeBinaryExpr(expr ast.BinaryExpr) (interface{}, error) {
 		return nil, err
 	}
 
-	switch expr.Operator.Kind {
+	switch operator.Kind {
 	case lexer.PLUS:
 		return leftNum + rightNum, nil
 	case lexer.DASH:
@@ -181,6 +651,11 @@ func (c *Compiler) executeBinaryExpr(expr ast.BinaryExpr) (interface{}, error) {
 			return nil, fmt.Errorf("division by zero")
 		}
 		return leftNum / rightNum, nil
+	case lexer.PERCENT:
+		if rightNum == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(leftNum, rightNum), nil
 	case lexer.LESS:
 		return leftNum < rightNum, nil
 	case lexer.LESS_EQUALS:
@@ -189,19 +664,70 @@ func (c *Compiler) executeBinaryExpr(expr ast.BinaryExpr) (interface{}, error) {
 		return leftNum > rightNum, nil
 	case lexer.GREATER_EQUALS:
 		return leftNum >= rightNum, nil
-	case lexer.EQUALS:
-		return leftNum == rightNum, nil
-	case lexer.NOT_EQUALS:
-		return leftNum != rightNum, nil
 	default:
-		return nil, fmt.Errorf("unknown operator: %v", expr.Operator)
+		return nil, fmt.Errorf("unknown operator: %s", operator.Value)
 	}
 }
 
+// applyIntOperator keeps arithmetic between ints in int64, so division
+// truncates as it does for the int type in the checker.
+func applyIntOperator(operator lexer.Token, left, right int64) (interface{}, error) {
+	switch operator.Kind {
+	case lexer.PLUS:
+		return left + right, nil
+	case lexer.DASH:
+		return left - right, nil
+	case lexer.STAR:
+		return left * right, nil
+	case lexer.SLASH:
+		if right == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return left / right, nil
+	case lexer.PERCENT:
+		if right == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return left % right, nil
+	case lexer.LESS:
+		return left < right, nil
+	case lexer.LESS_EQUALS:
+		return left <= right, nil
+	case lexer.GREATER:
+		return left > right, nil
+	case lexer.GREATER_EQUALS:
+		return left >= right, nil
+	default:
+		return nil, fmt.Errorf("unknown operator: %s", operator.Value)
+	}
+}
+
+func (c *Compiler) executeTernaryExpr(expr ast.TernaryExpr) (interface{}, error) {
+	condition, err := c.executeExpr(expr.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	branch := expr.Alternate
+	if isTruthy(condition) {
+		branch = expr.Consequent
+	}
+	value, err := c.executeExpr(branch)
+	if err != nil {
+		return nil, err
+	}
+	// Both branches give a value of the type they have in common
+	return convert(c.typeOf(expr), value), nil
+}
+
 func (c *Compiler) executeAssignment(expr ast.AssignmentExpr) (interface{}, error) {
-	target, ok := expr.Assigne.(ast.SymbolExpr)
-	if !ok {
-		return nil, fmt.Errorf("invalid assignment target")
+	// x ??= value only evaluates value when x is null
+	if expr.Operator.Kind == lexer.NULLISH_ASSIGNMENT {
+		current, err := c.executeExpr(expr.Assigne)
+		if err != nil || current != nil {
+			return current, err
+		}
+		expr.Operator = lexer.NewToken(lexer.ASSIGNMENT, "=")
 	}
 
 	value, err := c.executeExpr(expr.Value)
@@ -209,16 +735,287 @@ func (c *Compiler) executeAssignment(expr ast.AssignmentExpr) (interface{}, erro
 		return nil, err
 	}
 
-	varInfo, exists := c.env.variables[target.Value]
+	switch target := expr.Assigne.(type) {
+	case ast.SymbolExpr:
+		varInfo, exists := c.env.get(target.Value)
+		if !exists {
+			return nil, fmt.Errorf("undefined variable: %s", target.Value)
+		}
+
+		value, err = c.compoundValue(expr, varInfo.Value, value)
+		if err != nil {
+			return nil, err
+		}
+
+		value = copyValue(value)
+		c.env.assign(target.Value, value)
+		return value, nil
+	case ast.IndexExpr:
+		container, err := c.executeExpr(target.Target)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := c.executeExpr(target.Index)
+		if err != nil {
+			return nil, err
+		}
+
+		if array, ok := container.(*ArrayValue); ok {
+			current, err := array.Get(key)
+			if err != nil {
+				return nil, err
+			}
+			value, err = c.compoundValue(expr, current, value)
+			if err != nil {
+				return nil, err
+			}
+			value = copyValue(value)
+			return value, array.Set(key, value)
+		}
+
+		m, ok := container.(*MapValue)
+		if !ok {
+			return nil, fmt.Errorf("cannot assign to index of %s", typeName(container))
+		}
+		if err := checkMapKey(key); err != nil {
+			return nil, err
+		}
+
+		current, exists := m.Get(key)
+		if !exists {
+			if expr.Operator.Kind != lexer.ASSIGNMENT {
+				return nil, fmt.Errorf("key %s not found in map", formatValue(key))
+			}
+			if err := c.limiter.alloc(entrySize); err != nil {
+				return nil, err
+			}
+		}
+		value, err = c.compoundValue(expr, current, value)
+		if err != nil {
+			return nil, err
+		}
+
+		value = copyValue(value)
+		m.Set(key, value)
+		return value, nil
+	case ast.MemberExpr:
+		object, err := c.executeExpr(target.Object)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := object.(fieldHolder)
+		if !ok {
+			return nil, fmt.Errorf("cannot assign to field of %s", typeName(object))
+		}
+
+		current, exists := s.Get(target.Property)
+		if !exists {
+			return nil, fmt.Errorf("%s has no field %s", typeName(object), target.Property)
+		}
+		value, err = c.compoundValue(expr, current, value)
+		if err != nil {
+			return nil, err
+		}
+
+		value = copyValue(value)
+		if err := s.Set(target.Property, value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	default:
+		return nil, fmt.Errorf("invalid assignment target")
+	}
+}
+
+// compoundValue computes the value stored by a = b, a += b or a -= b, of
+// the type of the assignment's target.
+func (c *Compiler) compoundValue(expr ast.AssignmentExpr, current, value interface{}) (interface{}, error) {
+	var err error
+	switch expr.Operator.Kind {
+	case lexer.PLUS_EQUALS:
+		value, err = c.applyOperator(lexer.NewToken(lexer.PLUS, "+"), current, value)
+	case lexer.MINUS_EQUALS:
+		value, err = c.applyOperator(lexer.NewToken(lexer.DASH, "-"), current, value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return convert(c.typeOf(expr), value), nil
+}
+
+func (c *Compiler) executeMapLiteralExpr(expr ast.MapLiteralExpr) (interface{}, error) {
+	if err := c.limiter.alloc(int64(entrySize * len(expr.Entries))); err != nil {
+		return nil, err
+	}
+	m := NewMapValue()
+	t, _ := c.typeOf(expr).(checker.MapType)
+
+	for _, entry := range expr.Entries {
+		key, err := c.executeExpr(entry.Key)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkMapKey(key); err != nil {
+			return nil, err
+		}
+
+		value, err := c.executeExpr(entry.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		m.Set(key, copyValue(convert(t.Value, value)))
+	}
+
+	return m, nil
+}
+
+func (c *Compiler) executeArrayLiteralExpr(expr ast.ArrayLiteralExpr) (interface{}, error) {
+	if err := c.limiter.alloc(int64(valueSize * len(expr.Elements))); err != nil {
+		return nil, err
+	}
+	elements := make([]interface{}, 0, len(expr.Elements))
+	t, _ := c.typeOf(expr).(checker.ArrayType)
+
+	for _, element := range expr.Elements {
+		value, err := c.executeExpr(element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, copyValue(convert(t.Elem, value)))
+	}
+
+	return NewArrayValue(elements), nil
+}
+
+func (c *Compiler) executeStructLiteralExpr(expr ast.StructLiteralExpr) (interface{}, error) {
+	declared, exists := c.env.getType(expr.TypeName)
 	if !exists {
-		return nil, fmt.Errorf("undefined variable: %s", target.Value)
+		return nil, fmt.Errorf("unknown type: %s", expr.TypeName)
+	}
+	structType, ok := declared.(ast.StructType)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct type", expr.TypeName)
+	}
+
+	s, err := c.zeroStruct(expr.TypeName, structType)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range expr.Fields {
+		value, err := c.executeExpr(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		if fieldType, exists := structField(structType, field.Name); exists {
+			value = coerce(c.env, fieldType, value)
+		}
+		if err := s.Set(field.Name, copyValue(value)); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func structField(t ast.StructType, name string) (ast.Type, bool) {
+	for _, field := range t.Fields {
+		if field.Name == name {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+func (c *Compiler) executeMemberExpr(expr ast.MemberExpr) (interface{}, error) {
+	object, err := c.executeExpr(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+	if object == nil && expr.Optional {
+		return nil, nil
+	}
+
+	switch o := object.(type) {
+	case *StructValue:
+		if value, exists := o.Get(expr.Property); exists {
+			return value, nil
+		}
+		return nil, fmt.Errorf("%s has no field %s", o.TypeName, expr.Property)
+	case *InstanceValue:
+		if value, exists := o.Get(expr.Property); exists {
+			return value, nil
+		}
+		if method, exists := o.Class.method(expr.Property); exists {
+			return &FunctionValue{Decl: method, env: o.Class.env, path: o.Class.path, types: o.Class.types, this: o}, nil
+		}
+		return nil, fmt.Errorf("%s has no member %s", o.Class.Decl.Name, expr.Property)
+	case *NamespaceValue:
+		if member, exists := o.members[expr.Property]; exists {
+			return member, nil
+		}
+		return nil, fmt.Errorf("%s has no member %s", o.Name, expr.Property)
+	case *EnumValue:
+		if variant, exists := o.variants[expr.Property]; exists {
+			return variant, nil
+		}
+		return nil, fmt.Errorf("%s has no variant %s", o.Decl.Name, expr.Property)
+	case *ArrayValue:
+		if method, exists := c.arrayMethod(o, expr.Property); exists {
+			return method, nil
+		}
+		return nil, fmt.Errorf("array has no member %s", expr.Property)
+	default:
+		return nil, fmt.Errorf("cannot access field %s of %s", expr.Property, typeName(object))
+	}
+}
+
+func (c *Compiler) executeIndexExpr(expr ast.IndexExpr) (interface{}, error) {
+	target, err := c.executeExpr(expr.Target)
+	if err != nil {
+		return nil, err
 	}
 
-	varInfo.Value = value
-	c.env.variables[target.Value] = varInfo
+	index, err := c.executeExpr(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := c.indexValue(target, index)
+	if err != nil {
+		return nil, c.runtimeError(expr, err, Operand{"target", target}, Operand{"index", index})
+	}
 	return value, nil
 }
 
+func (c *Compiler) indexValue(target, index interface{}) (interface{}, error) {
+	if r, ok := index.(*RangeValue); ok {
+		switch t := target.(type) {
+		case *ArrayValue:
+			return c.sliceArray(t, r)
+		case string:
+			return c.sliceString(t, r)
+		default:
+			return nil, fmt.Errorf("cannot slice %s", typeName(target))
+		}
+	}
+
+	switch t := target.(type) {
+	case *ArrayValue:
+		return t.Get(index)
+	case *MapValue:
+		value, exists := t.Get(index)
+		if !exists {
+			return nil, fmt.Errorf("key %s not found in map", formatValue(index))
+		}
+		return value, nil
+	default:
+		return nil, fmt.Errorf("cannot index %s", typeName(target))
+	}
+}
+
 func (c *Compiler) executeIf(stmt ast.IfStmt) (interface{}, error) {
 	condition, err := c.executeExpr(stmt.Condition)
 	if err != nil {
@@ -238,6 +1035,10 @@ func (c *Compiler) executeWhile(stmt ast.WhileStmt) (interface{}, error) {
 	var lastValue interface{}
 
 	for {
+		if err := c.limiter.iterate(); err != nil {
+			return nil, err
+		}
+
 		condition, err := c.executeExpr(stmt.Condition)
 		if err != nil {
 			return nil, err
@@ -256,36 +1057,193 @@ func (c *Compiler) executeWhile(stmt ast.WhileStmt) (interface{}, error) {
 	return lastValue, nil
 }
 
+func (c *Compiler) executeForeach(stmt ast.ForeachStmt) (interface{}, error) {
+	iterable, err := c.executeExpr(stmt.Iterable)
+	if err != nil {
+		return nil, err
+	}
+
+	if array, ok := iterable.(*ArrayValue); ok {
+		return c.executeForeachArray(stmt, array)
+	}
+	if r, ok := iterable.(*RangeValue); ok {
+		return c.executeForeachRange(stmt, r)
+	}
+
+	m, ok := iterable.(*MapValue)
+	if !ok {
+		return nil, fmt.Errorf("cannot iterate over %s", typeName(iterable))
+	}
+
+	var lastValue interface{}
+	for _, key := range m.Keys() {
+		if err := c.limiter.iterate(); err != nil {
+			return nil, err
+		}
+
+		value, exists := m.Get(key)
+		if !exists {
+			continue // deleted by an earlier iteration
+		}
+
+		// Loop variables live in their own scope around the body
+		loopEnv := NewEnvironment(c.env)
+		loopEnv.define(stmt.KeyName, Value{Type: inferValueType(key), Value: key})
+		if stmt.ValueName != "" {
+			loopEnv.define(stmt.ValueName, Value{Type: inferValueType(value), Value: copyValue(value)})
+		}
+
+		lastValue, err = c.executeBlockIn(stmt.Body, loopEnv)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return lastValue, nil
+}
+
+// executeForeachArray binds the element to the single loop variable, or
+// the index and the element when two are given. Like the keys of a map,
+// the elements visited are those the array had when the loop started.
+func (c *Compiler) executeForeachArray(stmt ast.ForeachStmt, array *ArrayValue) (interface{}, error) {
+	var lastValue interface{}
+	var err error
+
+	length := array.Len()
+	for i := 0; i < length; i++ {
+		if err := c.limiter.iterate(); err != nil {
+			return nil, err
+		}
+		element := array.elements[i]
+
+		loopEnv := NewEnvironment(c.env)
+		if stmt.ValueName == "" {
+			loopEnv.define(stmt.KeyName, Value{Type: inferValueType(element), Value: copyValue(element)})
+		} else {
+			loopEnv.define(stmt.KeyName, Value{Type: ValueTypeInt, Value: int64(i)})
+			loopEnv.define(stmt.ValueName, Value{Type: inferValueType(element), Value: copyValue(element)})
+		}
+
+		lastValue, err = c.executeBlockIn(stmt.Body, loopEnv)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return lastValue, nil
+}
+
+// executeForeachRange binds loop variables the same way arrays do.
+func (c *Compiler) executeForeachRange(stmt ast.ForeachStmt, r *RangeValue) (interface{}, error) {
+	var lastValue interface{}
+	var err error
+
+	for i := 0; i < r.Len(); i++ {
+		if err := c.limiter.iterate(); err != nil {
+			return nil, err
+		}
+
+		loopEnv := NewEnvironment(c.env)
+		if stmt.ValueName == "" {
+			loopEnv.define(stmt.KeyName, Value{Type: ValueTypeInt, Value: r.At(i)})
+		} else {
+			loopEnv.define(stmt.KeyName, Value{Type: ValueTypeInt, Value: int64(i)})
+			loopEnv.define(stmt.ValueName, Value{Type: ValueTypeInt, Value: r.At(i)})
+		}
+
+		lastValue, err = c.executeBlockIn(stmt.Body, loopEnv)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return lastValue, nil
+}
+
 func (c *Compiler) executePrint(stmt ast.PrintStmt) (interface{}, error) {
 	value, err := c.executeExpr(stmt.Expression)
 	if err != nil {
 		return nil, err
 	}
-	fmt.Println(value)
+	fmt.Fprintln(c.stdout, displayValue(value))
 	return nil, nil
 }
 
 func (c *Compiler) executeRead(stmt ast.ReadStmt) (interface{}, error) {
+	if !c.capabilities.Stdin {
+		return nil, &PermissionError{Op: "read", Reason: "stdin access is disabled"}
+	}
+
+	if prompt, ok := stmt.Target.(ast.StringExpr); ok {
+		fmt.Fprint(c.stdout, prompt.Value)
+		_, err := readLine(c.stdin)
+		return nil, err
+	}
+
+	// Like the compiled backends, read takes the first word of a line
+	line, err := readLine(c.stdin)
+	if err != nil {
+		return nil, err
+	}
 	var input string
-	fmt.Scanln(&input)
+	if words := strings.Fields(line); len(words) > 0 {
+		input = words[0]
+	}
 
 	target, ok := stmt.Target.(ast.SymbolExpr)
 	if !ok {
 		return nil, fmt.Errorf("invalid read target")
 	}
 
-	varInfo, exists := c.env.variables[target.Value]
+	varInfo, exists := c.env.get(target.Value)
 	if !exists {
 		return nil, fmt.Errorf("undefined variable: %s", target.Value)
 	}
 
-	return c.convertInput(input, varInfo.Type)
+	value, err := c.convertInput(input, varInfo.Type)
+	if err != nil {
+		return nil, c.runtimeError(target, err)
+	}
+
+	c.env.assign(target.Value, value)
+	return nil, nil
+}
+
+// readLine returns the input up to the end of the line, reading a byte
+// at a time so that nothing after it is consumed.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	var b [1]byte
+	for {
+		n, err := r.Read(b[:])
+		if n == 1 {
+			if b[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, b[0])
+		}
+		if err == io.EOF {
+			return string(line), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
 }
 
 func (c *Compiler) executeBlock(block ast.BlockStmt) (interface{}, error) {
+	return c.executeBlockIn(block, NewEnvironment(c.env))
+}
+
+// executeBlockIn runs block with env as the current scope.
+func (c *Compiler) executeBlockIn(block ast.BlockStmt, env *Environment) (interface{}, error) {
 	var result interface{}
 	var err error
 
+	previous := c.env
+	c.env = env
+	defer func() { c.env = previous }()
+
 	for _, stmt := range block.Body {
 		result, err = c.executeStmt(stmt)
 		if err != nil {
@@ -297,6 +1255,10 @@ func (c *Compiler) executeBlock(block ast.BlockStmt) (interface{}, error) {
 }
 
 func (c *Compiler) toNumber(v interface{}) (float64, error) {
+	return toFloat(v)
+}
+
+func toFloat(v interface{}) (float64, error) {
 	switch val := v.(type) {
 	case int64:
 		return float64(val), nil
@@ -333,6 +1295,88 @@ func (c *Compiler) convertInput(input string, targetType ValueType) (interface{}
 	return value, nil
 }
 
+func typeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case *MapValue:
+		return "map"
+	case *StructValue:
+		return v.TypeName
+	case *InstanceValue:
+		return v.Class.Decl.Name
+	case *FunctionValue:
+		return "function"
+	case *ClassValue:
+		return "class"
+	case *EnumValue:
+		return "enum"
+	case *VariantValue:
+		return v.Enum.Decl.Name
+	case *NativeFunction:
+		return "function"
+	case *ArrayValue:
+		return "array"
+	case *NamespaceValue:
+		return "namespace"
+	case *RangeValue:
+		return "range"
+	case time.Time:
+		return "Time"
+	case time.Duration:
+		return "Duration"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func inferValueType(value interface{}) ValueType {
+	switch value.(type) {
+	case nil:
+		return ValueTypeNull
+	case int64:
+		return ValueTypeInt
+	case string:
+		return ValueTypeString
+	case bool:
+		return ValueTypeBool
+	case *MapValue:
+		return ValueTypeMap
+	case *StructValue:
+		return ValueTypeStruct
+	case *FunctionValue:
+		return ValueTypeFunction
+	case *ClassValue:
+		return ValueTypeClass
+	case *InstanceValue:
+		return ValueTypeObject
+	case *EnumValue, *VariantValue:
+		return ValueTypeEnum
+	case *NativeFunction:
+		return ValueTypeFunction
+	case *ArrayValue:
+		return ValueTypeArray
+	case *NamespaceValue:
+		return ValueTypeNamespace
+	case *RangeValue:
+		return ValueTypeRange
+	case time.Time:
+		return ValueTypeTime
+	case time.Duration:
+		return ValueTypeDuration
+	default:
+		return ValueTypeFloat
+	}
+}
+
 func isTruthy(value interface{}) bool {
 	switch v := value.(type) {
 	case bool:
@@ -0,0 +1,164 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+)
+
+// EnumValue is the value of an enum's name, holding its variants:
+// Color.Red is a VariantValue, Shape.Circle a function building one.
+type EnumValue struct {
+	Decl     ast.EnumDeclStmt
+	variants map[string]interface{}
+}
+
+func (e *EnumValue) String() string {
+	return "enum " + e.Decl.Name
+}
+
+// VariantValue is a value of an enum. Variants without payload are
+// shared, so they compare and key maps by identity.
+type VariantValue struct {
+	Enum    *EnumValue
+	Name    string
+	Payload []interface{}
+}
+
+func (v *VariantValue) String() string {
+	name := v.Enum.Decl.Name + "." + v.Name
+	if v.Payload == nil {
+		return name
+	}
+	values := make([]string, 0, len(v.Payload))
+	for _, value := range v.Payload {
+		values = append(values, formatValue(value))
+	}
+	return name + "(" + strings.Join(values, ", ") + ")"
+}
+
+func (v *VariantValue) equal(other *VariantValue, equal func(a, b interface{}) bool) bool {
+	if v.Enum != other.Enum || v.Name != other.Name || len(v.Payload) != len(other.Payload) {
+		return false
+	}
+	for i := range v.Payload {
+		if !equal(v.Payload[i], other.Payload[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// newEnumValue declares the variants of decl, whose payload types are
+// resolved in env.
+func (c *Compiler) newEnumValue(decl ast.EnumDeclStmt, env *Environment) *EnumValue {
+	enum := &EnumValue{Decl: decl, variants: make(map[string]interface{}, len(decl.Variants))}
+
+	for _, variant := range decl.Variants {
+		if variant.Payload == nil {
+			enum.variants[variant.Name] = &VariantValue{Enum: enum, Name: variant.Name}
+			continue
+		}
+
+		variant := variant
+		enum.variants[variant.Name] = &NativeFunction{
+			Name:   decl.Name + "." + variant.Name,
+			Params: variant.Payload,
+			Return: ast.SymbolType{Name: decl.Name},
+			Fn: func(args []interface{}) (interface{}, error) {
+				if err := c.limiter.alloc(int64(objectSize + valueSize*len(args))); err != nil {
+					return nil, err
+				}
+				payload := make([]interface{}, 0, len(args))
+				for i, arg := range args {
+					payload = append(payload, coerce(env, variant.Payload[i], copyValue(arg)))
+				}
+				return &VariantValue{Enum: enum, Name: variant.Name, Payload: payload}, nil
+			},
+		}
+	}
+	return enum
+}
+
+func (c *Compiler) isEnum(name string) bool {
+	value, exists := c.env.get(name)
+	if !exists {
+		return false
+	}
+	_, ok := value.Value.(*EnumValue)
+	return ok
+}
+
+// executeMatch runs the first arm with a pattern matching the subject. A
+// match statement runs block arms and returns nil.
+func (c *Compiler) executeMatch(subject ast.Expr, arms []ast.MatchArm) (interface{}, error) {
+	value, err := c.executeExpr(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arm := range arms {
+		for _, pattern := range arm.Patterns {
+			env := NewEnvironment(c.env)
+			matched, err := c.matchPattern(pattern, value, env)
+			if err != nil {
+				return nil, err
+			}
+			if !matched {
+				continue
+			}
+
+			if arm.Body != nil {
+				_, err := c.executeBlockIn(*arm.Body, env)
+				return nil, err
+			}
+			previous := c.env
+			c.env = env
+			defer func() { c.env = previous }()
+			return c.executeExpr(arm.Value)
+		}
+	}
+	return nil, c.runtimeError(subject, fmt.Errorf("no match arm for %s", formatValue(value)))
+}
+
+// matchPattern reports whether value matches pattern, defining the names
+// it binds in env.
+func (c *Compiler) matchPattern(pattern ast.Pattern, value interface{}, env *Environment) (bool, error) {
+	switch p := pattern.(type) {
+	case ast.WildcardPattern:
+		return true, nil
+	case ast.BindingPattern:
+		env.define(p.Name, Value{Type: inferValueType(value), Value: value})
+		return true, nil
+	case ast.LiteralPattern:
+		literal, err := c.executeExpr(p.Value)
+		if err != nil {
+			return false, err
+		}
+		if literal == nil || value == nil {
+			return literal == value, nil
+		}
+		return c.valuesEqual(literal, value), nil
+	case ast.RangePattern:
+		r, err := c.executeRangeExpr(ast.RangeExpr{Start: p.Start, End: p.End, Inclusive: p.Inclusive})
+		if err != nil {
+			return false, err
+		}
+		n, ok := value.(int64)
+		return ok && r.(*RangeValue).Contains(n), nil
+	case ast.VariantPattern:
+		variant, ok := value.(*VariantValue)
+		if !ok || variant.Enum.Decl.Name != p.Enum || variant.Name != p.Variant {
+			return false, nil
+		}
+		for i, name := range p.Bindings {
+			if name != "_" {
+				env.define(name, Value{Type: inferValueType(variant.Payload[i]), Value: variant.Payload[i]})
+			}
+		}
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown pattern: %T", pattern)
+	}
+}
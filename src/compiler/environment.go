@@ -0,0 +1,53 @@
+package compiler
+
+import "github.com/RyanOliveira00/go-compiler/src/ast"
+
+// Environment holds the variables and types of one scope. Lookups walk
+// outwards through enclosing scopes until the module's global scope.
+type Environment struct {
+	variables map[string]Value
+	types     map[string]ast.Type
+	outer     *Environment
+}
+
+func NewEnvironment(outer *Environment) *Environment {
+	return &Environment{
+		variables: make(map[string]Value),
+		types:     make(map[string]ast.Type),
+		outer:     outer,
+	}
+}
+
+func (e *Environment) get(name string) (Value, bool) {
+	for env := e; env != nil; env = env.outer {
+		if value, exists := env.variables[name]; exists {
+			return value, true
+		}
+	}
+	return Value{}, false
+}
+
+func (e *Environment) define(name string, value Value) {
+	e.variables[name] = value
+}
+
+// assign updates an existing variable in the scope that declared it.
+func (e *Environment) assign(name string, value interface{}) bool {
+	for env := e; env != nil; env = env.outer {
+		if info, exists := env.variables[name]; exists {
+			info.Value = value
+			env.variables[name] = info
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Environment) getType(name string) (ast.Type, bool) {
+	for env := e; env != nil; env = env.outer {
+		if t, exists := env.types[name]; exists {
+			return t, true
+		}
+	}
+	return nil, false
+}
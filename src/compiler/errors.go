@@ -0,0 +1,254 @@
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+// RuntimeError is an error raised while evaluating an expression. It
+// points at the innermost failing expression and records the calls that
+// led to it.
+type RuntimeError struct {
+	Err error
+	// Path is the file containing Span, empty for REPL input
+	Path string
+	Span lexer.Span
+	// Values are the operands that caused the error, when relevant
+	Values []Operand
+	// Stack lists the calls being executed, innermost first
+	Stack []Frame
+}
+
+// Operand is a named value involved in a RuntimeError.
+type Operand struct {
+	Name  string
+	Value interface{}
+}
+
+// Frame is one call on the stack of a RuntimeError: Function was called
+// from the expression at Span in the file at Path.
+type Frame struct {
+	Function string
+	Path     string
+	Span     lexer.Span
+}
+
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("%s: %s", location(e.Path, e.Span), e.Err)
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// Report renders the error with the failing source line and a caret under
+// the span, followed by the operands and the stack trace. source returns
+// the text of the file at a path, or false when it is unavailable.
+func (e *RuntimeError) Report(source func(path string) (string, bool)) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s\n", e.Error())
+
+	if text, ok := source(e.Path); ok {
+		sb.WriteString(caret(text, e.Span))
+	}
+
+	for _, operand := range e.Values {
+		fmt.Fprintf(&sb, "  %s = %s\n", operand.Name, formatValue(operand.Value))
+	}
+
+	// Deep recursion is shown by its innermost and outermost calls only
+	lines := e.StackTrace("")
+	if len(lines) > 2*reportedFrames {
+		omitted := fmt.Sprintf("... %d more calls", len(lines)-2*reportedFrames)
+		lines = append(append(lines[:reportedFrames:reportedFrames], omitted), lines[len(lines)-reportedFrames:]...)
+	}
+	for _, line := range lines {
+		fmt.Fprintf(&sb, "    %s\n", line)
+	}
+
+	return sb.String()
+}
+
+// reportedFrames is how many calls Report shows at each end of a long
+// stack trace.
+const reportedFrames = 10
+
+// StackTrace lists the location of the error followed by each call site,
+// innermost first. caller names the function containing the outermost
+// call site, empty for the top level.
+func (e *RuntimeError) StackTrace(caller string) []string {
+	if caller == "" {
+		caller = "<top level>"
+	}
+
+	// Each frame's call site lies inside the function of the next frame
+	function := caller
+	if len(e.Stack) > 0 {
+		function = e.Stack[0].Function
+	}
+	lines := []string{fmt.Sprintf("at %s (%s)", function, location(e.Path, e.Span))}
+
+	for i, frame := range e.Stack {
+		function := caller
+		if i+1 < len(e.Stack) {
+			function = e.Stack[i+1].Function
+		}
+		lines = append(lines, fmt.Sprintf("at %s (%s)", function, location(frame.Path, frame.Span)))
+	}
+	return lines
+}
+
+func location(path string, span lexer.Span) string {
+	if path == "" {
+		return fmt.Sprintf("%d:%d", span.Start.Line, span.Start.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", displayPath(path), span.Start.Line, span.Start.Column)
+}
+
+// caret returns the first source line of span with carets underneath.
+func caret(source string, span lexer.Span) string {
+	lines := strings.Split(source, "\n")
+	if span.Start.Line < 1 || span.Start.Line > len(lines) {
+		return ""
+	}
+	line := strings.TrimRight(lines[span.Start.Line-1], "\r")
+
+	width := 1
+	if span.End.Line == span.Start.Line && span.End.Column > span.Start.Column {
+		width = span.End.Column - span.Start.Column
+	}
+
+	prefix := fmt.Sprintf("%4d | ", span.Start.Line)
+	var padding strings.Builder
+	for i, ch := range []rune(line) {
+		if i >= span.Start.Column-1 {
+			break
+		}
+		// Keep tabs so the caret lines up with the text above
+		if ch == '\t' {
+			padding.WriteRune('\t')
+		} else {
+			padding.WriteRune(' ')
+		}
+	}
+
+	return fmt.Sprintf("%s%s\n%s%s%s\n", prefix, line, strings.Repeat(" ", len(prefix)), padding.String(), strings.Repeat("^", width))
+}
+
+// runtimeError attaches the location of expr to err, unless an inner
+// expression already did.
+func (c *Compiler) runtimeError(expr ast.Expr, err error, values ...Operand) error {
+	if _, ok := err.(*RuntimeError); ok {
+		return err
+	}
+	return &RuntimeError{Err: err, Path: c.path, Span: expr.Span(), Values: values}
+}
+
+// inFrame records that err escaped a call to function made at expr.
+func (c *Compiler) inFrame(err error, function string, expr ast.Expr) error {
+	if runtimeErr, ok := err.(*RuntimeError); ok {
+		runtimeErr.Stack = append(runtimeErr.Stack, Frame{Function: function, Path: c.path, Span: expr.Span()})
+	}
+	return err
+}
+
+var errorType = ast.StructType{
+	Fields: []ast.StructField{
+		{Name: "message", Type: typeString},
+		{Name: "stack", Type: ast.ArrayType{Underlying: typeString}},
+	},
+}
+
+// newError returns an Error struct, the value a catch block receives.
+func newError(message string, stack []string) *StructValue {
+	lines := make([]interface{}, 0, len(stack))
+	for _, line := range stack {
+		lines = append(lines, line)
+	}
+
+	s := NewStructValue("Error", []string{"message", "stack"})
+	s.values["message"] = message
+	s.values["stack"] = NewArrayValue(lines)
+	return s
+}
+
+// ThrownError is raised by a throw statement. Value is the thrown Error.
+type ThrownError struct {
+	Value *StructValue
+}
+
+func (e *ThrownError) Error() string {
+	message, _ := e.Value.Get("message")
+	return fmt.Sprintf("%v", message)
+}
+
+func (c *Compiler) executeThrow(stmt ast.ThrowStmt) (interface{}, error) {
+	value, err := c.executeExpr(stmt.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	var thrown *StructValue
+	switch v := value.(type) {
+	case string:
+		thrown = newError(v, nil)
+	case *StructValue:
+		thrown = copyValue(v).(*StructValue)
+	default:
+		return nil, c.runtimeError(stmt.Value, fmt.Errorf("cannot throw %s", typeName(value)))
+	}
+	return nil, c.runtimeError(stmt.Value, &ThrownError{Value: thrown})
+}
+
+func (c *Compiler) executeTry(stmt ast.TryStmt) (interface{}, error) {
+	result, err := c.executeBlock(stmt.Body)
+
+	if err != nil && stmt.Catch != nil && catchable(err) {
+		catchEnv := NewEnvironment(c.env)
+		catchEnv.define(stmt.CatchName, Value{Type: ValueTypeStruct, Value: c.caught(err)})
+		result, err = c.executeBlockIn(*stmt.Catch, catchEnv)
+	}
+
+	// finally runs even when the try or catch block returns or fails, an
+	// error inside it replaces theirs
+	if stmt.Finally != nil {
+		if _, finallyErr := c.executeBlock(*stmt.Finally); finallyErr != nil {
+			return nil, finallyErr
+		}
+	}
+
+	return result, err
+}
+
+// catchable reports whether a catch block may handle err. Returns are
+// control flow, and the execution limits must stay in force.
+func catchable(err error) bool {
+	if _, ok := err.(*returnSignal); ok {
+		return false
+	}
+
+	var canceled *CanceledError
+	var steps *StepLimitError
+	var depth *CallDepthError
+	var memory *MemoryLimitError
+	return !errors.As(err, &canceled) && !errors.As(err, &steps) && !errors.As(err, &depth) && !errors.As(err, &memory)
+}
+
+// caught converts err into the Error value bound by catch.
+func (c *Compiler) caught(err error) *StructValue {
+	runtimeErr, ok := err.(*RuntimeError)
+	if !ok {
+		return newError(err.Error(), nil)
+	}
+
+	stack := runtimeErr.StackTrace(c.function)
+	if thrown, ok := runtimeErr.Err.(*ThrownError); ok {
+		message, _ := thrown.Value.Get("message")
+		return newError(fmt.Sprintf("%v", message), stack)
+	}
+	return newError(runtimeErr.Err.Error(), stack)
+}
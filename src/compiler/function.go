@@ -0,0 +1,332 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/checker"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+// FunctionValue is a declared function or a method bound to an instance.
+// env is the scope the function was declared in, so it always runs in its
+// own module's namespace.
+type FunctionValue struct {
+	Decl ast.FunctionDeclStmt
+	env  *Environment
+	// path of the module declaring the function, and the types the
+	// checker found in it
+	path  string
+	types map[lexer.Span]checker.Type
+	this  *InstanceValue
+}
+
+func (f *FunctionValue) String() string {
+	return "fn " + f.Decl.Name
+}
+
+// name is the function's name as shown in stack traces.
+func (fn *FunctionValue) name() string {
+	if fn.this != nil {
+		return fn.this.Class.Decl.Name + "." + fn.Decl.Name
+	}
+	return fn.Decl.Name
+}
+
+type ClassValue struct {
+	Decl  ast.ClassDeclStmt
+	env   *Environment
+	path  string
+	types map[lexer.Span]checker.Type
+}
+
+func (c *ClassValue) String() string {
+	return "class " + c.Decl.Name
+}
+
+func (c *ClassValue) method(name string) (ast.FunctionDeclStmt, bool) {
+	for _, method := range c.Decl.Methods {
+		if method.Name == name {
+			return method, true
+		}
+	}
+	return ast.FunctionDeclStmt{}, false
+}
+
+// InstanceValue is an object created with new. Unlike structs, instances
+// are shared by reference.
+type InstanceValue struct {
+	Class  *ClassValue
+	values map[string]interface{}
+}
+
+func (i *InstanceValue) Get(field string) (interface{}, bool) {
+	value, exists := i.values[field]
+	return value, exists
+}
+
+func (i *InstanceValue) Set(field string, value interface{}) error {
+	if _, exists := i.values[field]; !exists {
+		return fmt.Errorf("%s has no field %s", i.Class.Decl.Name, field)
+	}
+	i.values[field] = value
+	return nil
+}
+
+func (i *InstanceValue) String() string {
+	var sb strings.Builder
+	sb.WriteString(i.Class.Decl.Name)
+	sb.WriteString(" { ")
+	for n, field := range i.Class.Decl.Fields {
+		if n > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(field.VariableName)
+		sb.WriteString(": ")
+		sb.WriteString(formatValue(i.values[field.VariableName]))
+	}
+	sb.WriteString(" }")
+	return sb.String()
+}
+
+// returnSignal unwinds the interpreter from a return statement up to the
+// function call that is executing it.
+type returnSignal struct {
+	value interface{}
+}
+
+func (r *returnSignal) Error() string {
+	return "return outside function"
+}
+
+func (c *Compiler) executeReturn(stmt ast.ReturnStmt) (interface{}, error) {
+	var value interface{}
+	if stmt.Value != nil {
+		var err error
+		value, err = c.executeExpr(stmt.Value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, &returnSignal{value: value}
+}
+
+func (c *Compiler) callFunction(fn *FunctionValue, args []interface{}) (interface{}, error) {
+	if len(args) != len(fn.Decl.Parameters) {
+		return nil, fmt.Errorf("%s expects %d arguments, got %d", fn.Decl.Name, len(fn.Decl.Parameters), len(args))
+	}
+
+	if err := c.limiter.enterCall(); err != nil {
+		return nil, err
+	}
+	defer c.limiter.exitCall()
+
+	env := NewEnvironment(typeParamEnv(fn.env, fn.Decl.TypeParams))
+	if fn.this != nil {
+		env.define("this", Value{Type: ValueTypeObject, Value: fn.this})
+	}
+	for i, param := range fn.Decl.Parameters {
+		arg := coerce(fn.env, param.Type, copyValue(args[i]))
+		env.define(param.Name, Value{Type: inferValueType(arg), Value: arg})
+	}
+
+	// Errors in the body point into the file declaring the function
+	previousPath, previousTypes, previousFunction := c.path, c.types, c.function
+	c.path, c.types, c.function = fn.path, fn.types, fn.name()
+	defer func() { c.path, c.types, c.function = previousPath, previousTypes, previousFunction }()
+
+	_, err := c.executeBlockIn(fn.Decl.Body, env)
+	if signal, ok := err.(*returnSignal); ok {
+		return coerce(fn.env, fn.Decl.ReturnType, signal.value), nil
+	}
+	return nil, err
+}
+
+func (c *Compiler) executeCallExpr(expr ast.CallExpr) (interface{}, error) {
+	args := make([]interface{}, 0, len(expr.Arguments))
+	for _, argument := range expr.Arguments {
+		arg, err := c.executeExpr(argument)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	if callee, ok := expr.Callee.(ast.SymbolExpr); ok {
+		if _, declared := c.env.get(callee.Value); !declared {
+			if builtin, exists := builtins[callee.Value]; exists {
+				return builtin(c, args)
+			}
+			return nil, fmt.Errorf("undefined function: %s", callee.Value)
+		}
+	}
+
+	callee, err := c.executeExpr(expr.Callee)
+	if err != nil {
+		return nil, err
+	}
+	if member, ok := expr.Callee.(ast.MemberExpr); ok && member.Optional && callee == nil {
+		return nil, nil
+	}
+	signature := c.typeOf(expr.Callee)
+	if opt, ok := signature.(checker.OptionalType); ok {
+		signature = opt.Elem
+	}
+	if signature, ok := signature.(checker.FunctionType); ok {
+		convertArgs(signature, args)
+	}
+
+	switch fn := callee.(type) {
+	case *FunctionValue:
+		value, err := c.callFunction(fn, args)
+		if err != nil {
+			return nil, c.inFrame(err, fn.name(), expr)
+		}
+		return value, nil
+	case *NativeFunction:
+		return c.callNative(fn, args)
+	default:
+		return nil, fmt.Errorf("cannot call %s", typeName(callee))
+	}
+}
+
+// convertArgs converts args to the parameters of the signature a call was
+// checked against. For generic functions it is the instantiation of the
+// call, so pair(1, 2.5) passes two floats.
+func convertArgs(signature checker.FunctionType, args []interface{}) {
+	params := signature.Params
+	for i := range args {
+		if len(params) == 0 {
+			return
+		}
+		args[i] = convert(params[min(i, len(params)-1)], args[i])
+	}
+}
+
+// typeParamEnv returns a scope inside outer where the type parameters of
+// a generic declaration are visible. Generics share one representation
+// for every type argument, so at run time T is only known as a parameter.
+func typeParamEnv(outer *Environment, params []ast.TypeParam) *Environment {
+	if len(params) == 0 {
+		return outer
+	}
+	env := NewEnvironment(outer)
+	for _, param := range params {
+		env.types[param.Name] = param
+	}
+	return env
+}
+
+func (c *Compiler) isClass(name string) bool {
+	value, exists := c.env.get(name)
+	if !exists {
+		return false
+	}
+	_, ok := value.Value.(*ClassValue)
+	return ok
+}
+
+func (c *Compiler) executeNewExpr(expr ast.NewExpr) (interface{}, error) {
+	value, exists := c.env.get(expr.ClassName)
+	if !exists {
+		return nil, fmt.Errorf("undefined class: %s", expr.ClassName)
+	}
+	class, ok := value.Value.(*ClassValue)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a class", expr.ClassName)
+	}
+
+	args := make([]interface{}, 0, len(expr.Arguments))
+	for _, argument := range expr.Arguments {
+		arg, err := c.executeExpr(argument)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	if t, ok := c.typeOf(expr).(*checker.ClassType); ok {
+		if constructor, exists := t.Method("constructor"); exists {
+			convertArgs(constructor, args)
+		}
+	}
+
+	if err := c.limiter.alloc(int64(objectSize + valueSize*len(class.Decl.Fields))); err != nil {
+		return nil, err
+	}
+	instance := &InstanceValue{
+		Class:  class,
+		values: make(map[string]interface{}),
+	}
+
+	// Field initializers run in the scope the class was declared in. With
+	// explicit type arguments, new Box<float>(1) converts like a float.
+	env := class.env
+	if len(expr.TypeArgs) == len(class.Decl.TypeParams) && len(expr.TypeArgs) > 0 {
+		env = NewEnvironment(class.env)
+		for i, param := range class.Decl.TypeParams {
+			env.types[param.Name] = expr.TypeArgs[i]
+		}
+	}
+	previous, previousPath, previousTypes := c.env, c.path, c.types
+	c.env, c.path, c.types = env, class.path, class.types
+	for _, field := range class.Decl.Fields {
+		var value interface{}
+		var err error
+		if field.ExplicitType != nil {
+			_, value, err = c.zeroValue(field.ExplicitType)
+		}
+		if err == nil && field.AssignedValue != nil {
+			value, err = c.executeExpr(field.AssignedValue)
+			if err == nil && field.ExplicitType != nil {
+				value = coerce(c.env, field.ExplicitType, value)
+			}
+		}
+		if err != nil {
+			c.env, c.path, c.types = previous, previousPath, previousTypes
+			return nil, err
+		}
+		instance.values[field.VariableName] = copyValue(value)
+	}
+	c.env, c.path, c.types = previous, previousPath, previousTypes
+
+	if constructor, exists := class.method("constructor"); exists {
+		bound := &FunctionValue{Decl: constructor, env: env, path: class.path, types: class.types, this: instance}
+		if _, err := c.callFunction(bound, args); err != nil {
+			return nil, c.inFrame(err, bound.name(), expr)
+		}
+	} else if len(args) > 0 {
+		return nil, fmt.Errorf("%s has no constructor", expr.ClassName)
+	}
+
+	return instance, nil
+}
+
+// executeFunctionExpr creates a closure over the current scope. Captured
+// variables are shared with the scope, not copied.
+func (c *Compiler) executeFunctionExpr(expr ast.FunctionExpr) *FunctionValue {
+	return &FunctionValue{
+		Decl: ast.FunctionDeclStmt{
+			Name:       "<anonymous>",
+			Parameters: expr.Parameters,
+			ReturnType: expr.ReturnType,
+			Body:       expr.Body,
+		},
+		env:   c.env,
+		path:  c.path,
+		types: c.types,
+	}
+}
+
+// callValue calls a function value received as an argument.
+func (c *Compiler) callValue(callee interface{}, args []interface{}) (interface{}, error) {
+	switch fn := callee.(type) {
+	case *FunctionValue:
+		return c.callFunction(fn, args)
+	case *NativeFunction:
+		return c.callNative(fn, args)
+	default:
+		return nil, fmt.Errorf("cannot call %s", typeName(callee))
+	}
+}
@@ -0,0 +1,290 @@
+package compiler
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+)
+
+// HostFunc is the Go implementation of a registered function. Arguments
+// arrive already converted to the declared parameter types.
+type HostFunc func(args []Value) (Value, error)
+
+// Signature declares the parameter and result types of a host function
+// with the same type expressions the parser produces, for example
+// ast.SymbolType{Name: "int"} or ast.ArrayType{Underlying: ...}. A nil
+// Return declares a function without a result. When Variadic is set the
+// last parameter type is repeated for any remaining arguments.
+type Signature struct {
+	Params   []ast.Type
+	Return   ast.Type
+	Variadic bool
+}
+
+// check rejects signatures the arguments of a call cannot be matched to.
+func (s Signature) check() error {
+	if s.Variadic && len(s.Params) == 0 {
+		return fmt.Errorf("a variadic signature needs a parameter type to repeat")
+	}
+	return nil
+}
+
+// HostFunction is a member of a namespace registered with RegisterNamespace.
+type HostFunction struct {
+	Name      string
+	Signature Signature
+	Fn        HostFunc
+}
+
+// RegisterFunc exposes fn to scripts as a global function. The checker
+// sees signature, so calls with the wrong arguments are rejected before
+// the program runs.
+func (c *Compiler) RegisterFunc(name string, signature Signature, fn HostFunc) error {
+	if err := c.checkHostName(name); err != nil {
+		return err
+	}
+	if err := signature.check(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	native := c.hostFunction(name, signature, fn)
+	t, err := c.nativeSignature(native)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	c.env.define(name, Value{Type: ValueTypeFunction, Value: native})
+	c.checker.Declare(name, t)
+	return nil
+}
+
+// RegisterNamespace exposes functions as members of a global namespace,
+// called from scripts as name.member(...), like the fs or time modules.
+func (c *Compiler) RegisterNamespace(name string, functions ...HostFunction) error {
+	if err := c.checkHostName(name); err != nil {
+		return err
+	}
+
+	natives := make([]*NativeFunction, 0, len(functions))
+	seen := make(map[string]bool)
+	for _, fn := range functions {
+		if seen[fn.Name] {
+			return fmt.Errorf("duplicate function %s in namespace %s", fn.Name, name)
+		}
+		seen[fn.Name] = true
+		if err := fn.Signature.check(); err != nil {
+			return fmt.Errorf("%s.%s: %w", name, fn.Name, err)
+		}
+		natives = append(natives, c.hostFunction(name+"."+fn.Name, fn.Signature, fn.Fn))
+	}
+
+	// Members are looked up by their short name
+	for i, native := range natives {
+		native.Name = functions[i].Name
+	}
+	return c.declareNamespace(name, natives)
+}
+
+// RegisterType declares a named type, as `type name = t;` would, so host
+// signatures and scripts can refer to it.
+func (c *Compiler) RegisterType(name string, t ast.Type) error {
+	if _, exists := c.env.getType(name); exists {
+		return fmt.Errorf("type %s is already declared", name)
+	}
+	return c.declareType(name, t)
+}
+
+// NewStruct returns a zero value of the named struct type, for host
+// functions that build struct results.
+func (c *Compiler) NewStruct(typeName string) (*StructValue, error) {
+	declared, exists := c.env.getType(typeName)
+	if !exists {
+		return nil, fmt.Errorf("unknown type: %s", typeName)
+	}
+	structType, ok := declared.(ast.StructType)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct type", typeName)
+	}
+	return c.zeroStruct(typeName, structType)
+}
+
+func (c *Compiler) checkHostName(name string) error {
+	if _, exists := c.env.get(name); exists {
+		return fmt.Errorf("%s is already declared", name)
+	}
+	if _, exists := builtins[name]; exists {
+		return fmt.Errorf("cannot redeclare builtin %s", name)
+	}
+	return nil
+}
+
+// hostFunction adapts fn to a NativeFunction, converting arguments and the
+// result between runtime values and the types declared in signature.
+func (c *Compiler) hostFunction(name string, signature Signature, fn HostFunc) *NativeFunction {
+	native := &NativeFunction{
+		Name:     name,
+		Params:   signature.Params,
+		Return:   signature.Return,
+		Variadic: signature.Variadic,
+	}
+
+	native.Fn = func(args []interface{}) (interface{}, error) {
+		values := make([]Value, len(args))
+		for i, arg := range args {
+			param := signature.Params[min(i, len(signature.Params)-1)]
+			value, err := c.convertValue(param, arg)
+			if err != nil {
+				return nil, fmt.Errorf("argument %d of %s: %w", i+1, name, err)
+			}
+			values[i] = value
+		}
+
+		result, err := fn(values)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if signature.Return == nil {
+			return nil, nil
+		}
+
+		value, err := c.convertValue(signature.Return, result.Value)
+		if err != nil {
+			return nil, fmt.Errorf("result of %s: %w", name, err)
+		}
+		// The tag must match the value, after an int widens to float
+		tag := result.Type
+		if tag == ValueTypeInt && value.Type == ValueTypeFloat {
+			tag = ValueTypeFloat
+		}
+		if tag != value.Type {
+			return nil, fmt.Errorf("%s returned a value tagged %s, expected %s", name, result.Type, value.Type)
+		}
+		return value.Value, nil
+	}
+
+	return native
+}
+
+// convertValue converts v to the runtime representation of t. Go integer
+// and float kinds are accepted, integral floats convert to int and ints
+// widen to float; anything else must already have the expected ValueType.
+func (c *Compiler) convertValue(t ast.Type, v interface{}) (Value, error) {
+	if union, ok := t.(ast.UnionType); ok {
+		for _, member := range union.Types {
+			if value, err := c.convertValue(member, v); err == nil {
+				return value, nil
+			}
+		}
+		return Value{}, fmt.Errorf("cannot use %s as any type of the union", typeName(normalizeHostValue(v)))
+	}
+
+	expected, err := c.valueTypeOf(t)
+	if err != nil {
+		return Value{}, err
+	}
+
+	if _, optional := t.(ast.OptionalType); optional && v == nil {
+		return Value{Type: expected, Value: nil}, nil
+	}
+
+	v = normalizeHostValue(v)
+	if expected == valueTypeAny {
+		return Value{Type: inferValueType(v), Value: v}, nil
+	}
+	switch n := v.(type) {
+	case float64:
+		if expected == ValueTypeInt && n == math.Trunc(n) {
+			v = int64(n)
+		}
+	case int64:
+		if expected == ValueTypeFloat {
+			v = float64(n)
+		}
+	}
+
+	if v == nil || inferValueType(v) != expected {
+		return Value{}, fmt.Errorf("cannot use %s as %s", typeName(v), expected)
+	}
+	return Value{Type: expected, Value: v}, nil
+}
+
+// valueTypeAny is what valueTypeOf returns for a type parameter, which
+// convertValue passes any value through for.
+const valueTypeAny ValueType = -1
+
+func (c *Compiler) valueTypeOf(t ast.Type) (ValueType, error) {
+	switch t := t.(type) {
+	case ast.MapType:
+		return ValueTypeMap, nil
+	case ast.ArrayType:
+		return ValueTypeArray, nil
+	case ast.StructType:
+		return ValueTypeStruct, nil
+	case ast.FunctionType:
+		return ValueTypeFunction, nil
+	case ast.OptionalType:
+		return c.valueTypeOf(t.Underlying)
+	case ast.UnionType:
+		return c.valueTypeOf(t.Types[0])
+	case ast.GenericType, ast.InterfaceType:
+		return ValueTypeObject, nil
+	case ast.TypeParam:
+		// Type arguments are erased, so a T takes any value as it is
+		return valueTypeAny, nil
+	case ast.SymbolType:
+		switch t.Name {
+		case "int":
+			return ValueTypeInt, nil
+		case "float":
+			return ValueTypeFloat, nil
+		case "string":
+			return ValueTypeString, nil
+		case "bool":
+			return ValueTypeBool, nil
+		case "Time":
+			return ValueTypeTime, nil
+		case "Duration":
+			return ValueTypeDuration, nil
+		case "range":
+			return ValueTypeRange, nil
+		}
+
+		declared, exists := c.env.getType(t.Name)
+		if !exists {
+			if c.isClass(t.Name) {
+				return ValueTypeObject, nil
+			}
+			if c.isEnum(t.Name) {
+				return ValueTypeEnum, nil
+			}
+			return 0, fmt.Errorf("unknown type: %s", t.Name)
+		}
+		return c.valueTypeOf(declared)
+	default:
+		return 0, fmt.Errorf("unsupported type %T", t)
+	}
+}
+
+func normalizeHostValue(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int8:
+		return int64(n)
+	case int16:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case uint8:
+		return int64(n)
+	case uint16:
+		return int64(n)
+	case uint32:
+		return int64(n)
+	case float32:
+		return float64(n)
+	default:
+		return v
+	}
+}
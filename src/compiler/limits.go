@@ -0,0 +1,155 @@
+package compiler
+
+import (
+	"context"
+	"fmt"
+)
+
+// Limits bounds the resources a program may use. A zero field means no
+// limit, except for MaxCallDepth.
+type Limits struct {
+	// MaxSteps caps the number of statements and loop iterations executed.
+	MaxSteps int64
+	// MaxCallDepth caps the number of nested function and method calls.
+	// Zero, like any value above it, means MaxCallDepthCeiling.
+	MaxCallDepth int
+	// MaxMemory caps the approximate number of bytes allocated for
+	// strings, arrays, maps, structs and objects.
+	MaxMemory int64
+}
+
+// CanceledError reports that the context passed to Compile was canceled
+// or its deadline passed. It unwraps to the context's error.
+type CanceledError struct {
+	Err error
+}
+
+func (e *CanceledError) Error() string {
+	return fmt.Sprintf("execution stopped: %s", e.Err)
+}
+
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+type StepLimitError struct {
+	Limit int64
+}
+
+func (e *StepLimitError) Error() string {
+	return fmt.Sprintf("step limit of %d exceeded", e.Limit)
+}
+
+type CallDepthError struct {
+	Limit int
+}
+
+func (e *CallDepthError) Error() string {
+	return fmt.Sprintf("call depth limit of %d exceeded", e.Limit)
+}
+
+type MemoryLimitError struct {
+	Limit int64
+}
+
+func (e *MemoryLimitError) Error() string {
+	return fmt.Sprintf("memory limit of %d bytes exceeded", e.Limit)
+}
+
+// MaxCallDepthCeiling bounds the calls nested in any program. Each call
+// takes some of the interpreter's own stack, and a program recursing
+// without end would otherwise overflow it, which crashes the host instead
+// of failing with a CallDepthError.
+const MaxCallDepthCeiling = 10000
+
+// Approximate sizes used to account allocations against MaxMemory.
+const (
+	valueSize  = 16
+	entrySize  = 48
+	objectSize = 64
+)
+
+// limiter tracks the usage of one Compile call. It is shared with every
+// module the program imports, so their work counts against the same
+// budget.
+type limiter struct {
+	// ctxs are the contexts of the runs in progress, more than one when a
+	// host function calls back into the program with Runtime.Call
+	ctxs   []context.Context
+	limits Limits
+	steps  int64
+	depth  int
+	memory int64
+}
+
+func newLimiter() *limiter {
+	return &limiter{}
+}
+
+// begin starts accounting for a run under ctx and returns the function
+// ending it. A run started while another is in progress continues its
+// budget and call depth, and stops when either context is done.
+func (l *limiter) begin(ctx context.Context) (end func()) {
+	if len(l.ctxs) == 0 {
+		l.steps = 0
+		l.depth = 0
+		l.memory = 0
+	}
+	l.ctxs = append(l.ctxs, ctx)
+	return func() { l.ctxs = l.ctxs[:len(l.ctxs)-1] }
+}
+
+// step accounts for one statement or loop iteration.
+func (l *limiter) step() error {
+	l.steps++
+	if l.limits.MaxSteps > 0 && l.steps > l.limits.MaxSteps {
+		return &StepLimitError{Limit: l.limits.MaxSteps}
+	}
+	return nil
+}
+
+// checkContext is called at loop iterations and calls, the only places a
+// program can run for unbounded time.
+func (l *limiter) checkContext() error {
+	for _, ctx := range l.ctxs {
+		if err := ctx.Err(); err != nil {
+			return &CanceledError{Err: err}
+		}
+	}
+	return nil
+}
+
+// iterate accounts for one loop iteration.
+func (l *limiter) iterate() error {
+	if err := l.checkContext(); err != nil {
+		return err
+	}
+	return l.step()
+}
+
+func (l *limiter) enterCall() error {
+	if err := l.checkContext(); err != nil {
+		return err
+	}
+	l.depth++
+	limit := l.limits.MaxCallDepth
+	if limit <= 0 || limit > MaxCallDepthCeiling {
+		limit = MaxCallDepthCeiling
+	}
+	if l.depth > limit {
+		return &CallDepthError{Limit: limit}
+	}
+	return nil
+}
+
+func (l *limiter) exitCall() {
+	l.depth--
+}
+
+func (l *limiter) alloc(bytes int64) error {
+	l.memory += bytes
+	if l.limits.MaxMemory > 0 && l.memory > l.limits.MaxMemory {
+		return &MemoryLimitError{Limit: l.limits.MaxMemory}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package compiler
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// MapValue is the runtime representation of map[K]V. Keys are kept in
+// insertion order so iteration is deterministic.
+type MapValue struct {
+	keys    []interface{}
+	entries map[interface{}]interface{}
+}
+
+func NewMapValue() *MapValue {
+	return &MapValue{
+		keys:    make([]interface{}, 0),
+		entries: make(map[interface{}]interface{}),
+	}
+}
+
+func checkMapKey(key interface{}) error {
+	switch key.(type) {
+	case int64, float64, string, bool:
+		return nil
+	default:
+		return fmt.Errorf("invalid map key type: %s", typeName(key))
+	}
+}
+
+// mapKey makes 2.0 and 2 the same key, as they compare equal with ==.
+func mapKey(key interface{}) interface{} {
+	if f, ok := key.(float64); ok && f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+		return int64(f)
+	}
+	return key
+}
+
+func (m *MapValue) Get(key interface{}) (interface{}, bool) {
+	key = mapKey(key)
+	value, exists := m.entries[key]
+	return value, exists
+}
+
+func (m *MapValue) Has(key interface{}) bool {
+	key = mapKey(key)
+	_, exists := m.entries[key]
+	return exists
+}
+
+func (m *MapValue) Set(key interface{}, value interface{}) {
+	key = mapKey(key)
+	if _, exists := m.entries[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.entries[key] = value
+}
+
+func (m *MapValue) Delete(key interface{}) bool {
+	key = mapKey(key)
+	if _, exists := m.entries[key]; !exists {
+		return false
+	}
+
+	delete(m.entries, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+func (m *MapValue) Len() int {
+	return len(m.keys)
+}
+
+// Keys returns a copy of the keys in insertion order, so callers may
+// modify the map while iterating over the result.
+func (m *MapValue) Keys() []interface{} {
+	keys := make([]interface{}, len(m.keys))
+	copy(keys, m.keys)
+	return keys
+}
+
+func (m *MapValue) String() string {
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i, key := range m.keys {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(formatValue(key))
+		sb.WriteString(": ")
+		sb.WriteString(formatValue(m.entries[key]))
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// formatValue renders a value the way it would be written in source,
+// which is how nested values are shown inside containers.
+func formatValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%v", displayValue(value))
+}
+
+// displayValue is how print shows a value: Go's nil is the null literal.
+func displayValue(value interface{}) interface{} {
+	if value == nil {
+		return "null"
+	}
+	return value
+}
@@ -0,0 +1,234 @@
+package compiler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/checker"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+	"github.com/RyanOliveira00/go-compiler/src/parser"
+)
+
+// moduleLoader is shared by a program and every module it imports, so
+// each file is checked and initialized only once and import cycles can be
+// detected.
+type moduleLoader struct {
+	modules map[string]*Compiler
+	loading []string
+	// order lists the modules checked, each after the ones it imports
+	order []*Compiler
+}
+
+func newModuleLoader() *moduleLoader {
+	return &moduleLoader{
+		modules: make(map[string]*Compiler),
+	}
+}
+
+// ModuleError names the file in which an error happened.
+type ModuleError struct {
+	Path string
+	Err  error
+}
+
+func (e *ModuleError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+func (e *ModuleError) Unwrap() error {
+	return e.Err
+}
+
+// RunFile compiles and runs the program in the file at path. Imports in
+// the file are resolved relative to its directory.
+func (c *Compiler) RunFile(ctx context.Context, path string) (interface{}, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.path = abs
+	defer c.limiter.begin(ctx)()
+	if err := c.checkFile(); err != nil {
+		return nil, err
+	}
+	c.modules.modules[abs] = c
+	return c.runFile()
+}
+
+// checkFile reads, parses and checks the module at c.path, and with it
+// every module it imports, without running any of them.
+func (c *Compiler) checkFile() error {
+	c.modules.loading = append(c.modules.loading, c.path)
+	defer func() { c.modules.loading = c.modules.loading[:len(c.modules.loading)-1] }()
+
+	source, err := os.ReadFile(c.path)
+	if err != nil {
+		return &ModuleError{Path: displayPath(c.path), Err: err}
+	}
+
+	program, err := parseSource(string(source))
+	if err != nil {
+		return &ModuleError{Path: displayPath(c.path), Err: err}
+	}
+
+	optimized, err := c.check(program)
+	if err != nil {
+		if hasLocation(err) {
+			return err
+		}
+		return &ModuleError{Path: displayPath(c.path), Err: err}
+	}
+
+	c.source, c.program, c.optimized = string(source), program, optimized
+	c.modules.order = append(c.modules.order, c)
+	return nil
+}
+
+// runFile runs the module checked by checkFile.
+func (c *Compiler) runFile() (interface{}, error) {
+	result, err := c.run(c.optimized)
+	if err != nil {
+		if hasLocation(err) {
+			return nil, err
+		}
+		return nil, &ModuleError{Path: displayPath(c.path), Err: err}
+	}
+	c.ran = true
+	return result, nil
+}
+
+// hasLocation reports whether err already names the file it happened in.
+func hasLocation(err error) bool {
+	var moduleErr *ModuleError
+	var runtimeErr *RuntimeError
+	return errors.As(err, &moduleErr) || errors.As(err, &runtimeErr)
+}
+
+// parseSource turns the panics raised by the lexer and parser into errors.
+func parseSource(source string) (program ast.BlockStmt, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s", strings.TrimSpace(fmt.Sprint(r)))
+		}
+	}()
+
+	return parser.Parse(lexer.Tokenize(source)), nil
+}
+
+// Import implements checker.Importer. It only checks the module, so that
+// no module runs before the whole program has been checked.
+func (c *Compiler) Import(path string) (map[string]checker.Export, error) {
+	module, err := c.checkModule(path)
+	if err != nil {
+		return nil, err
+	}
+	return module.checker.Exports(), nil
+}
+
+// checkModule returns the module imported from path, checking it the
+// first time.
+func (c *Compiler) checkModule(path string) (*Compiler, error) {
+	abs, err := c.resolveModulePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if module, checked := c.modules.modules[abs]; checked {
+		return module, nil
+	}
+	// Imported files are read as fs.readFile reads them, unlike the
+	// program's own file, which the host chose
+	if abs, err = c.capabilities.resolvePath("import", abs, false); err != nil {
+		return nil, err
+	}
+
+	if err := importCycle(c.modules.loading, abs); err != nil {
+		return nil, err
+	}
+
+	module := newCompiler(abs, c)
+	if err := module.checkFile(); err != nil {
+		return nil, err
+	}
+
+	c.modules.modules[abs] = module
+	return module, nil
+}
+
+// loadModule returns the module imported from path, running it the first
+// time, so its top level executes once however many files import it.
+func (c *Compiler) loadModule(path string) (*Compiler, error) {
+	module, err := c.checkModule(path)
+	if err != nil {
+		return nil, err
+	}
+	if !module.ran {
+		if _, err := module.runFile(); err != nil {
+			return nil, err
+		}
+	}
+	return module, nil
+}
+
+// importCycle fails when the module at abs is among the ones loading,
+// which import each other in order.
+func importCycle(loading []string, abs string) error {
+	for i, path := range loading {
+		if path == abs {
+			cycle := make([]string, 0, len(loading)-i+1)
+			for _, p := range append(loading[i:], abs) {
+				cycle = append(cycle, displayPath(p))
+			}
+			return fmt.Errorf("import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	return nil
+}
+
+func (c *Compiler) resolveModulePath(path string) (string, error) {
+	if !strings.HasPrefix(path, "./") && !strings.HasPrefix(path, "../") {
+		return "", fmt.Errorf("module path %q must start with ./ or ../", path)
+	}
+
+	base := "."
+	if c.path != "" {
+		base = filepath.Dir(c.path)
+	}
+	return filepath.Abs(filepath.Join(base, path))
+}
+
+// displayPath shortens path relative to the working directory for errors.
+func displayPath(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil {
+			return rel
+		}
+	}
+	return path
+}
+
+// executeImport binds the imported names in the current module. Values are
+// copied at import time, types and functions keep pointing at the module
+// that declared them.
+func (c *Compiler) executeImport(stmt ast.ImportStmt) error {
+	module, err := c.loadModule(stmt.Path)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range stmt.Names {
+		if value, exists := module.env.variables[name]; exists {
+			c.env.define(name, value)
+		}
+		if t, exists := module.env.types[name]; exists {
+			c.env.types[name] = t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package compiler
+
+import "github.com/RyanOliveira00/go-compiler/src/ast"
+
+// NativeFunction is a function implemented in Go. Its signature is
+// written with the same type expressions the parser produces, so the
+// checker can resolve it like a declared function.
+type NativeFunction struct {
+	Name     string
+	Params   []ast.Type
+	Return   ast.Type
+	Variadic bool
+	Fn       func(args []interface{}) (interface{}, error)
+}
+
+func (f *NativeFunction) String() string {
+	return "fn " + f.Name
+}
+
+// NamespaceValue groups the members of a built-in module, e.g. fs.
+type NamespaceValue struct {
+	Name    string
+	members map[string]interface{}
+}
+
+func (n *NamespaceValue) String() string {
+	return "module " + n.Name
+}
+
+var (
+	typeInt      = ast.SymbolType{Name: "int"}
+	typeFloat    = ast.SymbolType{Name: "float"}
+	typeString   = ast.SymbolType{Name: "string"}
+	typeBool     = ast.SymbolType{Name: "bool"}
+	typeTime     = ast.SymbolType{Name: "Time"}
+	typeDuration = ast.SymbolType{Name: "Duration"}
+)
@@ -0,0 +1,180 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+)
+
+// RangeValue is the runtime representation of a..b. Ranges are lazy: the
+// elements are computed on demand, so 0..1000000 costs no memory.
+type RangeValue struct {
+	Start     int64
+	End       int64
+	Step      int64
+	Inclusive bool
+}
+
+func NewRangeValue(start, end, step int64, inclusive bool) (*RangeValue, error) {
+	if step == 0 {
+		return nil, fmt.Errorf("range step cannot be zero")
+	}
+	return &RangeValue{Start: start, End: end, Step: step, Inclusive: inclusive}, nil
+}
+
+// Len is the number of elements, which is zero when the step walks away
+// from the end.
+func (r *RangeValue) Len() int {
+	end := r.End
+	if r.Inclusive {
+		if r.Step > 0 {
+			end++
+		} else {
+			end--
+		}
+	}
+
+	var span, step int64
+	if r.Step > 0 {
+		span, step = end-r.Start, r.Step
+	} else {
+		span, step = r.Start-end, -r.Step
+	}
+	if span <= 0 {
+		return 0
+	}
+	return int((span + step - 1) / step)
+}
+
+// At returns the i-th element, i must be within [0, Len).
+func (r *RangeValue) At(i int) int64 {
+	return r.Start + int64(i)*r.Step
+}
+
+func (r *RangeValue) Contains(n int64) bool {
+	offset := n - r.Start
+	if offset%r.Step != 0 {
+		return false
+	}
+	i := offset / r.Step
+	return i >= 0 && i < int64(r.Len())
+}
+
+func (r *RangeValue) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d..", r.Start)
+	if r.Inclusive {
+		sb.WriteString("=")
+	}
+	fmt.Fprintf(&sb, "%d", r.End)
+	if r.Step != 1 {
+		fmt.Fprintf(&sb, " step %d", r.Step)
+	}
+	return sb.String()
+}
+
+func (c *Compiler) executeRangeExpr(expr ast.RangeExpr) (interface{}, error) {
+	bounds := []ast.Expr{expr.Start, expr.End}
+	if expr.Step != nil {
+		bounds = append(bounds, expr.Step)
+	}
+
+	values := []int64{0, 0, 1}
+	for i, bound := range bounds {
+		value, err := c.executeExpr(bound)
+		if err != nil {
+			return nil, err
+		}
+		n, ok := toIndex(value)
+		if !ok {
+			return nil, c.runtimeError(bound, fmt.Errorf("range bounds must be integers, got %s", formatValue(value)))
+		}
+		values[i] = int64(n)
+	}
+
+	r, err := NewRangeValue(values[0], values[1], values[2], expr.Inclusive)
+	if err != nil {
+		return nil, c.runtimeError(expr, err)
+	}
+	return r, nil
+}
+
+// sliceIndexes validates r as a slice of a sequence of length n. Like Go,
+// an empty slice may start at n itself.
+func (r *RangeValue) sliceIndexes(n int) error {
+	length := r.Len()
+	if length == 0 {
+		if r.Start < 0 || r.Start > int64(n) {
+			return fmt.Errorf("slice %s out of range [0:%d]", r, n)
+		}
+		return nil
+	}
+
+	first, last := r.At(0), r.At(length-1)
+	if first < 0 || first >= int64(n) || last < 0 || last >= int64(n) {
+		return fmt.Errorf("slice %s out of range [0:%d]", r, n)
+	}
+	return nil
+}
+
+func (c *Compiler) sliceArray(array *ArrayValue, r *RangeValue) (*ArrayValue, error) {
+	if err := r.sliceIndexes(array.Len()); err != nil {
+		return nil, err
+	}
+
+	elements := make([]interface{}, 0, r.Len())
+	for i := 0; i < r.Len(); i++ {
+		elements = append(elements, copyValue(array.elements[r.At(i)]))
+	}
+	if err := c.limiter.alloc(int64(len(elements)) * valueSize); err != nil {
+		return nil, err
+	}
+	return NewArrayValue(elements), nil
+}
+
+// sliceString slices bytes, the same unit len counts in.
+func (c *Compiler) sliceString(s string, r *RangeValue) (string, error) {
+	if err := r.sliceIndexes(len(s)); err != nil {
+		return "", err
+	}
+	if err := c.limiter.alloc(int64(r.Len())); err != nil {
+		return "", err
+	}
+
+	if r.Step == 1 {
+		return s[r.Start : r.Start+int64(r.Len())], nil
+	}
+	var sb strings.Builder
+	for i := 0; i < r.Len(); i++ {
+		sb.WriteByte(s[r.At(i)])
+	}
+	return sb.String(), nil
+}
+
+// contains implements element in container.
+func (c *Compiler) contains(container, element interface{}) (bool, error) {
+	switch v := container.(type) {
+	case *RangeValue:
+		n, ok := toIndex(element)
+		return ok && v.Contains(int64(n)), nil
+	case *ArrayValue:
+		for _, candidate := range v.elements {
+			if c.valuesEqual(candidate, element) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case *MapValue:
+		_, exists := v.Get(element)
+		return exists, nil
+	case string:
+		sub, ok := element.(string)
+		if !ok {
+			return false, fmt.Errorf("cannot search for %s in string", typeName(element))
+		}
+		return strings.Contains(v, sub), nil
+	default:
+		return false, fmt.Errorf("cannot use in with %s", typeName(container))
+	}
+}
@@ -0,0 +1,271 @@
+package compiler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/checker"
+)
+
+// Options configures a Runtime. Nil streams default to the process'
+// standard input and output. The zero Capabilities deny file system and
+// stdin access, so scripts only reach what the host grants explicitly.
+type Options struct {
+	Stdout       io.Writer
+	Stdin        io.Reader
+	Limits       Limits
+	Capabilities Capabilities
+}
+
+// Runtime evaluates scripts on behalf of a Go program. Globals injected
+// with Set and those declared by scripts persist across RunSource calls,
+// the same way REPL lines share one scope. The embedded Compiler exposes
+// RegisterFunc, RegisterNamespace, RegisterType and NewStruct.
+type Runtime struct {
+	*Compiler
+	inputs map[string]bool
+}
+
+func NewRuntime(opts Options) *Runtime {
+	r := &Runtime{
+		Compiler: New(),
+		inputs:   make(map[string]bool),
+	}
+	if opts.Stdout != nil {
+		r.stdout = opts.Stdout
+	}
+	if opts.Stdin != nil {
+		r.stdin = opts.Stdin
+	}
+	r.SetLimits(opts.Limits)
+	r.SetCapabilities(opts.Capabilities)
+	return r
+}
+
+// RunSource checks and runs src, returning the value of its last
+// statement. name identifies the source in errors, and imports are
+// resolved relative to it.
+func (r *Runtime) RunSource(ctx context.Context, name, src string) (Value, error) {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return Value{}, err
+	}
+	r.path = abs
+
+	program, err := parseSource(src)
+	if err != nil {
+		return Value{}, &ModuleError{Path: name, Err: err}
+	}
+
+	defer r.limiter.begin(ctx)()
+	result, err := r.compile(program)
+	if err != nil {
+		if hasLocation(err) {
+			return Value{}, err
+		}
+		return Value{}, &ModuleError{Path: name, Err: err}
+	}
+	return Value{Type: inferValueType(result), Value: result}, nil
+}
+
+// Set injects value as a constant global visible to later scripts. value
+// is either a Value or a plain Go value: integers, floats, strings, bools,
+// time.Time, time.Duration, or an array, map or struct built with
+// NewArrayValue, NewMapValue or NewStruct. Setting the same name again
+// replaces the previous value.
+func (r *Runtime) Set(name string, value interface{}) error {
+	if !r.inputs[name] {
+		if err := r.checkHostName(name); err != nil {
+			return err
+		}
+	}
+
+	if v, ok := value.(Value); ok {
+		value = v.Value
+	}
+	value = normalizeHostValue(value)
+
+	t, err := r.checkerType(value)
+	if err != nil {
+		return fmt.Errorf("cannot set %s: %w", name, err)
+	}
+
+	r.env.define(name, Value{Type: inferValueType(value), Value: copyValue(value)})
+	r.checker.Declare(name, t)
+	r.inputs[name] = true
+	return nil
+}
+
+// Get returns the current value of a global.
+func (r *Runtime) Get(name string) (Value, bool) {
+	value, exists := r.env.get(name)
+	if !exists {
+		return Value{}, false
+	}
+	return typedValue(value), true
+}
+
+// Call calls the global function fnName. Arguments are converted to the
+// declared parameter types as they are for host functions. The returned
+// Value holds nil for functions without a result. Each call gets a fresh
+// budget under the runtime's limits, and stops like RunSource when ctx is
+// canceled. A call made by a host function while a script runs is part
+// of that run instead: it uses what is left of the run's budget, and also
+// stops when the run's context is canceled.
+func (r *Runtime) Call(ctx context.Context, fnName string, args ...interface{}) (Value, error) {
+	defer r.limiter.begin(ctx)()
+
+	global, exists := r.env.get(fnName)
+	if !exists {
+		return Value{}, fmt.Errorf("undefined function: %s", fnName)
+	}
+
+	switch fn := global.Value.(type) {
+	case *FunctionValue:
+		params := make([]ast.Type, 0, len(fn.Decl.Parameters))
+		for _, param := range fn.Decl.Parameters {
+			params = append(params, param.Type)
+		}
+		// The types are resolved where fn is declared, among its type
+		// parameters
+		env := r.env
+		r.env = typeParamEnv(fn.env, fn.Decl.TypeParams)
+		defer func() { r.env = env }()
+
+		converted, err := r.convertArgs(fnName, params, false, args)
+		if err != nil {
+			return Value{}, err
+		}
+
+		result, err := r.callFunction(fn, converted)
+		if err != nil {
+			return Value{}, err
+		}
+		if fn.Decl.ReturnType == nil {
+			return Value{}, nil
+		}
+		return r.convertValue(fn.Decl.ReturnType, result)
+	case *NativeFunction:
+		converted, err := r.convertArgs(fnName, fn.Params, fn.Variadic, args)
+		if err != nil {
+			return Value{}, err
+		}
+
+		result, err := r.callNative(fn, converted)
+		if err != nil {
+			return Value{}, err
+		}
+		if fn.Return == nil {
+			return Value{}, nil
+		}
+		return Value{Type: inferValueType(result), Value: result}, nil
+	default:
+		return Value{}, fmt.Errorf("%s is not a function", fnName)
+	}
+}
+
+func (r *Runtime) convertArgs(name string, params []ast.Type, variadic bool, args []interface{}) ([]interface{}, error) {
+	if (!variadic && len(args) != len(params)) || (variadic && len(args) < len(params)-1) {
+		return nil, fmt.Errorf("%s expects %d arguments, got %d", name, len(params), len(args))
+	}
+
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		if v, ok := arg.(Value); ok {
+			arg = v.Value
+		}
+		value, err := r.convertValue(params[min(i, len(params)-1)], arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d of %s: %w", i+1, name, err)
+		}
+		converted[i] = value.Value
+	}
+	return converted, nil
+}
+
+// checkerType describes a value injected from Go to the checker. Arrays
+// and maps take their element types from their contents, empty ones hold
+// any.
+func (r *Runtime) checkerType(value interface{}) (checker.Type, error) {
+	switch v := value.(type) {
+	case int64:
+		return checker.Int, nil
+	case float64:
+		return checker.Float, nil
+	case string:
+		return checker.String, nil
+	case bool:
+		return checker.Bool, nil
+	case time.Time:
+		return checker.Time, nil
+	case time.Duration:
+		return checker.Duration, nil
+	case *RangeValue:
+		return checker.Range, nil
+	case *StructValue:
+		t, exists := r.checker.LookupType(v.TypeName)
+		if !exists {
+			return nil, fmt.Errorf("unknown type: %s", v.TypeName)
+		}
+		return t, nil
+	case *ArrayValue:
+		elem, err := r.commonCheckerType(v.elements)
+		if err != nil {
+			return nil, err
+		}
+		return checker.ArrayType{Elem: elem}, nil
+	case *MapValue:
+		keys := v.Keys()
+		values := make([]interface{}, 0, len(keys))
+		for _, key := range keys {
+			value, _ := v.Get(key)
+			values = append(values, value)
+		}
+
+		key, err := r.commonCheckerType(keys)
+		if err != nil {
+			return nil, err
+		}
+		elem, err := r.commonCheckerType(values)
+		if err != nil {
+			return nil, err
+		}
+		return checker.MapType{Key: key, Value: elem}, nil
+	default:
+		return nil, fmt.Errorf("unsupported value %T", value)
+	}
+}
+
+func (r *Runtime) commonCheckerType(values []interface{}) (checker.Type, error) {
+	if len(values) == 0 {
+		return checker.Any, nil
+	}
+
+	common, err := r.checkerType(values[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values[1:] {
+		t, err := r.checkerType(value)
+		if err != nil {
+			return nil, err
+		}
+		if !checker.Identical(common, t) {
+			return nil, fmt.Errorf("mixed element types %s and %s", common, t)
+		}
+	}
+	return common, nil
+}
+
+// typedValue converts a number stored under an int declaration to int64,
+// for values that reached it untyped, e.g. through an any parameter.
+func typedValue(value Value) Value {
+	if f, ok := value.Value.(float64); ok && value.Type == ValueTypeInt {
+		value.Value = int64(f)
+	}
+	return value
+}
@@ -0,0 +1,117 @@
+package compiler_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/compiler"
+)
+
+var intType = ast.SymbolType{Name: "int"}
+
+// TestCallDuringRun checks that a host function calling back into the
+// script continues the run's budget instead of starting a new one.
+func TestCallDuringRun(t *testing.T) {
+	ctx := context.Background()
+	r := compiler.NewRuntime(compiler.Options{Limits: compiler.Limits{MaxSteps: 50}})
+	err := r.RegisterFunc("twice", compiler.Signature{Params: []ast.Type{intType}, Return: intType},
+		func(args []compiler.Value) (compiler.Value, error) {
+			return r.Call(ctx, "double", args[0].Value)
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.RunSource(ctx, "main.lang", `
+fn double(n: int): int { return n * 2; }
+let total = 0;
+foreach i in 0..100 { total += twice(i); }
+`)
+	var limit *compiler.StepLimitError
+	if !errors.As(err, &limit) {
+		t.Fatalf("got %v, want a StepLimitError", err)
+	}
+
+	// The budget is fresh again once the run is over
+	if _, err := r.Call(ctx, "double", 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCallDuringCanceledRun checks that a call back into the script stops
+// when the run's context is canceled, whatever context it was given.
+func TestCallDuringCanceledRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := compiler.NewRuntime(compiler.Options{Stdout: io.Discard})
+	err := r.RegisterFunc("stop", compiler.Signature{Return: intType},
+		func(args []compiler.Value) (compiler.Value, error) {
+			cancel()
+			return r.Call(context.Background(), "spin")
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.RunSource(ctx, "main.lang", `
+fn spin(): int {
+    let n = 0;
+    while (n < 1000000) { n += 1; }
+    return n;
+}
+print(stop());
+`)
+	var canceled *compiler.CanceledError
+	if !errors.As(err, &canceled) {
+		t.Fatalf("got %v, want a CanceledError", err)
+	}
+}
+
+// TestCallGeneric checks that a type parameter takes the value passed as
+// it is.
+func TestCallGeneric(t *testing.T) {
+	ctx := context.Background()
+	r := compiler.NewRuntime(compiler.Options{})
+	if _, err := r.RunSource(ctx, "main.lang", `fn id<T>(x: T): T { return x; }`); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.Call(ctx, "id", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != compiler.ValueTypeInt || got.Value != int64(7) {
+		t.Errorf("id(7) = %v, want int 7", got)
+	}
+	got, err = r.Call(ctx, "id", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != compiler.ValueTypeString || got.Value != "a" {
+		t.Errorf(`id("a") = %v, want string "a"`, got)
+	}
+}
+
+// TestCallEnum checks that an enum result is tagged as an enum.
+func TestCallEnum(t *testing.T) {
+	ctx := context.Background()
+	r := compiler.NewRuntime(compiler.Options{})
+	_, err := r.RunSource(ctx, "main.lang", `
+enum Color { Red, Green }
+fn green(): Color { return Color.Green; }
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.Call(ctx, "green")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != compiler.ValueTypeEnum {
+		t.Errorf("green() is tagged %s, want enum", got.Type)
+	}
+}
@@ -0,0 +1,320 @@
+package compiler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/checker"
+)
+
+// stdlibModule is a built-in module, available in every program as a
+// global namespace without an import.
+type stdlibModule struct {
+	Name      string
+	Types     []ast.TypeDeclStmt
+	Functions []*NativeFunction
+}
+
+// stdlib returns the built-in modules, checking caps on every call that
+// reaches the host.
+func stdlib(caps *Capabilities) []*stdlibModule {
+	return []*stdlibModule{
+		fsModule(caps),
+		pathModule(),
+		timeModule(caps),
+	}
+}
+
+// installStdlib declares the built-in modules in both the checker and the
+// global environment of c.
+func (c *Compiler) installStdlib() error {
+	for _, module := range stdlib(c.capabilities) {
+		for _, decl := range module.Types {
+			if err := c.declareType(decl.Name, decl.Type); err != nil {
+				return err
+			}
+		}
+		if err := c.declareNamespace(module.Name, module.Functions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Compiler) declareType(name string, t ast.Type) error {
+	if err := c.checker.DeclareType(name, t); err != nil {
+		return err
+	}
+	c.env.types[name] = t
+	return nil
+}
+
+// declareNamespace binds name to a namespace holding functions, visible
+// to the checker as a NamespaceType with the functions' signatures.
+func (c *Compiler) declareNamespace(name string, functions []*NativeFunction) error {
+	namespace := &NamespaceValue{
+		Name:    name,
+		members: make(map[string]interface{}),
+	}
+	namespaceType := checker.NamespaceType{
+		Name:    name,
+		Members: make(map[string]checker.Type),
+	}
+
+	for _, fn := range functions {
+		signature, err := c.nativeSignature(fn)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", name, fn.Name, err)
+		}
+		namespace.members[fn.Name] = fn
+		namespaceType.Members[fn.Name] = signature
+	}
+
+	c.env.define(name, Value{Type: ValueTypeNamespace, Value: namespace})
+	c.checker.Declare(name, namespaceType)
+	return nil
+}
+
+func (c *Compiler) nativeSignature(fn *NativeFunction) (checker.FunctionType, error) {
+	params := make([]checker.Type, 0, len(fn.Params))
+	for _, param := range fn.Params {
+		t, err := c.checker.ResolveType(param)
+		if err != nil {
+			return checker.FunctionType{}, err
+		}
+		params = append(params, t)
+	}
+
+	var result checker.Type = checker.Void
+	if fn.Return != nil {
+		t, err := c.checker.ResolveType(fn.Return)
+		if err != nil {
+			return checker.FunctionType{}, err
+		}
+		result = t
+	}
+
+	return checker.FunctionType{Params: params, Return: result, Variadic: fn.Variadic}, nil
+}
+
+func (c *Compiler) callNative(fn *NativeFunction, args []interface{}) (interface{}, error) {
+	if !fn.Variadic && len(args) != len(fn.Params) {
+		return nil, fmt.Errorf("%s expects %d arguments, got %d", fn.Name, len(fn.Params), len(args))
+	}
+	return fn.Fn(args)
+}
+
+// --------------------
+// fs
+// --------------------
+
+var fileInfoType = ast.StructType{
+	Fields: []ast.StructField{
+		{Name: "name", Type: typeString},
+		{Name: "size", Type: typeInt},
+		{Name: "isDir", Type: typeBool},
+		{Name: "creationTime", Type: typeTime},
+		{Name: "modTime", Type: typeTime},
+	},
+}
+
+func fsModule(caps *Capabilities) *stdlibModule {
+	return &stdlibModule{
+		Name: "fs",
+		Types: []ast.TypeDeclStmt{
+			{Name: "FileInfo", Type: fileInfoType},
+		},
+		Functions: []*NativeFunction{
+			{
+				Name:   "readDir",
+				Params: []ast.Type{typeString},
+				Return: ast.ArrayType{Underlying: typeString},
+				Fn: func(args []interface{}) (interface{}, error) {
+					path, err := caps.resolvePath("fs.readDir", args[0].(string), false)
+					if err != nil {
+						return nil, err
+					}
+					entries, err := os.ReadDir(path)
+					if err != nil {
+						return nil, err
+					}
+					names := make([]interface{}, 0, len(entries))
+					for _, entry := range entries {
+						names = append(names, entry.Name())
+					}
+					return NewArrayValue(names), nil
+				},
+			},
+			{
+				Name:   "stat",
+				Params: []ast.Type{typeString},
+				Return: ast.SymbolType{Name: "FileInfo"},
+				Fn: func(args []interface{}) (interface{}, error) {
+					path, err := caps.resolvePath("fs.stat", args[0].(string), false)
+					if err != nil {
+						return nil, err
+					}
+					info, err := os.Stat(path)
+					if err != nil {
+						return nil, err
+					}
+					return newFileInfo(info), nil
+				},
+			},
+			{
+				Name:   "readFile",
+				Params: []ast.Type{typeString},
+				Return: typeString,
+				Fn: func(args []interface{}) (interface{}, error) {
+					path, err := caps.resolvePath("fs.readFile", args[0].(string), false)
+					if err != nil {
+						return nil, err
+					}
+					content, err := os.ReadFile(path)
+					if err != nil {
+						return nil, err
+					}
+					return string(content), nil
+				},
+			},
+			{
+				Name:   "writeFile",
+				Params: []ast.Type{typeString, typeString},
+				Fn: func(args []interface{}) (interface{}, error) {
+					path, err := caps.resolvePath("fs.writeFile", args[0].(string), true)
+					if err != nil {
+						return nil, err
+					}
+					return nil, os.WriteFile(path, []byte(args[1].(string)), 0644)
+				},
+			},
+			{
+				Name:   "exists",
+				Params: []ast.Type{typeString},
+				Return: typeBool,
+				Fn: func(args []interface{}) (interface{}, error) {
+					path, err := caps.resolvePath("fs.exists", args[0].(string), false)
+					if err != nil {
+						return nil, err
+					}
+					_, err = os.Stat(path)
+					return err == nil, nil
+				},
+			},
+		},
+	}
+}
+
+// newFileInfo converts an os.FileInfo into a FileInfo struct. Go has no
+// portable access to a file's birth time, so creationTime is the last
+// modification time.
+func newFileInfo(info os.FileInfo) *StructValue {
+	fields := make([]string, 0, len(fileInfoType.Fields))
+	for _, field := range fileInfoType.Fields {
+		fields = append(fields, field.Name)
+	}
+
+	s := NewStructValue("FileInfo", fields)
+	s.values["name"] = info.Name()
+	s.values["size"] = info.Size()
+	s.values["isDir"] = info.IsDir()
+	s.values["creationTime"] = info.ModTime()
+	s.values["modTime"] = info.ModTime()
+	return s
+}
+
+// --------------------
+// path
+// --------------------
+
+func pathModule() *stdlibModule {
+	stringFn := func(name string, fn func(string) string) *NativeFunction {
+		return &NativeFunction{
+			Name:   name,
+			Params: []ast.Type{typeString},
+			Return: typeString,
+			Fn: func(args []interface{}) (interface{}, error) {
+				return fn(args[0].(string)), nil
+			},
+		}
+	}
+
+	return &stdlibModule{
+		Name: "path",
+		Functions: []*NativeFunction{
+			{
+				Name:     "join",
+				Params:   []ast.Type{typeString},
+				Return:   typeString,
+				Variadic: true,
+				Fn: func(args []interface{}) (interface{}, error) {
+					parts := make([]string, 0, len(args))
+					for _, arg := range args {
+						parts = append(parts, arg.(string))
+					}
+					return filepath.Join(parts...), nil
+				},
+			},
+			stringFn("base", filepath.Base),
+			stringFn("dir", filepath.Dir),
+			stringFn("ext", filepath.Ext),
+		},
+	}
+}
+
+// --------------------
+// time
+// --------------------
+
+func timeModule(caps *Capabilities) *stdlibModule {
+	durationFn := func(name string, unit time.Duration) *NativeFunction {
+		return &NativeFunction{
+			Name:   name,
+			Params: []ast.Type{typeFloat},
+			Return: typeDuration,
+			Fn: func(args []interface{}) (interface{}, error) {
+				n, err := toFloat(args[0])
+				if err != nil {
+					return nil, err
+				}
+				return time.Duration(n * float64(unit)), nil
+			},
+		}
+	}
+
+	return &stdlibModule{
+		Name: "time",
+		Functions: []*NativeFunction{
+			{
+				Name:   "now",
+				Return: typeTime,
+				Fn: func(args []interface{}) (interface{}, error) {
+					return caps.now(), nil
+				},
+			},
+			{
+				Name:   "since",
+				Params: []ast.Type{typeTime},
+				Return: typeDuration,
+				Fn: func(args []interface{}) (interface{}, error) {
+					return caps.now().Sub(args[0].(time.Time)), nil
+				},
+			},
+			{
+				Name:   "unix",
+				Params: []ast.Type{typeTime},
+				Return: typeInt,
+				Fn: func(args []interface{}) (interface{}, error) {
+					return args[0].(time.Time).Unix(), nil
+				},
+			},
+			durationFn("hours", time.Hour),
+			durationFn("minutes", time.Minute),
+			durationFn("seconds", time.Second),
+			durationFn("milliseconds", time.Millisecond),
+		},
+	}
+}
@@ -0,0 +1,105 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fieldHolder is implemented by values whose fields can be assigned.
+type fieldHolder interface {
+	Get(field string) (interface{}, bool)
+	Set(field string, value interface{}) error
+}
+
+// StructValue is an instance of a struct type. Structs have value
+// semantics: copyValue must be used whenever one is stored somewhere new.
+type StructValue struct {
+	TypeName string
+	fields   []string
+	values   map[string]interface{}
+}
+
+func NewStructValue(typeName string, fields []string) *StructValue {
+	return &StructValue{
+		TypeName: typeName,
+		fields:   fields,
+		values:   make(map[string]interface{}),
+	}
+}
+
+func (s *StructValue) Get(field string) (interface{}, bool) {
+	value, exists := s.values[field]
+	return value, exists
+}
+
+func (s *StructValue) Set(field string, value interface{}) error {
+	if _, exists := s.values[field]; !exists {
+		return fmt.Errorf("%s has no field %s", s.TypeName, field)
+	}
+	s.values[field] = value
+	return nil
+}
+
+func (s *StructValue) String() string {
+	var sb strings.Builder
+	sb.WriteString(s.TypeName)
+	sb.WriteString(" { ")
+	for i, field := range s.fields {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(field)
+		sb.WriteString(": ")
+		sb.WriteString(formatValue(s.values[field]))
+	}
+	sb.WriteString(" }")
+	return sb.String()
+}
+
+// copyValue returns a copy of value that can be stored independently of
+// the original. Only structs need copying, every other value is either
+// immutable or shared by reference.
+func copyValue(value interface{}) interface{} {
+	s, ok := value.(*StructValue)
+	if !ok {
+		return value
+	}
+
+	clone := NewStructValue(s.TypeName, s.fields)
+	for field, v := range s.values {
+		clone.values[field] = copyValue(v)
+	}
+	return clone
+}
+
+// valuesEqual implements == for non numeric values. Structs compare
+// field by field, enum values by variant and payload, maps by identity.
+func (c *Compiler) valuesEqual(left, right interface{}) bool {
+	if lv, ok := left.(*VariantValue); ok {
+		rv, ok := right.(*VariantValue)
+		return ok && lv.equal(rv, c.valuesEqual)
+	}
+
+	ls, lok := left.(*StructValue)
+	rs, rok := right.(*StructValue)
+	if lok || rok {
+		if !lok || !rok || ls.TypeName != rs.TypeName || len(ls.values) != len(rs.values) {
+			return false
+		}
+		for field, lv := range ls.values {
+			rv, exists := rs.values[field]
+			if !exists || !c.valuesEqual(lv, rv) {
+				return false
+			}
+		}
+		return true
+	}
+
+	leftNum, lerr := c.toNumber(left)
+	rightNum, rerr := c.toNumber(right)
+	if lerr == nil && rerr == nil {
+		return leftNum == rightNum
+	}
+
+	return left == right
+}
@@ -0,0 +1,83 @@
+package compiler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+func isTimeValue(value interface{}) bool {
+	switch value.(type) {
+	case time.Time, time.Duration:
+		return true
+	default:
+		return false
+	}
+}
+
+// applyTimeOperator implements arithmetic and comparisons between Time
+// and Duration values. == and != are handled by valuesEqual.
+func applyTimeOperator(operator lexer.Token, left, right interface{}) (interface{}, error) {
+	invalid := fmt.Errorf("invalid operation: %s %s %s", typeName(left), operator.Value, typeName(right))
+
+	switch l := left.(type) {
+	case time.Time:
+		switch r := right.(type) {
+		case time.Time:
+			switch operator.Kind {
+			case lexer.DASH:
+				return l.Sub(r), nil
+			case lexer.LESS:
+				return l.Before(r), nil
+			case lexer.LESS_EQUALS:
+				return !l.After(r), nil
+			case lexer.GREATER:
+				return l.After(r), nil
+			case lexer.GREATER_EQUALS:
+				return !l.Before(r), nil
+			}
+		case time.Duration:
+			switch operator.Kind {
+			case lexer.PLUS:
+				return l.Add(r), nil
+			case lexer.DASH:
+				return l.Add(-r), nil
+			}
+		}
+	case time.Duration:
+		switch r := right.(type) {
+		case time.Time:
+			if operator.Kind == lexer.PLUS {
+				return r.Add(l), nil
+			}
+		case time.Duration:
+			switch operator.Kind {
+			case lexer.PLUS:
+				return l + r, nil
+			case lexer.DASH:
+				return l - r, nil
+			case lexer.LESS:
+				return l < r, nil
+			case lexer.LESS_EQUALS:
+				return l <= r, nil
+			case lexer.GREATER:
+				return l > r, nil
+			case lexer.GREATER_EQUALS:
+				return l >= r, nil
+			}
+		case int64, float64:
+			if operator.Kind == lexer.STAR {
+				n, _ := toFloat(r)
+				return time.Duration(float64(l) * n), nil
+			}
+		}
+	case int64, float64:
+		if r, ok := right.(time.Duration); ok && operator.Kind == lexer.STAR {
+			n, _ := toFloat(l)
+			return time.Duration(n * float64(r)), nil
+		}
+	}
+
+	return nil, invalid
+}
@@ -0,0 +1,137 @@
+// Package langtest holds what the tests of the IR and of the code
+// generators share: the programs they translate, golden files, and the
+// output of the interpreter their translations must match.
+package langtest
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/RyanOliveira00/go-compiler/src/compiler"
+	"github.com/RyanOliveira00/go-compiler/src/ir"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// Program is a program of testdata, with the input it reads.
+type Program struct {
+	Name  string
+	Path  string
+	Input string
+}
+
+// Programs lists the programs in the testdata directory dir, each read
+// from name.lang with its input in name.in when there is one. An empty
+// dir stands for the programs of the IR, which every code generator
+// translating it runs.
+func Programs(t *testing.T, dir string) []Program {
+	t.Helper()
+	if dir == "" {
+		_, file, _, _ := runtime.Caller(0)
+		dir = filepath.Join(filepath.Dir(file), "..", "..", "ir", "testdata")
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.lang"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatalf("no programs in %s", dir)
+	}
+
+	programs := make([]Program, 0, len(paths))
+	for _, path := range paths {
+		base := strings.TrimSuffix(path, ".lang")
+		input, err := os.ReadFile(base + ".in")
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		programs = append(programs, Program{Name: filepath.Base(base), Path: path, Input: string(input)})
+	}
+	return programs
+}
+
+// Interpret runs p with the interpreter and returns what it prints.
+func Interpret(t *testing.T, p Program) string {
+	t.Helper()
+	var stdout bytes.Buffer
+	r := compiler.NewRuntime(compiler.Options{
+		Stdout:       &stdout,
+		Stdin:        strings.NewReader(p.Input),
+		Capabilities: compiler.TrustedCapabilities,
+	})
+	if _, err := r.RunFile(context.Background(), p.Path); err != nil {
+		t.Fatalf("%s: %s", p.Name, err)
+	}
+	return stdout.String()
+}
+
+// Lower checks p and lowers it to IR optimized by passes, as the build
+// command does.
+func Lower(t *testing.T, p Program, passes *ir.PassManager) *ir.Module {
+	t.Helper()
+	program, err := compiler.CheckFile(p.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	module, err := ir.Lower(program)
+	if err != nil {
+		t.Fatalf("%s: %s", p.Name, err)
+	}
+	if err := ir.Verify(module); err != nil {
+		t.Fatalf("%s: lowered: %s", p.Name, err)
+	}
+	if err := passes.Run(module); err != nil {
+		t.Fatalf("%s: %s", p.Name, err)
+	}
+	return module
+}
+
+// Golden compares got with the file at path, which go test -update
+// rewrites instead.
+func Golden(t *testing.T, path, got string) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs, run go test -update to accept it:\n%s", path, Diff(string(want), got))
+	}
+}
+
+// Diff shows the first lines in which want and got differ.
+func Diff(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	var out strings.Builder
+	shown := 0
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w == g {
+			continue
+		}
+		out.WriteString("line " + strconv.Itoa(i+1) + ":\n-" + w + "\n+" + g + "\n")
+		if shown++; shown == 5 {
+			break
+		}
+	}
+	return out.String()
+}
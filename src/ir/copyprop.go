@@ -0,0 +1,228 @@
+package ir
+
+import "math"
+
+// copyProp propagates copies and constants through the function: uses of
+// a copy, or of a phi merging a single value, use that value instead,
+// operations on constants become constants, and branches on a constant
+// only keep the path they take, which may leave more phis and operations
+// to simplify. SSA values being visible in every block they dominate,
+// this carries constants across blocks.
+func copyProp(f *Func) {
+	for changed := true; changed; {
+		changed = false
+		removed := make([]bool, f.NumValues())
+
+		for _, b := range f.Blocks {
+			for _, v := range b.Values {
+				if removed[v.ID] {
+					continue
+				}
+				if same := copyOf(v); same != nil {
+					f.replaceUses(v, same)
+					removed[v.ID] = true
+					changed = true
+					continue
+				}
+				if fold(v) {
+					changed = true
+				}
+			}
+		}
+		for _, b := range f.Blocks {
+			b.removeValues(removed)
+		}
+
+		for _, b := range f.Blocks {
+			if b.Kind == BlockIf && b.Control.Op == OpConst {
+				taken, skipped := b.Succs[0], b.Succs[1]
+				if b.Control.AuxInt == 0 {
+					taken, skipped = skipped, taken
+				}
+				skipped.removePred(skipped.PredIndex(b))
+				b.Kind, b.Control, b.Succs = BlockPlain, nil, []*Block{taken}
+				changed = true
+			}
+		}
+		f.removeUnreachable()
+	}
+}
+
+// copyOf returns the value v is a copy of, or nil.
+func copyOf(v *Value) *Value {
+	switch v.Op {
+	case OpCopy:
+		return v.Args[0]
+	case OpPhi:
+		var same *Value
+		for _, arg := range v.Args {
+			if arg == v || arg == same {
+				continue
+			}
+			if same != nil {
+				return nil
+			}
+			same = arg
+		}
+		return same
+	}
+	return nil
+}
+
+// fold turns v into a constant when its arguments are constants, with
+// the result the interpreter would compute. Divisions by zero are left to
+// fail at run time.
+func fold(v *Value) bool {
+	if v.Op == OpConst || v.Op == OpPhi || !pure(v) && v.Op != OpDiv && v.Op != OpMod {
+		return false
+	}
+	for _, arg := range v.Args {
+		if arg.Op != OpConst {
+			return false
+		}
+	}
+
+	var result Value
+	switch len(v.Args) {
+	case 1:
+		if !foldUnary(v.Op, v.Args[0], &result) {
+			return false
+		}
+	case 2:
+		if !foldBinary(v.Op, v.Args[0], v.Args[1], &result) {
+			return false
+		}
+	default:
+		return false
+	}
+
+	v.Op, v.Args = OpConst, nil
+	v.AuxInt, v.AuxFloat, v.AuxString = result.AuxInt, result.AuxFloat, result.AuxString
+	return true
+}
+
+func foldUnary(op Op, x *Value, result *Value) bool {
+	switch {
+	case op == OpNeg && x.Type == Int:
+		result.AuxInt = -x.AuxInt
+	case op == OpNeg && x.Type == Float:
+		result.AuxFloat = -x.AuxFloat
+	case op == OpNot:
+		result.AuxInt = 1 - x.AuxInt
+	case op == OpIntToFloat:
+		result.AuxFloat = float64(x.AuxInt)
+	default:
+		return false
+	}
+	return true
+}
+
+func foldBinary(op Op, x, y *Value, result *Value) bool {
+	switch op {
+	case OpConcat:
+		result.AuxString = x.AuxString + y.AuxString
+		return true
+	case OpEq, OpNe, OpLt, OpLe, OpGt, OpGe:
+		c := compareConsts(x, y)
+		var holds bool
+		switch op {
+		case OpEq:
+			holds = c == 0
+		case OpNe:
+			holds = c != 0
+		case OpLt:
+			holds = c < 0
+		case OpLe:
+			holds = c <= 0
+		case OpGt:
+			holds = c > 0
+		case OpGe:
+			holds = c >= 0
+		}
+		// NaN is neither smaller, equal nor greater than anything
+		if x.Type == Float && (math.IsNaN(x.AuxFloat) || math.IsNaN(y.AuxFloat)) {
+			holds = op == OpNe
+		}
+		if holds {
+			result.AuxInt = 1
+		}
+		return true
+	}
+
+	if x.Type == Int {
+		a, b := x.AuxInt, y.AuxInt
+		switch op {
+		case OpAdd:
+			result.AuxInt = a + b
+		case OpSub:
+			result.AuxInt = a - b
+		case OpMul:
+			result.AuxInt = a * b
+		case OpDiv:
+			if b == 0 {
+				return false
+			}
+			result.AuxInt = a / b
+		case OpMod:
+			if b == 0 {
+				return false
+			}
+			result.AuxInt = a % b
+		default:
+			return false
+		}
+		return true
+	}
+
+	a, b := x.AuxFloat, y.AuxFloat
+	switch op {
+	case OpAdd:
+		result.AuxFloat = a + b
+	case OpSub:
+		result.AuxFloat = a - b
+	case OpMul:
+		result.AuxFloat = a * b
+	case OpDiv:
+		if b == 0 {
+			return false
+		}
+		result.AuxFloat = a / b
+	case OpMod:
+		if b == 0 {
+			return false
+		}
+		result.AuxFloat = math.Mod(a, b)
+	default:
+		return false
+	}
+	return true
+}
+
+// compareConsts orders two constants of the same type like the
+// interpreter, returning -1, 0 or 1.
+func compareConsts(x, y *Value) int {
+	switch x.Type {
+	case Float:
+		switch {
+		case x.AuxFloat < y.AuxFloat:
+			return -1
+		case x.AuxFloat > y.AuxFloat:
+			return 1
+		}
+	case String:
+		switch {
+		case x.AuxString < y.AuxString:
+			return -1
+		case x.AuxString > y.AuxString:
+			return 1
+		}
+	default:
+		switch {
+		case x.AuxInt < y.AuxInt:
+			return -1
+		case x.AuxInt > y.AuxInt:
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,96 @@
+package ir
+
+import "math"
+
+// cseKey identifies what a pure value computes.
+type cseKey struct {
+	op        Op
+	typ       Type
+	aux       string
+	auxInt    int64
+	auxFloat  uint64 // the bits, so 0.0 and -0.0 stay apart
+	auxString string
+	args      [2]int
+}
+
+// cse eliminates common subexpressions: a pure value computing the same
+// thing as one in a block dominating it is replaced by the latter. The
+// dominator tree is walked with the values available at each block.
+func cse(f *Func) {
+	dom := Dominators(f)
+	available := make(map[cseKey]*Value)
+	replacement := make([]*Value, f.NumValues())
+	resolve := func(v *Value) *Value {
+		if r := replacement[v.ID]; r != nil {
+			return r
+		}
+		return v
+	}
+
+	var visit func(*Block)
+	visit = func(b *Block) {
+		var added []cseKey
+		for _, v := range b.Values {
+			if !pure(v) || len(v.Args) > len(cseKey{}.args) {
+				continue
+			}
+			key := cseKey{
+				op:        v.Op,
+				typ:       v.Type,
+				aux:       v.Aux,
+				auxInt:    v.AuxInt,
+				auxFloat:  math.Float64bits(v.AuxFloat),
+				auxString: v.AuxString,
+				args:      [2]int{-1, -1},
+			}
+			for i, arg := range v.Args {
+				key.args[i] = resolve(arg).ID
+			}
+			if commutative(v.Op) && key.args[0] > key.args[1] {
+				key.args[0], key.args[1] = key.args[1], key.args[0]
+			}
+
+			if existing, exists := available[key]; exists {
+				replacement[v.ID] = existing
+				continue
+			}
+			available[key] = v
+			added = append(added, key)
+		}
+
+		for _, child := range dom.Children(b) {
+			visit(child)
+		}
+		for _, key := range added {
+			delete(available, key)
+		}
+	}
+	visit(f.Entry())
+
+	for _, b := range f.Blocks {
+		for _, v := range b.Values {
+			for i, arg := range v.Args {
+				v.Args[i] = resolve(arg)
+			}
+		}
+		if b.Control != nil {
+			b.Control = resolve(b.Control)
+		}
+	}
+	removed := make([]bool, f.NumValues())
+	for i, r := range replacement {
+		removed[i] = r != nil
+	}
+	for _, b := range f.Blocks {
+		b.removeValues(removed)
+	}
+}
+
+func commutative(op Op) bool {
+	switch op {
+	case OpAdd, OpMul, OpEq, OpNe:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,75 @@
+package ir
+
+// deadCode removes unreachable blocks and the values whose result nothing
+// needs: a value is live when it has side effects, decides how a block
+// ends, or is an argument of a live value.
+func deadCode(f *Func) {
+	f.removeUnreachable()
+
+	live := make([]bool, f.NumValues())
+	var work []*Value
+	mark := func(v *Value) {
+		if !live[v.ID] {
+			live[v.ID] = true
+			work = append(work, v)
+		}
+	}
+	for _, param := range f.Params {
+		mark(param)
+	}
+	for _, b := range f.Blocks {
+		for _, v := range b.Values {
+			if v.Op.HasSideEffects() {
+				mark(v)
+			}
+		}
+		if b.Control != nil {
+			mark(b.Control)
+		}
+	}
+	for len(work) > 0 {
+		v := work[len(work)-1]
+		work = work[:len(work)-1]
+		for _, arg := range v.Args {
+			mark(arg)
+		}
+	}
+
+	dead := make([]bool, f.NumValues())
+	for _, b := range f.Blocks {
+		for _, v := range b.Values {
+			dead[v.ID] = !live[v.ID]
+		}
+		b.removeValues(dead)
+	}
+}
+
+// deadStore removes stores to a global that are overwritten before
+// anything can read them: by a later store in the same block with no load
+// of the global or call in between. Stores left pending when the main
+// function returns are dead as well, as the program ends with it.
+func deadStore(f *Func) {
+	dead := make([]bool, f.NumValues())
+	for _, b := range f.Blocks {
+		pending := make(map[string]*Value)
+		for _, v := range b.Values {
+			switch v.Op {
+			case OpStore:
+				if previous, exists := pending[v.Aux]; exists {
+					dead[previous.ID] = true
+				}
+				pending[v.Aux] = v
+			case OpLoad:
+				delete(pending, v.Aux)
+			case OpCall:
+				pending = make(map[string]*Value)
+			}
+		}
+		if f.Name == MainName && b.Kind == BlockReturn {
+			for _, store := range pending {
+				dead[store.ID] = true
+			}
+		}
+		b.removeValues(dead)
+	}
+}
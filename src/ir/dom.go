@@ -0,0 +1,140 @@
+package ir
+
+// DomTree is the dominator tree of a function: a block dominates another
+// when every path from the entry to the latter goes through it. It is
+// computed with the iterative algorithm of Cooper, Harvey and Kennedy,
+// "A Simple, Fast Dominance Algorithm".
+type DomTree struct {
+	idom     []*Block // by block ID, nil for the entry and unreachable blocks
+	children [][]*Block
+	order    []*Block // reverse postorder of the reachable blocks
+	rpo      []int    // position of each block in order, -1 if unreachable
+
+	// pre and post number the dominator tree's nodes in a depth-first
+	// walk, so dominance is an interval check
+	pre, post []int
+}
+
+func Dominators(f *Func) *DomTree {
+	n := f.NumBlocks()
+	d := &DomTree{
+		idom:     make([]*Block, n),
+		children: make([][]*Block, n),
+		rpo:      make([]int, n),
+		pre:      make([]int, n),
+		post:     make([]int, n),
+	}
+	for i := range d.rpo {
+		d.rpo[i] = -1
+	}
+
+	d.order = ReversePostorder(f)
+	for i, b := range d.order {
+		d.rpo[b.ID] = i
+	}
+
+	entry := f.Entry()
+	d.idom[entry.ID] = entry
+	for changed := true; changed; {
+		changed = false
+		for _, b := range d.order[1:] {
+			var idom *Block
+			for _, pred := range b.Preds {
+				if d.rpo[pred.ID] < 0 || d.idom[pred.ID] == nil {
+					continue // unreachable or not processed yet
+				}
+				if idom == nil {
+					idom = pred
+				} else {
+					idom = d.intersect(pred, idom)
+				}
+			}
+			if d.idom[b.ID] != idom {
+				d.idom[b.ID] = idom
+				changed = true
+			}
+		}
+	}
+	d.idom[entry.ID] = nil
+
+	for _, b := range d.order[1:] {
+		parent := d.idom[b.ID]
+		d.children[parent.ID] = append(d.children[parent.ID], b)
+	}
+	d.number(entry, 0)
+	return d
+}
+
+func (d *DomTree) intersect(a, b *Block) *Block {
+	for a != b {
+		for d.rpo[a.ID] > d.rpo[b.ID] {
+			a = d.idom[a.ID]
+		}
+		for d.rpo[b.ID] > d.rpo[a.ID] {
+			b = d.idom[b.ID]
+		}
+	}
+	return a
+}
+
+func (d *DomTree) number(b *Block, next int) int {
+	d.pre[b.ID] = next
+	next++
+	for _, child := range d.children[b.ID] {
+		next = d.number(child, next)
+	}
+	d.post[b.ID] = next
+	return next
+}
+
+// Idom is the immediate dominator of b, nil for the entry.
+func (d *DomTree) Idom(b *Block) *Block {
+	return d.idom[b.ID]
+}
+
+// Children are the blocks b immediately dominates.
+func (d *DomTree) Children(b *Block) []*Block {
+	return d.children[b.ID]
+}
+
+// Reachable reports whether b can run at all.
+func (d *DomTree) Reachable(b *Block) bool {
+	return d.rpo[b.ID] >= 0
+}
+
+// Dominates reports whether a dominates b. Every block dominates itself.
+func (d *DomTree) Dominates(a, b *Block) bool {
+	if !d.Reachable(a) || !d.Reachable(b) {
+		return false
+	}
+	return d.pre[a.ID] <= d.pre[b.ID] && d.post[b.ID] <= d.post[a.ID]
+}
+
+// Order returns the reachable blocks in reverse postorder, where a block
+// comes before the blocks it dominates.
+func (d *DomTree) Order() []*Block {
+	return d.order
+}
+
+// ReversePostorder lists the blocks reachable from the entry of f so that,
+// loops aside, every block comes after its predecessors.
+func ReversePostorder(f *Func) []*Block {
+	seen := make([]bool, f.NumBlocks())
+	var post []*Block
+	var visit func(*Block)
+	visit = func(b *Block) {
+		seen[b.ID] = true
+		for _, succ := range b.Succs {
+			if !seen[succ.ID] {
+				visit(succ)
+			}
+		}
+		post = append(post, b)
+	}
+	visit(f.Entry())
+
+	for i, j := 0, len(post)-1; i < j; i, j = i+1, j-1 {
+		post[i], post[j] = post[j], post[i]
+	}
+	return post
+}
@@ -0,0 +1,93 @@
+package ir
+
+// Inline counts the uses of each value of f by ID, as an argument or as
+// the control of a block, and reports which values a code generator may
+// compute where they are used instead of where they are: those used once
+// that can be computed later without changing the result, and whose use
+// comes before any phi of their block is assigned.
+func Inline(f *Func) (uses []int, inline []bool) {
+	uses = make([]int, f.NumValues())
+	inline = make([]bool, f.NumValues())
+
+	users := make([]*Value, f.NumValues())
+	for _, b := range f.Blocks {
+		for _, v := range b.Values {
+			for _, arg := range v.Args {
+				uses[arg.ID]++
+				users[arg.ID] = v
+			}
+		}
+		if b.Control != nil {
+			uses[b.Control.ID]++
+		}
+	}
+	for _, b := range f.Blocks {
+		for _, v := range b.Values {
+			inline[v.ID] = uses[v.ID] == 1 && inlinable(v) && usedInPlace(v, users[v.ID])
+		}
+	}
+	return uses, inline
+}
+
+// inlinable reports whether v can be computed later than where it is
+// without changing the result, which holds for operations on SSA values
+// that cannot fail.
+func inlinable(v *Value) bool {
+	switch v.Op {
+	case OpConst, OpPhi, OpParam, OpLoad:
+		return false
+	}
+	return v.Type != Void && !v.Op.HasSideEffects()
+}
+
+// usedInPlace reports whether the only use of v, by user or by a block
+// control when user is nil, comes before any phi of its block is
+// assigned: in its own block, or as the only phi assigned on an edge
+// leaving it, as code generators may assign the phis of an edge one
+// after the other.
+func usedInPlace(v, user *Value) bool {
+	b := v.Block
+	switch {
+	case user == nil:
+		return b.Control == v
+	case user.Op != OpPhi:
+		return user.Block == b
+	}
+	i := user.Block.PredIndex(b)
+	return i >= 0 && user.Args[i] == v && len(PhiMoves(b, user.Block)) == 1
+}
+
+// PhiMoves lists the phis of to that the edge from from assigns, leaving
+// out the ones keeping their value.
+func PhiMoves(from, to *Block) []*Value {
+	i := to.PredIndex(from)
+	var phis []*Value
+	for _, v := range to.Values {
+		if v.Op == OpPhi && v.Args[i] != v {
+			phis = append(phis, v)
+		}
+	}
+	return phis
+}
+
+var negated = map[Op]Op{
+	OpEq: OpNe,
+	OpNe: OpEq,
+	OpLt: OpGe,
+	OpLe: OpGt,
+	OpGt: OpLe,
+	OpGe: OpLt,
+}
+
+// Negation returns a copy of the comparison v computing its negation,
+// outside of any block. Float comparisons have none, as a comparison with
+// NaN is false either way.
+func Negation(v *Value) (*Value, bool) {
+	op, exists := negated[v.Op]
+	if !exists || v.Args[0].Type == Float {
+		return nil, false
+	}
+	flipped := *v
+	flipped.Op = op
+	return &flipped, true
+}
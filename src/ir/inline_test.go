@@ -0,0 +1,69 @@
+package ir
+
+import "testing"
+
+// loop builds a function adding a and b until a is not below b, where b
+// takes the sum on every iteration and a takes the old b, or keeps its
+// value when swap is false.
+func loop(swap bool) (f *Func, sum, cond *Value) {
+	f = NewFunc("loop", Int)
+	entry, header, body, exit := f.NewBlock(), f.NewBlock(), f.NewBlock(), f.NewBlock()
+
+	zero := entry.NewValue(OpConst, Int)
+	one := entry.NewValue(OpConst, Int)
+	one.AuxInt = 1
+	entry.AddEdge(header)
+
+	a := header.NewValue(OpPhi, Int)
+	b := header.NewValue(OpPhi, Int)
+	cond = header.NewValue(OpLt, Bool, a, b)
+	header.Kind, header.Control = BlockIf, cond
+	header.AddEdge(body)
+	header.AddEdge(exit)
+
+	sum = body.NewValue(OpAdd, Int, a, b)
+	body.AddEdge(header)
+
+	a.Args = []*Value{zero, a}
+	if swap {
+		a.Args[1] = b
+	}
+	b.Args = []*Value{one, sum}
+	exit.Kind, exit.Control = BlockReturn, a
+	return f, sum, cond
+}
+
+func TestInline(t *testing.T) {
+	f, sum, cond := loop(false)
+	uses, inline := Inline(f)
+	if uses[sum.ID] != 1 || !inline[sum.ID] {
+		t.Errorf("sum, the only phi set on its edge, is not inlined (%d uses)", uses[sum.ID])
+	}
+	if !inline[cond.ID] {
+		t.Error("the condition of a branch is not inlined")
+	}
+
+	// Once a takes b on the same edge, b may be assigned before sum reads
+	// it
+	f, sum, _ = loop(true)
+	if _, inline := Inline(f); inline[sum.ID] {
+		t.Error("sum is inlined on an edge setting two phis")
+	}
+}
+
+func TestNegation(t *testing.T) {
+	f := NewFunc("f", Bool)
+	b := f.NewBlock()
+	x, y := b.NewValue(OpParam, Int), b.NewValue(OpParam, Float)
+
+	lt := b.NewValue(OpLt, Bool, x, x)
+	if flipped, ok := Negation(lt); !ok || flipped.Op != OpGe || lt.Op != OpLt {
+		t.Errorf("negation of %s: %v, %t", lt.LongString(), flipped, ok)
+	}
+	if _, ok := Negation(b.NewValue(OpLt, Bool, y, y)); ok {
+		t.Error("a float comparison is flipped")
+	}
+	if _, ok := Negation(b.NewValue(OpAdd, Int, x, x)); ok {
+		t.Error("an addition has a negation")
+	}
+}
@@ -0,0 +1,403 @@
+// Package ir is the SSA form shared by the optimizations and the code
+// generators. A Module holds functions made of basic blocks; each block
+// computes Values and ends in a jump, a two way branch or a return. Every
+// Value is assigned once, and a phi at the start of a block picks the
+// value coming from the predecessor control arrived from.
+//
+// Lower builds a Module from a checked program. It handles programs over
+// int, float, bool and string values: variables, functions, if, while,
+// foreach over ranges, print, println and read.
+package ir
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Type is the type of a Value.
+type Type int
+
+const (
+	Void Type = iota
+	Int
+	Float
+	Bool
+	String
+)
+
+var typeNames = map[Type]string{
+	Void:   "void",
+	Int:    "int",
+	Float:  "float",
+	Bool:   "bool",
+	String: "string",
+}
+
+func (t Type) String() string {
+	if name, exists := typeNames[t]; exists {
+		return name
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
+// Op is the operation computing a Value.
+type Op int
+
+const (
+	OpInvalid Op = iota
+
+	OpConst // AuxInt for ints and bools, AuxFloat or AuxString otherwise
+	OpParam // AuxInt is the parameter's index
+	OpPhi   // one argument per predecessor of the block, in order
+	OpCopy
+
+	// Arithmetic on two ints or two floats. Div and Mod fail at run time
+	// when the divisor is zero; int division truncates.
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+	OpNeg
+	OpConcat
+	OpIntToFloat
+
+	// Comparisons of two values of the same type, resulting in a bool
+	OpEq
+	OpNe
+	OpLt
+	OpLe
+	OpGt
+	OpGe
+	OpNot
+
+	OpCall  // Aux is the name of the called function
+	OpLoad  // Aux is the name of the global
+	OpStore // Aux is the name of the global, the argument its new value
+
+	// OpPrint writes its arguments separated by spaces and a newline.
+	// OpRead reads a word from the input as a value of its type and fails
+	// at run time when it is not one.
+	OpPrint
+	OpRead
+)
+
+var opNames = map[Op]string{
+	OpConst:      "const",
+	OpParam:      "param",
+	OpPhi:        "phi",
+	OpCopy:       "copy",
+	OpAdd:        "add",
+	OpSub:        "sub",
+	OpMul:        "mul",
+	OpDiv:        "div",
+	OpMod:        "mod",
+	OpNeg:        "neg",
+	OpConcat:     "concat",
+	OpIntToFloat: "itof",
+	OpEq:         "eq",
+	OpNe:         "ne",
+	OpLt:         "lt",
+	OpLe:         "le",
+	OpGt:         "gt",
+	OpGe:         "ge",
+	OpNot:        "not",
+	OpCall:       "call",
+	OpLoad:       "load",
+	OpStore:      "store",
+	OpPrint:      "print",
+	OpRead:       "read",
+}
+
+func (op Op) String() string {
+	if name, exists := opNames[op]; exists {
+		return name
+	}
+	return fmt.Sprintf("Op(%d)", int(op))
+}
+
+// HasSideEffects reports whether values of op must be kept even when
+// their result is unused.
+func (op Op) HasSideEffects() bool {
+	switch op {
+	case OpCall, OpStore, OpPrint, OpRead, OpDiv, OpMod:
+		return true
+	default:
+		return false
+	}
+}
+
+// Value is the result of one operation. Values without a result, such as
+// a store, have type Void.
+type Value struct {
+	ID    int
+	Op    Op
+	Type  Type
+	Args  []*Value
+	Block *Block
+
+	Aux       string
+	AuxInt    int64
+	AuxFloat  float64
+	AuxString string
+}
+
+func (v *Value) String() string {
+	return "v" + strconv.Itoa(v.ID)
+}
+
+// LongString prints v as it appears in the textual dump.
+func (v *Value) LongString() string {
+	var b strings.Builder
+	if v.Type != Void {
+		fmt.Fprintf(&b, "%s: %s = ", v, v.Type)
+	}
+	b.WriteString(v.Op.String())
+
+	switch v.Op {
+	case OpConst:
+		b.WriteString(" " + v.constString())
+	case OpParam:
+		fmt.Fprintf(&b, " %d", v.AuxInt)
+	case OpPhi:
+		for i, arg := range v.Args {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, " [%s: %s]", v.Block.Preds[i], arg)
+		}
+		return b.String()
+	case OpCall:
+		b.WriteString(" " + v.Aux + "(" + valueList(v.Args) + ")")
+		return b.String()
+	case OpLoad, OpStore:
+		b.WriteString(" @" + v.Aux)
+		if len(v.Args) > 0 {
+			b.WriteString(",")
+		}
+	}
+	if len(v.Args) > 0 {
+		b.WriteString(" " + valueList(v.Args))
+	}
+	return b.String()
+}
+
+func (v *Value) constString() string {
+	switch v.Type {
+	case Float:
+		text := strconv.FormatFloat(v.AuxFloat, 'g', -1, 64)
+		if !strings.ContainsAny(text, ".eEn") {
+			text += ".0"
+		}
+		return text
+	case String:
+		return strconv.Quote(v.AuxString)
+	case Bool:
+		return strconv.FormatBool(v.AuxInt != 0)
+	default:
+		return strconv.FormatInt(v.AuxInt, 10)
+	}
+}
+
+func valueList(values []*Value) string {
+	names := make([]string, 0, len(values))
+	for _, value := range values {
+		names = append(names, value.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// BlockKind says how a block ends.
+type BlockKind int
+
+const (
+	// BlockPlain jumps to its only successor
+	BlockPlain BlockKind = iota
+	// BlockIf goes to Succs[0] when Control is true, Succs[1] otherwise
+	BlockIf
+	// BlockReturn leaves the function with Control, nil in void functions
+	BlockReturn
+)
+
+type Block struct {
+	ID      int
+	Kind    BlockKind
+	Values  []*Value
+	Control *Value
+	Succs   []*Block
+	Preds   []*Block
+	Func    *Func
+}
+
+func (b *Block) String() string {
+	return "b" + strconv.Itoa(b.ID)
+}
+
+// NewValue appends a value computing op over args to b.
+func (b *Block) NewValue(op Op, t Type, args ...*Value) *Value {
+	v := b.Func.newValue(op, t, args)
+	v.Block = b
+	b.Values = append(b.Values, v)
+	return v
+}
+
+// AddEdge makes to a successor of b.
+func (b *Block) AddEdge(to *Block) {
+	b.Succs = append(b.Succs, to)
+	to.Preds = append(to.Preds, b)
+}
+
+// PredIndex is the position of pred among the predecessors of b, which
+// is also the position of its argument in b's phis, or -1.
+func (b *Block) PredIndex(pred *Block) int {
+	for i, p := range b.Preds {
+		if p == pred {
+			return i
+		}
+	}
+	return -1
+}
+
+type Func struct {
+	Name   string
+	Params []*Value // the OpParam values of the entry block
+	Return Type
+	Blocks []*Block // Blocks[0] is the entry
+
+	nextValue int
+	nextBlock int
+}
+
+func NewFunc(name string, ret Type) *Func {
+	return &Func{Name: name, Return: ret}
+}
+
+func (f *Func) Entry() *Block {
+	return f.Blocks[0]
+}
+
+// NewBlock adds an empty block to f, the entry if it is the first one.
+func (f *Func) NewBlock() *Block {
+	b := &Block{ID: f.nextBlock, Func: f}
+	f.nextBlock++
+	f.Blocks = append(f.Blocks, b)
+	return b
+}
+
+func (f *Func) newValue(op Op, t Type, args []*Value) *Value {
+	v := &Value{ID: f.nextValue, Op: op, Type: t, Args: args}
+	f.nextValue++
+	return v
+}
+
+// NumValues bounds the IDs of the values of f, for tables indexed by ID.
+func (f *Func) NumValues() int {
+	return f.nextValue
+}
+
+// NumBlocks bounds the IDs of the blocks of f.
+func (f *Func) NumBlocks() int {
+	return f.nextBlock
+}
+
+func (f *Func) String() string {
+	var b strings.Builder
+	params := make([]string, 0, len(f.Params))
+	for _, param := range f.Params {
+		params = append(params, param.String()+": "+param.Type.String())
+	}
+	fmt.Fprintf(&b, "func %s(%s)", f.Name, strings.Join(params, ", "))
+	if f.Return != Void {
+		b.WriteString(": " + f.Return.String())
+	}
+	b.WriteString(" {\n")
+
+	for _, block := range f.Blocks {
+		b.WriteString(block.String() + ":")
+		if len(block.Preds) > 0 {
+			b.WriteString(" <- " + blockList(block.Preds))
+		}
+		b.WriteString("\n")
+		for _, v := range block.Values {
+			b.WriteString("    " + v.LongString() + "\n")
+		}
+		b.WriteString("    " + block.terminator() + "\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (b *Block) terminator() string {
+	switch b.Kind {
+	case BlockPlain:
+		if len(b.Succs) == 1 {
+			return "jump " + b.Succs[0].String()
+		}
+	case BlockIf:
+		if len(b.Succs) == 2 && b.Control != nil {
+			return fmt.Sprintf("if %s then %s else %s", b.Control, b.Succs[0], b.Succs[1])
+		}
+	case BlockReturn:
+		if b.Control == nil {
+			return "return"
+		}
+		return "return " + b.Control.String()
+	}
+	return fmt.Sprintf("invalid terminator (kind %d, %d successors)", b.Kind, len(b.Succs))
+}
+
+func blockList(blocks []*Block) string {
+	names := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		names = append(names, block.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// Global is a top-level variable used by functions, kept in memory and
+// accessed with OpLoad and OpStore. It starts out as the zero value of
+// its type.
+type Global struct {
+	Name string
+	Type Type
+}
+
+// MainName names the function running the top-level statements, a name
+// no function of the program can have.
+const MainName = "<main>"
+
+type Module struct {
+	Globals []Global
+	Funcs   []*Func // the functions declared by the program
+	Main    *Func
+}
+
+// AllFuncs returns the declared functions followed by Main.
+func (m *Module) AllFuncs() []*Func {
+	return append(append([]*Func{}, m.Funcs...), m.Main)
+}
+
+// Func returns the function named name, or nil.
+func (m *Module) Func(name string) *Func {
+	for _, f := range m.Funcs {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func (m *Module) String() string {
+	var b strings.Builder
+	for _, global := range m.Globals {
+		fmt.Fprintf(&b, "global @%s: %s\n", global.Name, global.Type)
+	}
+	for i, f := range m.AllFuncs() {
+		if i > 0 || len(m.Globals) > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(f.String())
+	}
+	return b.String()
+}
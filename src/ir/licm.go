@@ -0,0 +1,86 @@
+package ir
+
+// licm hoists loop-invariant code: pure values of a loop whose arguments
+// are all computed outside of it move to the block entering the loop, so
+// they are computed once instead of on every iteration. The loops of while
+// and foreach statements are entered from a single block jumping to their
+// header; loops entered otherwise are left alone.
+//
+// Only pure values move, which cannot fail, so computing one the loop
+// would not have reached is harmless.
+func licm(f *Func) {
+	dom := Dominators(f)
+	for _, header := range dom.Order() {
+		body := loopBody(dom, header)
+		if body == nil {
+			continue
+		}
+
+		var entries []*Block
+		for _, pred := range header.Preds {
+			if !body[pred] {
+				entries = append(entries, pred)
+			}
+		}
+		if len(entries) != 1 || entries[0].Kind != BlockPlain {
+			continue
+		}
+		preheader := entries[0]
+
+		// Blocks are visited in reverse postorder, so the arguments of a
+		// value are hoisted before it
+		for _, b := range dom.Order() {
+			if !body[b] {
+				continue
+			}
+			kept := b.Values[:0]
+			for _, v := range b.Values {
+				if invariant(v, body) {
+					v.Block = preheader
+					preheader.Values = append(preheader.Values, v)
+					continue
+				}
+				kept = append(kept, v)
+			}
+			b.Values = kept
+		}
+	}
+}
+
+// loopBody returns the blocks of the natural loop of header, nil when no
+// back edge leads to it. A back edge comes from a block header dominates.
+func loopBody(dom *DomTree, header *Block) map[*Block]bool {
+	var work []*Block
+	for _, pred := range header.Preds {
+		if dom.Dominates(header, pred) {
+			work = append(work, pred)
+		}
+	}
+	if len(work) == 0 {
+		return nil
+	}
+
+	body := map[*Block]bool{header: true}
+	for len(work) > 0 {
+		b := work[len(work)-1]
+		work = work[:len(work)-1]
+		if body[b] {
+			continue
+		}
+		body[b] = true
+		work = append(work, b.Preds...)
+	}
+	return body
+}
+
+func invariant(v *Value, body map[*Block]bool) bool {
+	if !pure(v) {
+		return false
+	}
+	for _, arg := range v.Args {
+		if body[arg.Block] {
+			return false
+		}
+	}
+	return true
+}
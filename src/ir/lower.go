@@ -0,0 +1,927 @@
+package ir
+
+import (
+	"fmt"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+// Lower translates a program that passed the type checker, optionally
+// optimized, into SSA form. Programs using anything outside the subset
+// described in the package documentation are rejected with an error
+// naming the construct.
+func Lower(program ast.BlockStmt) (module *Module, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			lowerErr, ok := r.(lowerError)
+			if !ok {
+				panic(r)
+			}
+			err = lowerErr.err
+		}
+	}()
+
+	l := &lowerer{
+		module:    &Module{},
+		functions: make(map[string]*signature),
+		globals:   make(map[string]bool),
+	}
+	l.lowerModule(program.Body)
+	return l.module, nil
+}
+
+// lowerError carries an error out of the recursive lowering.
+type lowerError struct {
+	err error
+}
+
+func unsupported(format string, args ...interface{}) {
+	panic(lowerError{fmt.Errorf(format+" is not supported by the IR", args...)})
+}
+
+type signature struct {
+	decl   ast.FunctionDeclStmt
+	params []Type
+	ret    Type
+	fn     *Func
+}
+
+// variable is a source variable, whose SSA values are tracked per block.
+// Globals live in memory instead.
+type variable struct {
+	name   string
+	typ    Type
+	global bool
+}
+
+type scope struct {
+	vars  map[string]*variable
+	outer *scope
+}
+
+func (s *scope) lookup(name string) (*variable, bool) {
+	for ; s != nil; s = s.outer {
+		if v, exists := s.vars[name]; exists {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+type lowerer struct {
+	module    *Module
+	functions map[string]*signature
+	// globals are the top-level variables used by some function
+	globals map[string]bool
+
+	fn    *Func
+	block *Block // nil after a return, until code is reachable again
+	scope *scope
+	top   *scope // the scope of the top-level statements
+
+	// SSA construction, following Braun et al., "Simple and Efficient
+	// Construction of Static Single Assignment Form"
+	defs       map[*variable]map[*Block]*Value
+	sealed     map[*Block]bool
+	incomplete map[*Block]map[*variable]*Value
+}
+
+func (l *lowerer) lowerModule(body []ast.Stmt) {
+	var decls []ast.FunctionDeclStmt
+	for _, stmt := range body {
+		switch s := unwrapExport(stmt).(type) {
+		case ast.FunctionDeclStmt:
+			decls = append(decls, s)
+		case ast.ImportStmt:
+			unsupported("import")
+		}
+	}
+
+	for _, decl := range decls {
+		if len(decl.TypeParams) > 0 {
+			unsupported("generic function %s", decl.Name)
+		}
+		sig := &signature{decl: decl, ret: Void, fn: NewFunc(decl.Name, Void)}
+		for _, param := range decl.Parameters {
+			sig.params = append(sig.params, typeOf(param.Type, "parameter "+param.Name))
+		}
+		if decl.ReturnType != nil {
+			sig.ret = typeOf(decl.ReturnType, "result of "+decl.Name)
+			sig.fn.Return = sig.ret
+		}
+		l.functions[decl.Name] = sig
+		l.module.Funcs = append(l.module.Funcs, sig.fn)
+
+		for name := range freeNames(decl.Body.Body) {
+			l.globals[name] = true
+		}
+	}
+
+	// main goes first so the globals have their types when the functions
+	// using them are lowered
+	l.module.Main = NewFunc(MainName, Void)
+	l.begin(l.module.Main, nil)
+	l.top = l.scope
+	l.stmts(body)
+	l.finish()
+
+	for _, decl := range decls {
+		sig := l.functions[decl.Name]
+		l.begin(sig.fn, l.top)
+		for i, param := range decl.Parameters {
+			v := l.block.NewValue(OpParam, sig.params[i])
+			v.AuxInt = int64(i)
+			sig.fn.Params = append(sig.fn.Params, v)
+			l.declare(param.Name, sig.params[i], v)
+		}
+		l.stmts(decl.Body.Body)
+		l.finish()
+	}
+}
+
+// begin starts lowering fn, whose variables are looked up in outer after
+// its own.
+func (l *lowerer) begin(fn *Func, outer *scope) {
+	l.fn = fn
+	l.scope = &scope{vars: make(map[string]*variable), outer: outer}
+	l.defs = make(map[*variable]map[*Block]*Value)
+	l.sealed = make(map[*Block]bool)
+	l.incomplete = make(map[*Block]map[*variable]*Value)
+	l.block = fn.NewBlock()
+	l.seal(l.block)
+}
+
+// finish returns from the end of the function body when it is reachable.
+// The checker guarantees a function with a result returns before, so the
+// zero value only stands in on paths it proved unreachable.
+func (l *lowerer) finish() {
+	if l.block == nil {
+		return
+	}
+	var result *Value
+	if l.fn.Return != Void {
+		result = l.zero(l.fn.Return)
+	}
+	l.ret(result)
+}
+
+func (l *lowerer) nested(f func()) {
+	outer := l.scope
+	l.scope = &scope{vars: make(map[string]*variable), outer: outer}
+	defer func() { l.scope = outer }()
+	f()
+}
+
+func (l *lowerer) declare(name string, t Type, value *Value) {
+	v := &variable{name: name, typ: t}
+	if l.scope == l.top && l.globals[name] {
+		v.global = true
+		l.module.Globals = append(l.module.Globals, Global{Name: name, Type: t})
+	}
+	l.scope.vars[name] = v
+	l.assign(v, value)
+}
+
+func (l *lowerer) lookup(name string) *variable {
+	v, exists := l.scope.lookup(name)
+	if !exists {
+		if _, isFunc := l.functions[name]; isFunc {
+			unsupported("using function %s as a value", name)
+		}
+		unsupported("name %s", name)
+	}
+	return v
+}
+
+func (l *lowerer) assign(v *variable, value *Value) {
+	if v.global {
+		store := l.block.NewValue(OpStore, Void, value)
+		store.Aux = v.name
+		return
+	}
+	l.writeVariable(v, l.block, value)
+}
+
+func (l *lowerer) use(v *variable) *Value {
+	if v.global {
+		load := l.block.NewValue(OpLoad, v.typ)
+		load.Aux = v.name
+		return load
+	}
+	return l.readVariable(v, l.block)
+}
+
+func (l *lowerer) writeVariable(v *variable, b *Block, value *Value) {
+	if l.defs[v] == nil {
+		l.defs[v] = make(map[*Block]*Value)
+	}
+	l.defs[v][b] = value
+}
+
+func (l *lowerer) readVariable(v *variable, b *Block) *Value {
+	if value, exists := l.defs[v][b]; exists {
+		return value
+	}
+
+	var value *Value
+	switch {
+	case !l.sealed[b]:
+		// More predecessors may come, the phi is completed by seal
+		value = l.newPhi(b, v.typ)
+		if l.incomplete[b] == nil {
+			l.incomplete[b] = make(map[*variable]*Value)
+		}
+		l.incomplete[b][v] = value
+	case len(b.Preds) == 1:
+		value = l.readVariable(v, b.Preds[0])
+	default:
+		phi := l.newPhi(b, v.typ)
+		l.writeVariable(v, b, phi)
+		value = l.addPhiOperands(v, phi)
+	}
+	l.writeVariable(v, b, value)
+	return value
+}
+
+func (l *lowerer) addPhiOperands(v *variable, phi *Value) *Value {
+	for _, pred := range phi.Block.Preds {
+		phi.Args = append(phi.Args, l.readVariable(v, pred))
+	}
+	return l.removeTrivialPhi(phi)
+}
+
+// removeTrivialPhi replaces a phi whose operands are all the same value,
+// or the phi itself, with that value.
+func (l *lowerer) removeTrivialPhi(phi *Value) *Value {
+	var same *Value
+	for _, arg := range phi.Args {
+		if arg == same || arg == phi {
+			continue
+		}
+		if same != nil {
+			return phi
+		}
+		same = arg
+	}
+	if same == nil {
+		// Only reachable through itself, the variable is never set
+		same = l.zeroIn(phi.Block.Func.Entry(), phi.Type)
+	}
+
+	users := l.replace(phi, same)
+	for _, user := range users {
+		if user.Op == OpPhi && user != phi {
+			l.removeTrivialPhi(user)
+		}
+	}
+	return same
+}
+
+// replace substitutes with for every use of old, removing old from its
+// block, and returns the values that used it.
+func (l *lowerer) replace(old, with *Value) []*Value {
+	var users []*Value
+	for _, b := range l.fn.Blocks {
+		kept := b.Values[:0]
+		for _, v := range b.Values {
+			if v == old {
+				continue
+			}
+			for i, arg := range v.Args {
+				if arg == old {
+					v.Args[i] = with
+					users = append(users, v)
+				}
+			}
+			kept = append(kept, v)
+		}
+		b.Values = kept
+		if b.Control == old {
+			b.Control = with
+		}
+	}
+	for _, defs := range l.defs {
+		for b, value := range defs {
+			if value == old {
+				defs[b] = with
+			}
+		}
+	}
+	return users
+}
+
+func (l *lowerer) newPhi(b *Block, t Type) *Value {
+	phi := b.Func.newValue(OpPhi, t, nil)
+	phi.Block = b
+	b.Values = append([]*Value{phi}, b.Values...)
+	return phi
+}
+
+// seal records that all predecessors of b are known, completing the phis
+// created while they were not.
+func (l *lowerer) seal(b *Block) {
+	for v, phi := range l.incomplete[b] {
+		l.addPhiOperands(v, phi)
+	}
+	delete(l.incomplete, b)
+	l.sealed[b] = true
+}
+
+func (l *lowerer) jump(to *Block) {
+	l.block.Kind = BlockPlain
+	l.block.AddEdge(to)
+	l.block = nil
+}
+
+func (l *lowerer) branch(cond *Value, then, otherwise *Block) {
+	l.block.Kind = BlockIf
+	l.block.Control = cond
+	l.block.AddEdge(then)
+	l.block.AddEdge(otherwise)
+	l.block = nil
+}
+
+func (l *lowerer) ret(value *Value) {
+	l.block.Kind = BlockReturn
+	l.block.Control = value
+	l.block = nil
+}
+
+// drop removes a block nothing jumps to.
+func (l *lowerer) drop(b *Block) {
+	blocks := l.fn.Blocks[:0]
+	for _, block := range l.fn.Blocks {
+		if block != b {
+			blocks = append(blocks, block)
+		}
+	}
+	l.fn.Blocks = blocks
+}
+
+func (l *lowerer) stmts(stmts []ast.Stmt) {
+	for _, stmt := range stmts {
+		if l.block == nil {
+			return // the rest is unreachable
+		}
+		l.stmt(stmt)
+	}
+}
+
+func (l *lowerer) stmt(stmt ast.Stmt) {
+	switch s := stmt.(type) {
+	case ast.ExportStmt:
+		l.stmt(s.Declaration)
+	case ast.ExprStmt:
+		l.expr(s.Expression)
+	case ast.VarDeclStmt:
+		l.varDecl(s)
+	case ast.BlockStmt:
+		l.nested(func() { l.stmts(s.Body) })
+	case ast.IfStmt:
+		l.ifStmt(s)
+	case ast.WhileStmt:
+		l.whileStmt(s)
+	case ast.ForeachStmt:
+		l.foreachStmt(s)
+	case ast.PrintStmt:
+		l.block.NewValue(OpPrint, Void, l.expr(s.Expression))
+	case ast.ReadStmt:
+		target, ok := s.Target.(ast.SymbolExpr)
+		if !ok {
+			unsupported("read with a prompt")
+		}
+		v := l.lookup(target.Value)
+		l.assign(v, l.block.NewValue(OpRead, v.typ))
+	case ast.ReturnStmt:
+		var value *Value
+		if s.Value != nil {
+			value = l.convert(l.expr(s.Value), l.fn.Return)
+		}
+		l.ret(value)
+	case ast.FunctionDeclStmt:
+		if l.fn != l.module.Main || l.scope != l.top {
+			unsupported("nested function %s", s.Name)
+		}
+	case ast.ClassDeclStmt:
+		unsupported("class %s", s.Name)
+	case ast.EnumDeclStmt:
+		unsupported("enum %s", s.Name)
+	case ast.TypeDeclStmt:
+		unsupported("type %s", s.Name)
+	case ast.MatchStmt:
+		unsupported("match")
+	case ast.TryStmt:
+		unsupported("try")
+	case ast.ThrowStmt:
+		unsupported("throw")
+	default:
+		unsupported("%T", stmt)
+	}
+}
+
+func (l *lowerer) varDecl(s ast.VarDeclStmt) {
+	var t Type
+	var value *Value
+	if s.AssignedValue != nil {
+		value = l.expr(s.AssignedValue)
+		t = value.Type
+	}
+	if s.ExplicitType != nil {
+		t = typeOf(s.ExplicitType, "variable "+s.VariableName)
+	}
+	if value == nil {
+		value = l.zero(t)
+	}
+	l.declare(s.VariableName, t, l.convert(value, t))
+}
+
+func (l *lowerer) ifStmt(s ast.IfStmt) {
+	cond := l.condition(s.Condition)
+	then, join := l.fn.NewBlock(), l.fn.NewBlock()
+	otherwise := join
+	if s.Alternative != nil {
+		otherwise = l.fn.NewBlock()
+	}
+	l.branch(cond, then, otherwise)
+
+	l.seal(then)
+	l.block = then
+	l.nested(func() { l.stmts(s.Consequence.Body) })
+	if l.block != nil {
+		l.jump(join)
+	}
+
+	if s.Alternative != nil {
+		l.seal(otherwise)
+		l.block = otherwise
+		l.nested(func() { l.stmts(s.Alternative.Body) })
+		if l.block != nil {
+			l.jump(join)
+		}
+	}
+
+	if len(join.Preds) == 0 {
+		l.drop(join)
+		return
+	}
+	l.seal(join)
+	l.block = join
+}
+
+func (l *lowerer) whileStmt(s ast.WhileStmt) {
+	header := l.fn.NewBlock()
+	l.jump(header)
+
+	l.block = header
+	cond := l.condition(s.Condition)
+	body, exit := l.fn.NewBlock(), l.fn.NewBlock()
+	l.branch(cond, body, exit)
+
+	l.seal(body)
+	l.block = body
+	l.nested(func() { l.stmts(s.Body.Body) })
+	if l.block != nil {
+		l.jump(header)
+	}
+
+	l.seal(header)
+	l.seal(exit)
+	l.block = exit
+}
+
+// foreachStmt lowers foreach i in a..b step s as a loop over a hidden
+// counter, so assigning to i in the body does not change the iteration.
+func (l *lowerer) foreachStmt(s ast.ForeachStmt) {
+	r, ok := s.Iterable.(ast.RangeExpr)
+	if !ok {
+		unsupported("foreach over anything but a range")
+	}
+	if s.ValueName != "" {
+		unsupported("foreach with two names over a range")
+	}
+
+	step := int64(1)
+	if r.Step != nil {
+		n, ok := r.Step.(ast.NumberExpr)
+		if !ok || n.Float || n.Int == 0 {
+			unsupported("range step that is not a non-zero int literal")
+		}
+		step = n.Int
+	}
+
+	start := l.convert(l.expr(r.Start), Int)
+	end := l.convert(l.expr(r.End), Int)
+	counter := &variable{name: s.KeyName, typ: Int}
+	l.writeVariable(counter, l.block, start)
+
+	header := l.fn.NewBlock()
+	l.jump(header)
+	l.block = header
+
+	op := OpLt
+	switch {
+	case step > 0 && r.Inclusive:
+		op = OpLe
+	case step < 0 && r.Inclusive:
+		op = OpGe
+	case step < 0:
+		op = OpGt
+	}
+	cond := l.block.NewValue(op, Bool, l.use(counter), end)
+	body, exit := l.fn.NewBlock(), l.fn.NewBlock()
+	l.branch(cond, body, exit)
+
+	l.seal(body)
+	l.block = body
+	l.nested(func() {
+		l.declare(s.KeyName, Int, l.use(counter))
+		l.stmts(s.Body.Body)
+	})
+	if l.block != nil {
+		next := l.block.NewValue(OpAdd, Int, l.use(counter), l.intConst(step))
+		l.writeVariable(counter, l.block, next)
+		l.jump(header)
+	}
+
+	l.seal(header)
+	l.seal(exit)
+	l.block = exit
+}
+
+func (l *lowerer) condition(expr ast.Expr) *Value {
+	cond := l.expr(expr)
+	if cond.Type != Bool {
+		unsupported("%s condition", cond.Type)
+	}
+	return cond
+}
+
+func (l *lowerer) expr(expr ast.Expr) *Value {
+	switch e := expr.(type) {
+	case ast.NumberExpr:
+		if e.Float {
+			return l.floatConst(e.Value)
+		}
+		return l.intConst(e.Int)
+	case ast.StringExpr:
+		v := l.block.NewValue(OpConst, String)
+		v.AuxString = e.Value
+		return v
+	case ast.SymbolExpr:
+		return l.use(l.lookup(e.Value))
+	case ast.PrefixExpr:
+		return l.prefix(e)
+	case ast.BinaryExpr:
+		return l.binary(e)
+	case ast.TernaryExpr:
+		return l.ternary(e)
+	case ast.AssignmentExpr:
+		return l.assignment(e)
+	case ast.CallExpr:
+		return l.call(e)
+	case ast.NullExpr:
+		unsupported("null")
+	case ast.ArrayLiteralExpr:
+		unsupported("array")
+	case ast.MapLiteralExpr:
+		unsupported("map")
+	case ast.StructLiteralExpr:
+		unsupported("struct %s", e.TypeName)
+	case ast.NewExpr:
+		unsupported("class %s", e.ClassName)
+	case ast.FunctionExpr:
+		unsupported("function expression")
+	case ast.MatchExpr:
+		unsupported("match")
+	case ast.RangeExpr:
+		unsupported("range outside of foreach")
+	case ast.IndexExpr:
+		unsupported("indexing")
+	case ast.MemberExpr:
+		unsupported("member %s", e.Property)
+	}
+	unsupported("%T", expr)
+	return nil
+}
+
+func (l *lowerer) prefix(e ast.PrefixExpr) *Value {
+	if e.Operator.Kind == lexer.TYPEOF {
+		// The type of a scalar is known statically
+		operand := l.expr(e.RightExpr)
+		v := l.block.NewValue(OpConst, String)
+		v.AuxString = operand.Type.String()
+		return v
+	}
+
+	operand := l.expr(e.RightExpr)
+	switch e.Operator.Kind {
+	case lexer.DASH:
+		return l.block.NewValue(OpNeg, operand.Type, operand)
+	case lexer.PLUS:
+		return operand
+	case lexer.NOT:
+		return l.block.NewValue(OpNot, Bool, operand)
+	}
+	unsupported("operator %s", e.Operator.Value)
+	return nil
+}
+
+var binaryOps = map[lexer.TokenKind]Op{
+	lexer.PLUS:           OpAdd,
+	lexer.DASH:           OpSub,
+	lexer.STAR:           OpMul,
+	lexer.SLASH:          OpDiv,
+	lexer.PERCENT:        OpMod,
+	lexer.EQUALS:         OpEq,
+	lexer.NOT_EQUALS:     OpNe,
+	lexer.LESS:           OpLt,
+	lexer.LESS_EQUALS:    OpLe,
+	lexer.GREATER:        OpGt,
+	lexer.GREATER_EQUALS: OpGe,
+}
+
+func (l *lowerer) binary(e ast.BinaryExpr) *Value {
+	switch e.Operator.Kind {
+	case lexer.AND, lexer.OR:
+		return l.logical(e)
+	}
+
+	op, exists := binaryOps[e.Operator.Kind]
+	if !exists {
+		unsupported("operator %s", e.Operator.Value)
+	}
+	left := l.expr(e.Left)
+	right := l.expr(e.Right)
+	return l.operation(op, left, right)
+}
+
+// operation computes left op right, converting an int operand to float
+// when the other one is a float.
+func (l *lowerer) operation(op Op, left, right *Value) *Value {
+	if left.Type == String && right.Type == String && op == OpAdd {
+		return l.block.NewValue(OpConcat, String, left, right)
+	}
+	if left.Type == Float || right.Type == Float {
+		left, right = l.convert(left, Float), l.convert(right, Float)
+	}
+
+	switch op {
+	case OpEq, OpNe, OpLt, OpLe, OpGt, OpGe:
+		return l.block.NewValue(op, Bool, left, right)
+	default:
+		return l.block.NewValue(op, left.Type, left, right)
+	}
+}
+
+// logical lowers a && b and a || b, which only evaluate b when a does not
+// decide the result.
+func (l *lowerer) logical(e ast.BinaryExpr) *Value {
+	left := l.condition(e.Left)
+	decided := l.block.NewValue(OpConst, Bool)
+	if e.Operator.Kind == lexer.OR {
+		decided.AuxInt = 1
+	}
+
+	rhs, join := l.fn.NewBlock(), l.fn.NewBlock()
+	if e.Operator.Kind == lexer.AND {
+		l.branch(left, rhs, join)
+	} else {
+		l.branch(left, join, rhs)
+	}
+
+	l.seal(rhs)
+	l.block = rhs
+	right := l.condition(e.Right)
+	l.jump(join)
+
+	l.seal(join)
+	l.block = join
+	phi := l.newPhi(join, Bool)
+	phi.Args = []*Value{decided, right}
+	return phi
+}
+
+func (l *lowerer) ternary(e ast.TernaryExpr) *Value {
+	cond := l.condition(e.Condition)
+	then, otherwise, join := l.fn.NewBlock(), l.fn.NewBlock(), l.fn.NewBlock()
+	l.branch(cond, then, otherwise)
+
+	l.seal(then)
+	l.block = then
+	consequent := l.expr(e.Consequent)
+	thenEnd := l.block
+
+	l.seal(otherwise)
+	l.block = otherwise
+	alternate := l.expr(e.Alternate)
+
+	t := consequent.Type
+	if consequent.Type != alternate.Type {
+		t = Float
+	}
+	alternate = l.convert(alternate, t)
+	l.jump(join)
+
+	l.block = thenEnd
+	consequent = l.convert(consequent, t)
+	l.jump(join)
+
+	l.seal(join)
+	l.block = join
+	phi := l.newPhi(join, t)
+	// join's predecessors are the end of the else branch, then of the
+	// then branch
+	phi.Args = []*Value{alternate, consequent}
+	return phi
+}
+
+func (l *lowerer) assignment(e ast.AssignmentExpr) *Value {
+	target, ok := e.Assigne.(ast.SymbolExpr)
+	if !ok {
+		unsupported("assigning to an expression")
+	}
+	v := l.lookup(target.Value)
+
+	value := l.expr(e.Value)
+	switch e.Operator.Kind {
+	case lexer.ASSIGNMENT:
+	case lexer.PLUS_EQUALS:
+		value = l.operation(OpAdd, l.use(v), value)
+	case lexer.MINUS_EQUALS:
+		value = l.operation(OpSub, l.use(v), value)
+	default:
+		unsupported("operator %s", e.Operator.Value)
+	}
+
+	value = l.convert(value, v.typ)
+	l.assign(v, value)
+	return value
+}
+
+func (l *lowerer) call(e ast.CallExpr) *Value {
+	callee, ok := e.Callee.(ast.SymbolExpr)
+	if !ok {
+		unsupported("calling an expression")
+	}
+	if _, isVar := l.scope.lookup(callee.Value); isVar {
+		unsupported("calling variable %s", callee.Value)
+	}
+
+	args := make([]*Value, 0, len(e.Arguments))
+	for _, arg := range e.Arguments {
+		args = append(args, l.expr(arg))
+	}
+
+	sig, exists := l.functions[callee.Value]
+	if !exists {
+		if callee.Value == "println" {
+			return l.block.NewValue(OpPrint, Void, args...)
+		}
+		unsupported("builtin %s", callee.Value)
+	}
+	for i := range args {
+		args[i] = l.convert(args[i], sig.params[i])
+	}
+	v := l.block.NewValue(OpCall, sig.ret, args...)
+	v.Aux = callee.Value
+	return v
+}
+
+// convert returns value as a t. The checker only lets ints stand in for
+// floats, every other conversion is a bug in the lowering.
+func (l *lowerer) convert(value *Value, t Type) *Value {
+	if value.Type == t {
+		return value
+	}
+	if value.Type == Int && t == Float {
+		if value.Op == OpConst {
+			return l.floatConst(float64(value.AuxInt))
+		}
+		return l.block.NewValue(OpIntToFloat, Float, value)
+	}
+	panic(fmt.Sprintf("ir: cannot convert %s to %s", value.Type, t))
+}
+
+func (l *lowerer) intConst(n int64) *Value {
+	v := l.block.NewValue(OpConst, Int)
+	v.AuxInt = n
+	return v
+}
+
+func (l *lowerer) floatConst(f float64) *Value {
+	v := l.block.NewValue(OpConst, Float)
+	v.AuxFloat = f
+	return v
+}
+
+func (l *lowerer) zero(t Type) *Value {
+	return l.zeroIn(l.block, t)
+}
+
+// zeroIn adds the zero value of t to b, which is the current block or the
+// entry, where no phis can precede it.
+func (l *lowerer) zeroIn(b *Block, t Type) *Value {
+	return b.NewValue(OpConst, t)
+}
+
+// typeOf maps a source type to a Type, naming what it is the type of when
+// it has none.
+func typeOf(t ast.Type, of string) Type {
+	if symbol, ok := t.(ast.SymbolType); ok {
+		switch symbol.Name {
+		case "int":
+			return Int
+		case "float":
+			return Float
+		case "bool":
+			return Bool
+		case "string":
+			return String
+		}
+	}
+	unsupported("the type of %s", of)
+	return Void
+}
+
+func unwrapExport(stmt ast.Stmt) ast.Stmt {
+	if export, ok := stmt.(ast.ExportStmt); ok {
+		return export.Declaration
+	}
+	return stmt
+}
+
+// freeNames collects the names a function body reads or assigns, which
+// are globals when the top level declares them. Names the body declares
+// itself are included too; treating such a variable of the top level as
+// a global is only slower.
+func freeNames(stmts []ast.Stmt) map[string]bool {
+	names := make(map[string]bool)
+	var visitExpr func(ast.Expr)
+	var visitStmts func([]ast.Stmt)
+
+	visitExpr = func(expr ast.Expr) {
+		switch e := expr.(type) {
+		case ast.SymbolExpr:
+			names[e.Value] = true
+		case ast.PrefixExpr:
+			visitExpr(e.RightExpr)
+		case ast.BinaryExpr:
+			visitExpr(e.Left)
+			visitExpr(e.Right)
+		case ast.TernaryExpr:
+			visitExpr(e.Condition)
+			visitExpr(e.Consequent)
+			visitExpr(e.Alternate)
+		case ast.AssignmentExpr:
+			visitExpr(e.Assigne)
+			visitExpr(e.Value)
+		case ast.CallExpr:
+			visitExpr(e.Callee)
+			for _, arg := range e.Arguments {
+				visitExpr(arg)
+			}
+		case ast.RangeExpr:
+			visitExpr(e.Start)
+			visitExpr(e.End)
+		}
+	}
+
+	visitStmts = func(stmts []ast.Stmt) {
+		for _, stmt := range stmts {
+			switch s := stmt.(type) {
+			case ast.ExprStmt:
+				visitExpr(s.Expression)
+			case ast.VarDeclStmt:
+				if s.AssignedValue != nil {
+					visitExpr(s.AssignedValue)
+				}
+			case ast.BlockStmt:
+				visitStmts(s.Body)
+			case ast.IfStmt:
+				visitExpr(s.Condition)
+				visitStmts(s.Consequence.Body)
+				if s.Alternative != nil {
+					visitStmts(s.Alternative.Body)
+				}
+			case ast.WhileStmt:
+				visitExpr(s.Condition)
+				visitStmts(s.Body.Body)
+			case ast.ForeachStmt:
+				visitExpr(s.Iterable)
+				visitStmts(s.Body.Body)
+			case ast.PrintStmt:
+				visitExpr(s.Expression)
+			case ast.ReadStmt:
+				visitExpr(s.Target)
+			case ast.ReturnStmt:
+				if s.Value != nil {
+					visitExpr(s.Value)
+				}
+			}
+		}
+	}
+
+	visitStmts(stmts)
+	return names
+}
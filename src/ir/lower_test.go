@@ -0,0 +1,50 @@
+package ir_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RyanOliveira00/go-compiler/src/compiler"
+	"github.com/RyanOliveira00/go-compiler/src/internal/langtest"
+	"github.com/RyanOliveira00/go-compiler/src/ir"
+)
+
+// TestLower lowers the programs of testdata, which Verify must accept,
+// without optimizing them.
+func TestLower(t *testing.T) {
+	pm := ir.NewPassManager()
+	for _, name := range ir.PassNames() {
+		pm.Enable(name, false)
+	}
+	for _, p := range langtest.Programs(t, "testdata") {
+		t.Run(p.Name, func(t *testing.T) {
+			module := langtest.Lower(t, p, pm)
+			if module.Main == nil || module.Main.Name != ir.MainName {
+				t.Errorf("no main function in\n%s", module)
+			}
+		})
+	}
+}
+
+func TestLowerUnsupported(t *testing.T) {
+	tests := map[string]string{
+		"let xs = [1, 2];":              "array is not supported by the IR",
+		"enum E { A }":                  "enum E is not supported by the IR",
+		"let m = {\"a\": 1}; print(m);": "map is not supported by the IR",
+	}
+	for source, want := range tests {
+		path := filepath.Join(t.TempDir(), "program.lang")
+		if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		program, err := compiler.CheckFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ir.Lower(program); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got %v, want %q", source, err, want)
+		}
+	}
+}
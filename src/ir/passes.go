@@ -0,0 +1,163 @@
+package ir
+
+import (
+	"fmt"
+	"io"
+)
+
+// pass is an optimization transforming one function in place.
+type pass struct {
+	name string
+	run  func(*Func)
+}
+
+// passes run in this order, each one once per function
+var passes = []pass{
+	{"copyprop", copyProp},
+	{"deadstore", deadStore},
+	{"licm", licm},
+	{"cse", cse},
+	{"deadcode", deadCode},
+}
+
+// PassNames lists the optimization passes in the order they run.
+func PassNames() []string {
+	names := make([]string, 0, len(passes))
+	for _, p := range passes {
+		names = append(names, p.name)
+	}
+	return names
+}
+
+// PassManager runs the enabled optimization passes over a module,
+// verifying each function after every pass.
+type PassManager struct {
+	// Dump receives each function before and after every pass that
+	// changes it, nil to dump nothing
+	Dump io.Writer
+
+	disabled map[string]bool
+}
+
+// NewPassManager returns a PassManager with every pass enabled.
+func NewPassManager() *PassManager {
+	return &PassManager{disabled: make(map[string]bool)}
+}
+
+// Enable turns the pass named name on or off.
+func (pm *PassManager) Enable(name string, enabled bool) error {
+	for _, p := range passes {
+		if p.name == name {
+			pm.disabled[name] = !enabled
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown pass %s", name)
+}
+
+func (pm *PassManager) Enabled(name string) bool {
+	return !pm.disabled[name]
+}
+
+// Run optimizes every function of m. An error means a pass broke an
+// invariant checked by Verify, which is a bug in that pass.
+func (pm *PassManager) Run(m *Module) error {
+	for _, f := range m.AllFuncs() {
+		for _, p := range passes {
+			if pm.disabled[p.name] {
+				continue
+			}
+
+			var before string
+			if pm.Dump != nil {
+				before = f.String()
+			}
+			p.run(f)
+			if pm.Dump != nil {
+				if after := f.String(); after != before {
+					fmt.Fprintf(pm.Dump, "// before %s\n%s// after %s\n%s", p.name, before, p.name, after)
+				}
+			}
+
+			if err := verifyFunc(m, f); err != nil {
+				return fmt.Errorf("%s: after %s: %w", f.Name, p.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// replaceUses makes every value and block of f using old use with
+// instead.
+func (f *Func) replaceUses(old, with *Value) {
+	for _, b := range f.Blocks {
+		for _, v := range b.Values {
+			for i, arg := range v.Args {
+				if arg == old {
+					v.Args[i] = with
+				}
+			}
+		}
+		if b.Control == old {
+			b.Control = with
+		}
+	}
+}
+
+// removeValues drops the values of b marked in removed, indexed by ID.
+func (b *Block) removeValues(removed []bool) {
+	kept := b.Values[:0]
+	for _, v := range b.Values {
+		if !removed[v.ID] {
+			kept = append(kept, v)
+		}
+	}
+	b.Values = kept
+}
+
+// removePred removes the i-th predecessor of b along with the matching
+// phi arguments.
+func (b *Block) removePred(i int) {
+	b.Preds = append(b.Preds[:i:i], b.Preds[i+1:]...)
+	for _, v := range b.Values {
+		if v.Op == OpPhi {
+			v.Args = append(v.Args[:i:i], v.Args[i+1:]...)
+		}
+	}
+}
+
+// removeUnreachable drops the blocks control can no longer reach, and
+// their edges into the rest of the function.
+func (f *Func) removeUnreachable() {
+	reachable := make([]bool, f.NumBlocks())
+	for _, b := range ReversePostorder(f) {
+		reachable[b.ID] = true
+	}
+
+	kept := f.Blocks[:0]
+	for _, b := range f.Blocks {
+		if reachable[b.ID] {
+			kept = append(kept, b)
+			continue
+		}
+		for _, succ := range b.Succs {
+			for i := len(succ.Preds) - 1; i >= 0; i-- {
+				if succ.Preds[i] == b {
+					succ.removePred(i)
+				}
+			}
+		}
+	}
+	f.Blocks = kept
+}
+
+// pure reports whether v computes its result from its arguments alone,
+// so two such values with the same operands are equal and it can run
+// anywhere they are available.
+func pure(v *Value) bool {
+	switch v.Op {
+	case OpPhi, OpParam, OpCopy, OpLoad:
+		return false
+	}
+	return v.Type != Void && !v.Op.HasSideEffects()
+}
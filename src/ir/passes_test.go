@@ -0,0 +1,41 @@
+package ir_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RyanOliveira00/go-compiler/src/internal/langtest"
+	"github.com/RyanOliveira00/go-compiler/src/ir"
+)
+
+// TestPasses compares what every pass changes in the programs of
+// testdata, as PassManager.Dump writes it, followed by the optimized
+// module, with the program's .golden file.
+func TestPasses(t *testing.T) {
+	for _, p := range langtest.Programs(t, "testdata") {
+		t.Run(p.Name, func(t *testing.T) {
+			var dump strings.Builder
+			pm := ir.NewPassManager()
+			pm.Dump = &dump
+			module := langtest.Lower(t, p, pm)
+			dump.WriteString("// optimized\n" + module.String())
+
+			langtest.Golden(t, strings.TrimSuffix(p.Path, ".lang")+".golden", dump.String())
+		})
+	}
+}
+
+func TestEnable(t *testing.T) {
+	pm := ir.NewPassManager()
+	for _, name := range ir.PassNames() {
+		if err := pm.Enable(name, false); err != nil {
+			t.Fatal(err)
+		}
+		if pm.Enabled(name) {
+			t.Errorf("%s is enabled after disabling it", name)
+		}
+	}
+	if err := pm.Enable("inline", false); err == nil {
+		t.Error("disabling an unknown pass succeeded")
+	}
+}
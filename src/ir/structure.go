@@ -0,0 +1,209 @@
+package ir
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Stmt is a statement of a function recovered as structured code, for
+// targets without arbitrary jumps such as WebAssembly, or meant to be
+// read, such as C. Every path through a statement list ends in a Jump or a
+// Return.
+type Stmt interface {
+	stmt()
+}
+
+// Code runs the values of Block, without its terminator.
+type Code struct {
+	Block *Block
+}
+
+type If struct {
+	Cond       *Value
+	Then, Else []Stmt
+}
+
+// Loop runs Body, which a Jump of kind JumpContinue to Header restarts.
+// Leaving it takes a JumpBreak to an enclosing Scope.
+type Loop struct {
+	Header *Block
+	Body   []Stmt
+}
+
+// Scope runs Body, which a Jump of kind JumpBreak to Follow leaves. The
+// code of Follow comes right after the Scope.
+type Scope struct {
+	Follow *Block
+	Body   []Stmt
+}
+
+type JumpKind int
+
+const (
+	// JumpNext goes on with the code of To, which comes next
+	JumpNext JumpKind = iota
+	// JumpContinue restarts the enclosing Loop whose Header is To
+	JumpContinue
+	// JumpBreak leaves the enclosing Scope whose Follow is To
+	JumpBreak
+)
+
+// Jump takes the edge From -> To, which sets the phis of To to their
+// arguments for From.
+type Jump struct {
+	From, To *Block
+	Kind     JumpKind
+}
+
+// Return leaves the function with Value, nil in void functions.
+type Return struct {
+	Value *Value
+}
+
+func (*Code) stmt()   {}
+func (*If) stmt()     {}
+func (*Loop) stmt()   {}
+func (*Scope) stmt()  {}
+func (*Jump) stmt()   {}
+func (*Return) stmt() {}
+
+// Structure recovers the structured statements of f, following Ramsey,
+// "Beyond Relooper": each block is placed in the code of its immediate
+// dominator, in a Scope when several edges lead to it. In addition, the
+// blocks a loop exits to are placed after the loop rather than inside it,
+// so they read as the code following a while statement. The control flow
+// of f must be reducible, which it is for lowered programs.
+func Structure(f *Func) ([]Stmt, error) {
+	s := &structurer{
+		dom:     Dominators(f),
+		loops:   make(map[*Block]map[*Block]bool),
+		follows: make(map[*Block][]*Block),
+		placed:  make(map[*Block]bool),
+	}
+	order := s.dom.Order()
+	s.rpo = make([]int, f.NumBlocks())
+	for i, b := range order {
+		s.rpo[b.ID] = i
+	}
+
+	for _, b := range order {
+		for _, pred := range b.Preds {
+			if s.rpo[pred.ID] >= s.rpo[b.ID] && !s.dom.Dominates(b, pred) {
+				return nil, fmt.Errorf("%s: irreducible control flow into %s", f.Name, b)
+			}
+		}
+		if body := loopBody(s.dom, b); body != nil {
+			s.loops[b] = body
+			s.headers = append(s.headers, b)
+		}
+	}
+
+	for _, b := range order[1:] {
+		idom := s.dom.Idom(b)
+		if header := s.exited(idom, b); header != nil {
+			s.follows[header] = append(s.follows[header], b)
+			s.placed[b] = true
+		} else if s.merge(b) {
+			s.follows[idom] = append(s.follows[idom], b)
+			s.placed[b] = true
+		}
+	}
+	for _, follows := range s.follows {
+		sort.Slice(follows, func(i, j int) bool {
+			return s.rpo[follows[i].ID] > s.rpo[follows[j].ID]
+		})
+	}
+
+	return s.code(f.Entry()), nil
+}
+
+type structurer struct {
+	dom     *DomTree
+	rpo     []int
+	loops   map[*Block]map[*Block]bool // the body of each loop by header
+	headers []*Block                   // in reverse postorder, outer loops first
+
+	// follows are the blocks placed after the code of a block, in Scopes
+	// around it; placed marks them
+	follows map[*Block][]*Block
+	placed  map[*Block]bool
+}
+
+// exited returns the header of the outermost loop containing b's
+// immediate dominator idom but not b, nil when there is none.
+func (s *structurer) exited(idom, b *Block) *Block {
+	for _, header := range s.headers {
+		if body := s.loops[header]; body[idom] && !body[b] {
+			return header
+		}
+	}
+	return nil
+}
+
+// merge reports whether several forward edges lead to b.
+func (s *structurer) merge(b *Block) bool {
+	forward := 0
+	for _, pred := range b.Preds {
+		if !s.dom.Dominates(b, pred) {
+			forward++
+		}
+	}
+	return forward > 1
+}
+
+// code is the code of b and of every block it dominates.
+func (s *structurer) code(b *Block) []Stmt {
+	body := s.loops[b]
+	if body == nil {
+		return s.within(s.follows[b], s.blockCode(b))
+	}
+
+	var inside, exits []*Block
+	for _, follow := range s.follows[b] {
+		if body[follow] {
+			inside = append(inside, follow)
+		} else {
+			exits = append(exits, follow)
+		}
+	}
+	loop := &Loop{Header: b, Body: s.within(inside, s.blockCode(b))}
+	return s.within(exits, []Stmt{loop})
+}
+
+// within nests stmts in a Scope for each of follows, the outermost one
+// for the first, which comes last.
+func (s *structurer) within(follows []*Block, stmts []Stmt) []Stmt {
+	if len(follows) == 0 {
+		return stmts
+	}
+	follow := follows[0]
+	scope := &Scope{Follow: follow, Body: s.within(follows[1:], stmts)}
+	return append([]Stmt{scope}, s.code(follow)...)
+}
+
+func (s *structurer) blockCode(b *Block) []Stmt {
+	stmts := []Stmt{&Code{Block: b}}
+	switch b.Kind {
+	case BlockPlain:
+		return append(stmts, s.edge(b, b.Succs[0])...)
+	case BlockIf:
+		return append(stmts, &If{
+			Cond: b.Control,
+			Then: s.edge(b, b.Succs[0]),
+			Else: s.edge(b, b.Succs[1]),
+		})
+	default:
+		return append(stmts, &Return{Value: b.Control})
+	}
+}
+
+func (s *structurer) edge(from, to *Block) []Stmt {
+	switch {
+	case s.loops[to][from]:
+		return []Stmt{&Jump{From: from, To: to, Kind: JumpContinue}}
+	case s.placed[to]:
+		return []Stmt{&Jump{From: from, To: to, Kind: JumpBreak}}
+	default:
+		return append([]Stmt{&Jump{From: from, To: to, Kind: JumpNext}}, s.code(to)...)
+	}
+}
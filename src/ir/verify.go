@@ -0,0 +1,297 @@
+package ir
+
+import "fmt"
+
+// Verify checks the invariants the passes and code generators rely on:
+// well formed blocks and edges, phis matching the predecessors of their
+// block, operand types, and every use dominated by its definition.
+func Verify(m *Module) error {
+	for _, f := range m.AllFuncs() {
+		if err := verifyFunc(m, f); err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func verifyFunc(m *Module, f *Func) error {
+	if len(f.Blocks) == 0 {
+		return fmt.Errorf("no blocks")
+	}
+	if len(f.Entry().Preds) > 0 {
+		return fmt.Errorf("entry %s has predecessors", f.Entry())
+	}
+
+	blocks := make(map[*Block]bool, len(f.Blocks))
+	values := make(map[*Value]int) // position within the block
+	for _, b := range f.Blocks {
+		if blocks[b] || b.Func != f {
+			return fmt.Errorf("%s is listed twice or belongs to another function", b)
+		}
+		blocks[b] = true
+		for i, v := range b.Values {
+			if _, seen := values[v]; seen || v.Block != b || v.ID >= f.NumValues() {
+				return fmt.Errorf("%s: %s is listed twice or belongs to another block", b, v)
+			}
+			values[v] = i
+		}
+	}
+
+	dom := Dominators(f)
+	for _, b := range f.Blocks {
+		if !dom.Reachable(b) {
+			return fmt.Errorf("%s is unreachable", b)
+		}
+		if err := verifyEdges(b, blocks); err != nil {
+			return fmt.Errorf("%s: %w", b, err)
+		}
+		if err := verifyTerminator(f, b); err != nil {
+			return fmt.Errorf("%s: %w", b, err)
+		}
+
+		phis := true
+		for i, v := range b.Values {
+			if v.Op == OpPhi && !phis {
+				return fmt.Errorf("%s: phi %s follows other values", b, v)
+			}
+			phis = v.Op == OpPhi
+
+			for j, arg := range v.Args {
+				if arg == nil {
+					return fmt.Errorf("%s: argument %d of %s is missing", b, j, v)
+				}
+				pos, defined := values[arg]
+				if !defined {
+					return fmt.Errorf("%s: %s uses %s, which is not in the function", b, v, arg)
+				}
+				// A phi's argument must be available at the end of the
+				// corresponding predecessor
+				if v.Op == OpPhi {
+					if j < len(b.Preds) && !dom.Dominates(arg.Block, b.Preds[j]) {
+						return fmt.Errorf("%s: %s uses %s, which does not dominate %s", b, v, arg, b.Preds[j])
+					}
+					continue
+				}
+				if arg.Block == b && pos >= i || !dom.Dominates(arg.Block, b) {
+					return fmt.Errorf("%s: %s uses %s before it is defined", b, v, arg)
+				}
+			}
+			if err := verifyValue(m, f, v); err != nil {
+				return fmt.Errorf("%s: %s: %w", b, v.LongString(), err)
+			}
+		}
+
+		if b.Control != nil {
+			if _, defined := values[b.Control]; !defined || !dom.Dominates(b.Control.Block, b) {
+				return fmt.Errorf("%s: control %s is not defined before", b, b.Control)
+			}
+		}
+	}
+	return nil
+}
+
+func verifyEdges(b *Block, blocks map[*Block]bool) error {
+	for _, succ := range b.Succs {
+		if !blocks[succ] {
+			return fmt.Errorf("successor %s is not in the function", succ)
+		}
+		if count(succ.Preds, b) != count(b.Succs, succ) {
+			return fmt.Errorf("edge to %s is missing from its predecessors", succ)
+		}
+	}
+	for _, pred := range b.Preds {
+		if !blocks[pred] {
+			return fmt.Errorf("predecessor %s is not in the function", pred)
+		}
+		if count(pred.Succs, b) != count(b.Preds, pred) {
+			return fmt.Errorf("edge from %s is missing from its successors", pred)
+		}
+	}
+	return nil
+}
+
+func count(blocks []*Block, b *Block) int {
+	n := 0
+	for _, block := range blocks {
+		if block == b {
+			n++
+		}
+	}
+	return n
+}
+
+func verifyTerminator(f *Func, b *Block) error {
+	switch b.Kind {
+	case BlockPlain:
+		if len(b.Succs) != 1 || b.Control != nil {
+			return fmt.Errorf("plain block needs one successor and no control")
+		}
+	case BlockIf:
+		if len(b.Succs) != 2 || b.Control == nil || b.Control.Type != Bool {
+			return fmt.Errorf("if block needs two successors and a bool control")
+		}
+	case BlockReturn:
+		if len(b.Succs) != 0 {
+			return fmt.Errorf("return block has successors")
+		}
+		if f.Return == Void && b.Control != nil {
+			return fmt.Errorf("returns a value from a function without result")
+		}
+		if f.Return != Void && (b.Control == nil || b.Control.Type != f.Return) {
+			return fmt.Errorf("must return a %s", f.Return)
+		}
+	default:
+		return fmt.Errorf("unknown block kind %d", b.Kind)
+	}
+	return nil
+}
+
+// verifyValue checks the operands and type of v.
+func verifyValue(m *Module, f *Func, v *Value) error {
+	args := func(n int) error {
+		if len(v.Args) != n {
+			return fmt.Errorf("takes %d arguments, has %d", n, len(v.Args))
+		}
+		return nil
+	}
+	sameType := func(want Type) error {
+		for _, arg := range v.Args {
+			if arg.Type != want {
+				return fmt.Errorf("argument %s is a %s, want %s", arg, arg.Type, want)
+			}
+		}
+		return nil
+	}
+
+	switch v.Op {
+	case OpConst:
+		if v.Type == Void {
+			return fmt.Errorf("void constant")
+		}
+		return args(0)
+	case OpParam:
+		if v.Block != f.Entry() || v.AuxInt < 0 || int(v.AuxInt) >= len(f.Params) || f.Params[v.AuxInt] != v {
+			return fmt.Errorf("parameter out of place")
+		}
+		return args(0)
+	case OpPhi:
+		if err := args(len(v.Block.Preds)); err != nil {
+			return err
+		}
+		return sameType(v.Type)
+	case OpCopy:
+		if err := args(1); err != nil {
+			return err
+		}
+		return sameType(v.Type)
+	case OpAdd, OpSub, OpMul, OpDiv, OpMod:
+		if v.Type != Int && v.Type != Float {
+			return fmt.Errorf("arithmetic on %s", v.Type)
+		}
+		if err := args(2); err != nil {
+			return err
+		}
+		return sameType(v.Type)
+	case OpNeg:
+		if v.Type != Int && v.Type != Float {
+			return fmt.Errorf("negation of %s", v.Type)
+		}
+		if err := args(1); err != nil {
+			return err
+		}
+		return sameType(v.Type)
+	case OpConcat:
+		if err := args(2); err != nil {
+			return err
+		}
+		return sameType(String)
+	case OpIntToFloat:
+		if err := args(1); err != nil {
+			return err
+		}
+		if v.Type != Float {
+			return fmt.Errorf("converts to %s", v.Type)
+		}
+		return sameType(Int)
+	case OpEq, OpNe, OpLt, OpLe, OpGt, OpGe:
+		if err := args(2); err != nil {
+			return err
+		}
+		if v.Type != Bool {
+			return fmt.Errorf("comparison results in %s", v.Type)
+		}
+		operand := v.Args[0].Type
+		if operand == Void || operand == Bool && v.Op != OpEq && v.Op != OpNe {
+			return fmt.Errorf("cannot order %s values", operand)
+		}
+		return sameType(operand)
+	case OpNot:
+		if err := args(1); err != nil {
+			return err
+		}
+		if v.Type != Bool {
+			return fmt.Errorf("negation results in %s", v.Type)
+		}
+		return sameType(Bool)
+	case OpCall:
+		callee := m.Func(v.Aux)
+		if callee == nil {
+			return fmt.Errorf("unknown function %s", v.Aux)
+		}
+		if err := args(len(callee.Params)); err != nil {
+			return err
+		}
+		for i, arg := range v.Args {
+			if arg.Type != callee.Params[i].Type {
+				return fmt.Errorf("argument %d is a %s, want %s", i, arg.Type, callee.Params[i].Type)
+			}
+		}
+		if v.Type != callee.Return {
+			return fmt.Errorf("results in %s, %s returns %s", v.Type, v.Aux, callee.Return)
+		}
+	case OpLoad, OpStore:
+		global, exists := m.global(v.Aux)
+		if !exists {
+			return fmt.Errorf("unknown global @%s", v.Aux)
+		}
+		if v.Op == OpLoad {
+			if v.Type != global.Type {
+				return fmt.Errorf("loads a %s from a %s", v.Type, global.Type)
+			}
+			return args(0)
+		}
+		if err := args(1); err != nil {
+			return err
+		}
+		if v.Type != Void {
+			return fmt.Errorf("store has a result")
+		}
+		return sameType(global.Type)
+	case OpPrint:
+		if v.Type != Void {
+			return fmt.Errorf("print has a result")
+		}
+		for _, arg := range v.Args {
+			if arg.Type == Void {
+				return fmt.Errorf("prints void %s", arg)
+			}
+		}
+	case OpRead:
+		if v.Type == Void {
+			return fmt.Errorf("reads void")
+		}
+		return args(0)
+	default:
+		return fmt.Errorf("unknown op")
+	}
+	return nil
+}
+
+func (m *Module) global(name string) (Global, bool) {
+	for _, global := range m.Globals {
+		if global.Name == name {
+			return global, true
+		}
+	}
+	return Global{}, false
+}
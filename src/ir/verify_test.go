@@ -0,0 +1,114 @@
+package ir
+
+import (
+	"strings"
+	"testing"
+)
+
+// diamond builds a function branching on its parameter to then, which
+// computes sum, or to otherwise, both joining in join, which returns the
+// phi merging sum with the parameter.
+func diamond() (m *Module, then, join *Block, sum, phi *Value) {
+	f := NewFunc("diamond", Int)
+	entry, then, otherwise, join := f.NewBlock(), f.NewBlock(), f.NewBlock(), f.NewBlock()
+
+	x := entry.NewValue(OpParam, Int)
+	f.Params = []*Value{x}
+	zero := entry.NewValue(OpConst, Int)
+	cond := entry.NewValue(OpGt, Bool, x, zero)
+	entry.Kind, entry.Control = BlockIf, cond
+	entry.AddEdge(then)
+	entry.AddEdge(otherwise)
+
+	sum = then.NewValue(OpAdd, Int, x, x)
+	then.AddEdge(join)
+	otherwise.AddEdge(join)
+
+	phi = join.NewValue(OpPhi, Int, sum, x)
+	join.Kind, join.Control = BlockReturn, phi
+
+	main := NewFunc(MainName, Void)
+	main.NewBlock().Kind = BlockReturn
+	return &Module{Funcs: []*Func{f}, Main: main}, then, join, sum, phi
+}
+
+func TestVerify(t *testing.T) {
+	m, _, _, _, _ := diamond()
+	if err := Verify(m); err != nil {
+		t.Fatalf("valid function rejected: %s", err)
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func(join *Block, sum, phi *Value)
+		want    string
+	}{
+		{
+			"phi arity",
+			func(join *Block, sum, phi *Value) { phi.Args = phi.Args[:1] },
+			"takes 2 arguments, has 1",
+		},
+		{
+			"phi argument not dominating its predecessor",
+			func(join *Block, sum, phi *Value) { phi.Args[1] = sum },
+			"does not dominate",
+		},
+		{
+			"use not dominated by its definition",
+			func(join *Block, sum, phi *Value) {
+				v := join.NewValue(OpNeg, Int, sum)
+				join.Values = []*Value{phi, v}
+			},
+			"before it is defined",
+		},
+		{
+			"use before its definition in the same block",
+			func(join *Block, sum, phi *Value) {
+				later := join.NewValue(OpNeg, Int, phi)
+				v := join.NewValue(OpNeg, Int, later)
+				join.Values = []*Value{phi, v, later}
+			},
+			"before it is defined",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, _, join, sum, phi := diamond()
+			test.corrupt(join, sum, phi)
+			err := Verify(m)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got %v, want an error containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestDominators(t *testing.T) {
+	m, then, join, _, _ := diamond()
+	f := m.Funcs[0]
+	entry, otherwise := f.Blocks[0], f.Blocks[2]
+	unreachable := f.NewBlock()
+	unreachable.Kind = BlockReturn
+
+	dom := Dominators(f)
+	for _, b := range []*Block{then, otherwise, join} {
+		if dom.Idom(b) != entry {
+			t.Errorf("idom of %s is %s, want %s", b, dom.Idom(b), entry)
+		}
+	}
+	if dom.Idom(entry) != nil {
+		t.Errorf("the entry has idom %s", dom.Idom(entry))
+	}
+	if !dom.Dominates(entry, join) || !dom.Dominates(join, join) {
+		t.Error("the entry and join itself do not dominate join")
+	}
+	if dom.Dominates(then, join) || dom.Dominates(otherwise, join) {
+		t.Error("a branch dominates the block both branches join")
+	}
+	if dom.Reachable(unreachable) || dom.Dominates(entry, unreachable) {
+		t.Error("an unreachable block is dominated")
+	}
+	if order := dom.Order(); len(order) != 4 || order[0] != entry || order[3] != join {
+		t.Errorf("reverse postorder %v does not start at the entry and end at join", order)
+	}
+}
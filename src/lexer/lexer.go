@@ -17,12 +17,26 @@ type lexer struct {
 	Tokens   []Token
 	source   string
 	pos      int
+	line     int
+	column   int
 }
 
 func (lex *lexer) advanceN(n int) {
+	for _, ch := range lex.source[lex.pos : lex.pos+n] {
+		if ch == '\n' {
+			lex.line++
+			lex.column = 1
+		} else {
+			lex.column++
+		}
+	}
 	lex.pos += n
 }
 
+func (lex *lexer) position() Position {
+	return Position{Offset: lex.pos, Line: lex.line, Column: lex.column}
+}
+
 func (lex *lexer) push(token Token) {
 	lex.Tokens = append(lex.Tokens, token)
 }
@@ -44,6 +58,7 @@ func Tokenize(source string) []Token {
 
 	for !lex.at_eof() {
 		matched := false
+		start, pushed := lex.position(), len(lex.Tokens)
 
 		for _, pattern := range lex.patterns {
 			loc := pattern.regex.FindStringIndex(lex.remainder())
@@ -55,12 +70,19 @@ func Tokenize(source string) []Token {
 			}
 		}
 
+		// Handlers push at most one token, spanning the text they consumed
+		if len(lex.Tokens) > pushed {
+			lex.Tokens[pushed].Span = Span{Start: start, End: lex.position()}
+		}
+
 		if !matched {
 			panic(fmt.Sprintf("Lexer error: unexpected character near '%s'", lex.remainder()))
 		}
 	}
 
-	lex.push(NewToken(EOF, "EOF"))
+	eof := NewToken(EOF, "EOF")
+	eof.Span = Span{Start: lex.position(), End: lex.position()}
+	lex.push(eof)
 	return lex.Tokens
 }
 
@@ -75,6 +97,8 @@ func defaultHandler(kind TokenKind, value string) regexHandler {
 func createLexer(source string) *lexer {
 	return &lexer{
 		pos:    0,
+		line:   1,
+		column: 1,
 		source: source,
 		Tokens: make([]Token, 0),
 		patterns: []regexPattern{
@@ -89,6 +113,7 @@ func createLexer(source string) *lexer {
 			{regexp.MustCompile(`\}`), defaultHandler(CLOSE_CURLY, "}")},
 			{regexp.MustCompile(`\(`), defaultHandler(OPEN_PAREN, "(")},
 			{regexp.MustCompile(`\)`), defaultHandler(CLOSE_PAREN, ")")},
+			{regexp.MustCompile(`=>`), defaultHandler(ARROW, "=>")},
 			{regexp.MustCompile(`==`), defaultHandler(EQUALS, "==")},
 			{regexp.MustCompile(`!=`), defaultHandler(NOT_EQUALS, "!=")},
 			{regexp.MustCompile(`=`), defaultHandler(ASSIGNMENT, "=")},
@@ -99,11 +124,15 @@ func createLexer(source string) *lexer {
 			{regexp.MustCompile(`>`), defaultHandler(GREATER, ">")},
 			{regexp.MustCompile(`\|\|`), defaultHandler(OR, "||")},
 			{regexp.MustCompile(`&&`), defaultHandler(AND, "&&")},
+			{regexp.MustCompile(`\|`), defaultHandler(PIPE, "|")},
+			{regexp.MustCompile(`\.\.=`), defaultHandler(DOT_DOT_EQUALS, "..=")},
 			{regexp.MustCompile(`\.\.`), defaultHandler(DOT_DOT, "..")},
 			{regexp.MustCompile(`\.`), defaultHandler(DOT, ".")},
 			{regexp.MustCompile(`;`), defaultHandler(SEMI_COLON, ";")},
 			{regexp.MustCompile(`:`), defaultHandler(COLON, ":")},
-			// {regexp.MustCompile(`\?\?=`), defaultHandler(NULLISH_ASSIGNMENT, "??=")},
+			{regexp.MustCompile(`\?\?=`), defaultHandler(NULLISH_ASSIGNMENT, "??=")},
+			{regexp.MustCompile(`\?\?`), defaultHandler(NULLISH, "??")},
+			{regexp.MustCompile(`\?\.`), defaultHandler(QUESTION_DOT, "?.")},
 			{regexp.MustCompile(`\?`), defaultHandler(QUESTION, "?")},
 			{regexp.MustCompile(`,`), defaultHandler(COMMA, ",")},
 			{regexp.MustCompile(`\+\+`), defaultHandler(PLUS_PLUS, "++")},
@@ -6,6 +6,7 @@ type TokenKind int
 
 const (
 	EOF TokenKind = iota
+	NULL
 	NUMBER
 	STRING
 	IDENTIFIER
@@ -29,19 +30,25 @@ const (
 
 	OR
 	AND
+	PIPE
 
 	DOT
 	DOT_DOT
+	DOT_DOT_EQUALS
 
 	SEMI_COLON
 	COLON
 	QUESTION
+	QUESTION_DOT
+	NULLISH
 	COMMA
+	ARROW
 
 	PLUS_PLUS
 	MINUS_MINUS
 	PLUS_EQUALS
 	MINUS_EQUALS
+	NULLISH_ASSIGNMENT
 	SLASH_EQUALS
 	STAR_EQUALS
 
@@ -59,6 +66,7 @@ const (
 	IMPORT
 	FROM
 	FN
+	RETURN
 	IF
 	ELSE
 	FOREACH
@@ -66,34 +74,75 @@ const (
 	FOR
 	EXPORT
 	TYPEOF
+	TYPE
 	IN
 	PRINT
 	READ
+	THROW
+	TRY
+	CATCH
+	FINALLY
+	INTERFACE
+	IMPLEMENTS
+	ENUM
+	MATCH
 )
 
 var reversed_lu map[string]TokenKind = map[string]TokenKind{
-	"let":     LET,
-	"const":   CONST,
-	"class":   CLASS,
-	"new":     NEW,
-	"import":  IMPORT,
-	"from":    FROM,
-	"fn":      FN,
-	"if":      IF,
-	"else":    ELSE,
-	"foreach": FOREACH,
-	"for":     FOR,
-	"while":   WHILE,
-	"export":  EXPORT,
-	"typeof":  TYPEOF,
-	"in":      IN,
-	"print":   PRINT,
-	"read":    READ,
+	"null":       NULL,
+	"let":        LET,
+	"const":      CONST,
+	"class":      CLASS,
+	"new":        NEW,
+	"import":     IMPORT,
+	"from":       FROM,
+	"fn":         FN,
+	"return":     RETURN,
+	"if":         IF,
+	"else":       ELSE,
+	"foreach":    FOREACH,
+	"for":        FOR,
+	"while":      WHILE,
+	"export":     EXPORT,
+	"typeof":     TYPEOF,
+	"type":       TYPE,
+	"in":         IN,
+	"print":      PRINT,
+	"read":       READ,
+	"throw":      THROW,
+	"try":        TRY,
+	"catch":      CATCH,
+	"finally":    FINALLY,
+	"interface":  INTERFACE,
+	"implements": IMPLEMENTS,
+	"enum":       ENUM,
+	"match":      MATCH,
+}
+
+// IsKeyword reports whether word is a reserved word.
+func IsKeyword(word string) bool {
+	_, exists := reversed_lu[word]
+	return exists
+}
+
+// Position is a location in the source. Offset counts bytes from 0, Line
+// and Column count from 1.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// Span covers the source from Start up to, but not including, End.
+type Span struct {
+	Start Position
+	End   Position
 }
 
 type Token struct {
 	Kind  TokenKind
 	Value string
+	Span  Span
 }
 
 func (token Token) IsOneOfMany(kinds ...TokenKind) bool {
@@ -121,8 +170,8 @@ func TokenKindString(kind TokenKind) string {
 	switch kind {
 	case EOF:
 		return "eof"
-	// case NULL:
-	// 	return "null"
+	case NULL:
+		return "null"
 	case NUMBER:
 		return "number"
 	case STRING:
@@ -165,18 +214,28 @@ func TokenKindString(kind TokenKind) string {
 		return "or"
 	case AND:
 		return "and"
+	case PIPE:
+		return "pipe"
 	case DOT:
 		return "dot"
 	case DOT_DOT:
 		return "dot_dot"
+	case DOT_DOT_EQUALS:
+		return "dot_dot_equals"
 	case SEMI_COLON:
 		return "semi_colon"
 	case COLON:
 		return "colon"
 	case QUESTION:
 		return "question"
+	case QUESTION_DOT:
+		return "question_dot"
+	case NULLISH:
+		return "nullish"
 	case COMMA:
 		return "comma"
+	case ARROW:
+		return "arrow"
 	case PLUS_PLUS:
 		return "plus_plus"
 	case MINUS_MINUS:
@@ -185,8 +244,8 @@ func TokenKindString(kind TokenKind) string {
 		return "plus_equals"
 	case MINUS_EQUALS:
 		return "minus_equals"
-	// case NULLISH_ASSIGNMENT:
-	// 	return "nullish_assignment"
+	case NULLISH_ASSIGNMENT:
+		return "nullish_assignment"
 	case PLUS:
 		return "plus"
 	case DASH:
@@ -211,6 +270,8 @@ func TokenKindString(kind TokenKind) string {
 		return "from"
 	case FN:
 		return "fn"
+	case RETURN:
+		return "return"
 	case IF:
 		return "if"
 	case ELSE:
@@ -223,12 +284,32 @@ func TokenKindString(kind TokenKind) string {
 		return "while"
 	case EXPORT:
 		return "export"
+	case TYPEOF:
+		return "typeof"
+	case TYPE:
+		return "type"
 	case IN:
 		return "in"
 	case PRINT:
 		return "print"
 	case READ:
 		return "read"
+	case THROW:
+		return "throw"
+	case TRY:
+		return "try"
+	case CATCH:
+		return "catch"
+	case FINALLY:
+		return "finally"
+	case INTERFACE:
+		return "interface"
+	case IMPLEMENTS:
+		return "implements"
+	case ENUM:
+		return "enum"
+	case MATCH:
+		return "match"
 	default:
 		return fmt.Sprintf("unknown(%d)", kind)
 	}
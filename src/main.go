@@ -1,11 +1,278 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
 
+	cgen "github.com/RyanOliveira00/go-compiler/src/codegen/c"
+	jsgen "github.com/RyanOliveira00/go-compiler/src/codegen/js"
+	wgen "github.com/RyanOliveira00/go-compiler/src/codegen/wasm"
+	"github.com/RyanOliveira00/go-compiler/src/compiler"
+	"github.com/RyanOliveira00/go-compiler/src/ir"
 	"github.com/RyanOliveira00/go-compiler/src/repl"
 )
 
 func main() {
-	repl.Start(os.Stdin, os.Stdout)
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		runCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "build" {
+		buildCommand(os.Args[2:])
+		return
+	}
+
+	flags := flag.NewFlagSet("repl", flag.ExitOnError)
+	profile, root := profileFlags(flags)
+	flags.Parse(os.Args[1:])
+
+	caps := capabilities(*profile, *root, ".")
+	repl.Start(os.Stdin, os.Stdout, caps)
+}
+
+func runCommand(args []string) {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	profile, root := profileFlags(flags)
+	dump := flags.Bool("dump-optimized", false, "print the program to stderr as it runs after optimization")
+	dumpIR := flags.Bool("dump-ir", false, "print the program's optimized SSA form to stderr before it runs")
+	dumpPasses := flags.Bool("dump-passes", false, "print each function before and after every IR pass changing it to stderr")
+	disabled := passFlag(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: run [flags] <file>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	path := flags.Arg(0)
+
+	c := compiler.New()
+	c.SetCapabilities(capabilities(*profile, *root, filepath.Dir(path)))
+	if *dump {
+		c.DumpOptimized(os.Stderr)
+	}
+	if *dumpIR {
+		c.DumpIR(os.Stderr)
+	}
+	if *dumpPasses {
+		c.IRPasses().Dump = os.Stderr
+	}
+	disablePasses(c.IRPasses(), *disabled)
+
+	// Ctrl-C stops the program between statements instead of killing it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if _, err := c.RunFile(ctx, path); err != nil {
+		var runtimeErr *compiler.RuntimeError
+		if errors.As(err, &runtimeErr) {
+			fmt.Fprintf(os.Stderr, "Error: %s", runtimeErr.Report(readSource))
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func buildCommand(args []string) {
+	flags := flag.NewFlagSet("build", flag.ExitOnError)
+	target := flags.String("target", "c", "language to translate the program to: c, wasm, wat or js")
+	output := flags.String("o", "", "file to write (default: the program's path with the target's extension)")
+	cc := flags.Bool("cc", false, "with --target=c, compile the C file into an executable with $CC (default cc)")
+	disabled := passFlag(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: build [flags] <file>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	path := flags.Arg(0)
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+
+	// JavaScript is translated from the checked program, not from the IR
+	if *target == "js" {
+		if *output == "" {
+			*output = base + ".js"
+		}
+		if err := buildJS(path, *output); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	passes := ir.NewPassManager()
+	disablePasses(passes, *disabled)
+	module, err := lowerFile(path, passes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+
+	switch *target {
+	case "c":
+		if *output == "" {
+			*output = base + ".c"
+		}
+		err = buildC(module, path, *output, *cc, base)
+	case "wasm", "wat":
+		if *output == "" {
+			*output = base + "." + *target
+		}
+		err = buildWasm(module, *output, *target == "wat")
+	default:
+		err = fmt.Errorf("unknown target %s", *target)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// lowerFile checks the program at path and lowers it to IR optimized by
+// passes.
+func lowerFile(path string, passes *ir.PassManager) (*ir.Module, error) {
+	program, err := compiler.CheckFile(path)
+	if err != nil {
+		return nil, err
+	}
+	module, err := ir.Lower(program)
+	if err == nil {
+		err = ir.Verify(module)
+	}
+	if err == nil {
+		err = passes.Run(module)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return module, nil
+}
+
+// buildC writes the C translation of module to output, with the runtime
+// header next to it, and compiles it into executable when compile is set.
+func buildC(module *ir.Module, path, output string, compile bool, executable string) error {
+	source, err := cgen.Generate(module, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(output, []byte(source), 0o644); err != nil {
+		return err
+	}
+	header := filepath.Join(filepath.Dir(output), cgen.RuntimeHeader)
+	if err := os.WriteFile(header, []byte(cgen.Runtime), 0o644); err != nil {
+		return err
+	}
+	if !compile {
+		return nil
+	}
+
+	cc := os.Getenv("CC")
+	if cc == "" {
+		cc = "cc"
+	}
+	cmd := exec.Command(cc, "-std=c99", "-O2", "-fwrapv", "-o", executable, output, "-lm")
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", cc, err)
+	}
+	return nil
+}
+
+// buildWasm writes the WebAssembly translation of module to output, in
+// the text format when text is set.
+func buildWasm(module *ir.Module, output string, text bool) error {
+	wasm, err := wgen.Generate(module)
+	if err != nil {
+		return err
+	}
+	contents := wasm.Binary()
+	if text {
+		contents = []byte(wasm.Text())
+	}
+	return os.WriteFile(output, contents, 0o644)
+}
+
+// buildJS writes the modules translating the program at path and the
+// modules it imports, the program's to output, with their source maps
+// and the runtime next to them.
+func buildJS(path, output string) error {
+	modules, err := compiler.CheckModules(path)
+	if err != nil {
+		return err
+	}
+	files, err := jsgen.Generate(modules, output)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.MkdirAll(filepath.Dir(file.Path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file.Path, []byte(file.Code), 0o644); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file.Path+".map", []byte(file.SourceMap), 0o644); err != nil {
+			return err
+		}
+	}
+	runtime := filepath.Join(filepath.Dir(output), jsgen.RuntimeModule)
+	return os.WriteFile(runtime, []byte(jsgen.Runtime), 0o644)
+}
+
+func passFlag(flags *flag.FlagSet) *string {
+	return flags.String("disable-passes", "", "comma-separated IR passes not to run: "+strings.Join(ir.PassNames(), ", "))
+}
+
+// disablePasses turns off the passes listed in list, exiting on an
+// unknown one.
+func disablePasses(passes *ir.PassManager, list string) {
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if err := passes.Enable(name, false); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(2)
+		}
+	}
+}
+
+func readSource(path string) (string, bool) {
+	source, err := os.ReadFile(path)
+	return string(source), err == nil
+}
+
+func profileFlags(flags *flag.FlagSet) (*string, *string) {
+	profile := flags.String("profile", "trusted", "capability profile: "+strings.Join(compiler.Profiles, ", "))
+	root := flags.String("root", "", "directory the readonly profile may read (default: the program's directory)")
+	return profile, root
+}
+
+// capabilities resolves the profile flags, exiting on an unknown profile.
+func capabilities(profile, root, defaultRoot string) compiler.Capabilities {
+	if root == "" {
+		root = defaultRoot
+	}
+
+	caps, err := compiler.Profile(profile, root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(2)
+	}
+	return caps
 }
@@ -0,0 +1,259 @@
+package optimize
+
+import (
+	"math"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+// constant is the value of a literal as the interpreter sees it: an
+// int64, float64 or string.
+func constant(expr ast.Expr) (interface{}, bool) {
+	switch e := expr.(type) {
+	case ast.NumberExpr:
+		if e.Float {
+			return e.Value, true
+		}
+		return e.Int, true
+	case ast.StringExpr:
+		return e.Value, true
+	default:
+		return nil, false
+	}
+}
+
+// literal is the expression producing value at span, if it has one.
+func literal(value interface{}, span lexer.Span) (ast.Expr, bool) {
+	node := ast.Node{Loc: span}
+	switch v := value.(type) {
+	case int64:
+		return ast.NumberExpr{Node: node, Int: v}, true
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, false
+		}
+		return ast.NumberExpr{Node: node, Value: v, Float: true}, true
+	case string:
+		return ast.StringExpr{Node: node, Value: v}, true
+	default:
+		return nil, false
+	}
+}
+
+func withSpan(expr ast.Expr, span lexer.Span) ast.Expr {
+	switch e := expr.(type) {
+	case ast.NumberExpr:
+		e.Node = ast.Node{Loc: span}
+		return e
+	case ast.StringExpr:
+		e.Node = ast.Node{Loc: span}
+		return e
+	default:
+		return expr
+	}
+}
+
+func foldPrefix(e ast.PrefixExpr) ast.Expr {
+	value, ok := constant(e.RightExpr)
+	if !ok {
+		return e
+	}
+
+	var result interface{}
+	switch v := value.(type) {
+	case int64:
+		switch e.Operator.Kind {
+		case lexer.DASH:
+			result = -v
+		case lexer.PLUS:
+			result = v
+		}
+	case float64:
+		switch e.Operator.Kind {
+		case lexer.DASH:
+			result = -v
+		case lexer.PLUS:
+			result = v
+		}
+	}
+
+	if folded, ok := literal(result, e.Span()); ok {
+		return folded
+	}
+	return e
+}
+
+func foldBinary(e ast.BinaryExpr) ast.Expr {
+	left, lok := constant(e.Left)
+	right, rok := constant(e.Right)
+	if !lok || !rok {
+		return e
+	}
+
+	if folded, ok := literal(arithmetic(e.Operator.Kind, left, right), e.Span()); ok {
+		return folded
+	}
+	return e
+}
+
+// arithmetic computes left op right for +, -, *, / and %, returning nil
+// when the operation is not folded. Division by zero is left to fail at
+// run time.
+func arithmetic(op lexer.TokenKind, left, right interface{}) interface{} {
+	if l, ok := left.(string); ok {
+		if r, ok := right.(string); ok && op == lexer.PLUS {
+			return l + r
+		}
+		return nil
+	}
+
+	if l, ok := left.(int64); ok {
+		if r, ok := right.(int64); ok {
+			switch op {
+			case lexer.PLUS:
+				return l + r
+			case lexer.DASH:
+				return l - r
+			case lexer.STAR:
+				return l * r
+			case lexer.SLASH:
+				if r != 0 {
+					return l / r
+				}
+			case lexer.PERCENT:
+				if r != 0 {
+					return l % r
+				}
+			}
+			return nil
+		}
+	}
+
+	l, lok := number(left)
+	r, rok := number(right)
+	if !lok || !rok {
+		return nil
+	}
+	switch op {
+	case lexer.PLUS:
+		return l + r
+	case lexer.DASH:
+		return l - r
+	case lexer.STAR:
+		return l * r
+	case lexer.SLASH:
+		if r != 0 {
+			return l / r
+		}
+	case lexer.PERCENT:
+		if r != 0 {
+			return math.Mod(l, r)
+		}
+	}
+	return nil
+}
+
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// truth evaluates a condition built from literals, comparisons, !, && and
+// ||, with the interpreter's notion of truthiness.
+func truth(expr ast.Expr) (bool, bool) {
+	if value, ok := constant(expr); ok {
+		switch v := value.(type) {
+		case int64:
+			return v != 0, true
+		case float64:
+			return v != 0, true
+		case string:
+			return v != "", true
+		}
+	}
+
+	switch e := expr.(type) {
+	case ast.NullExpr:
+		return false, true
+	case ast.PrefixExpr:
+		if e.Operator.Kind != lexer.NOT {
+			return false, false
+		}
+		value, known := truth(e.RightExpr)
+		return !value, known
+	case ast.BinaryExpr:
+		return binaryTruth(e)
+	default:
+		return false, false
+	}
+}
+
+func binaryTruth(e ast.BinaryExpr) (bool, bool) {
+	switch e.Operator.Kind {
+	case lexer.AND, lexer.OR:
+		left, known := truth(e.Left)
+		if !known {
+			return false, false
+		}
+		// false && x and true || x do not evaluate x
+		if left == (e.Operator.Kind == lexer.OR) {
+			return left, true
+		}
+		return truth(e.Right)
+	}
+
+	left, lok := constant(e.Left)
+	right, rok := constant(e.Right)
+	if !lok || !rok {
+		return false, false
+	}
+
+	ls, lstring := left.(string)
+	rs, rstring := right.(string)
+	if lstring != rstring {
+		return false, false
+	}
+	if lstring {
+		switch e.Operator.Kind {
+		case lexer.EQUALS:
+			return ls == rs, true
+		case lexer.NOT_EQUALS:
+			return ls != rs, true
+		case lexer.LESS:
+			return ls < rs, true
+		case lexer.LESS_EQUALS:
+			return ls <= rs, true
+		case lexer.GREATER:
+			return ls > rs, true
+		case lexer.GREATER_EQUALS:
+			return ls >= rs, true
+		}
+		return false, false
+	}
+
+	l, _ := number(left)
+	r, _ := number(right)
+	switch e.Operator.Kind {
+	case lexer.EQUALS:
+		return l == r, true
+	case lexer.NOT_EQUALS:
+		return l != r, true
+	case lexer.LESS:
+		return l < r, true
+	case lexer.LESS_EQUALS:
+		return l <= r, true
+	case lexer.GREATER:
+		return l > r, true
+	case lexer.GREATER_EQUALS:
+		return l >= r, true
+	default:
+		return false, false
+	}
+}
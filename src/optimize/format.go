@@ -0,0 +1,424 @@
+package optimize
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+// Format prints program as source code, as shown by --dump-optimized.
+// The output is meant to be read rather than parsed again: a float folded
+// to a whole number is printed as 2.0, which the parser reads as an int,
+// and a block left by a removed if is printed on its own.
+func Format(program ast.BlockStmt) string {
+	f := &formatter{}
+	for _, stmt := range program.Body {
+		f.stmt(stmt)
+	}
+	return f.String()
+}
+
+type formatter struct {
+	strings.Builder
+	indent int
+}
+
+func (f *formatter) line(format string, args ...interface{}) {
+	f.WriteString(strings.Repeat("    ", f.indent))
+	fmt.Fprintf(f, format, args...)
+	f.WriteString("\n")
+}
+
+// block prints { body } followed by suffix, the opening brace ending the
+// line started by header.
+func (f *formatter) block(header string, body ast.BlockStmt, suffix string) {
+	f.line("%s{", header)
+	f.body(body)
+	f.line("}%s", suffix)
+}
+
+func (f *formatter) body(body ast.BlockStmt) {
+	f.indent++
+	for _, stmt := range body.Body {
+		f.stmt(stmt)
+	}
+	f.indent--
+}
+
+// nested prints what print writes at the current indentation, without
+// the indentation of its first line, to embed it in an expression.
+func (f *formatter) nested(print func(inner *formatter)) string {
+	inner := &formatter{indent: f.indent}
+	print(inner)
+	text := strings.TrimSuffix(inner.String(), "\n")
+	return strings.TrimPrefix(text, strings.Repeat("    ", f.indent))
+}
+
+func (f *formatter) stmt(stmt ast.Stmt) {
+	switch s := stmt.(type) {
+	case ast.ExportStmt:
+		f.line("export %s", f.nested(func(inner *formatter) { inner.stmt(s.Declaration) }))
+	case ast.ExprStmt:
+		f.line("%s;", f.expr(s.Expression))
+	case ast.VarDeclStmt:
+		f.line("%s;", f.varDecl(s))
+	case ast.IfStmt:
+		if s.Alternative == nil {
+			f.block(fmt.Sprintf("if (%s) ", f.expr(s.Condition)), s.Consequence, ";")
+			return
+		}
+		f.line("if (%s) {", f.expr(s.Condition))
+		f.body(s.Consequence)
+		f.line("} else {")
+		f.body(*s.Alternative)
+		f.line("};")
+	case ast.WhileStmt:
+		f.block(fmt.Sprintf("while (%s) ", f.expr(s.Condition)), s.Body, "")
+	case ast.ForeachStmt:
+		names := s.KeyName
+		if s.ValueName != "" {
+			names += ", " + s.ValueName
+		}
+		f.block(fmt.Sprintf("foreach %s in %s ", names, f.expr(s.Iterable)), s.Body, "")
+	case ast.BlockStmt:
+		f.block("", s, "")
+	case ast.PrintStmt:
+		f.line("print(%s);", f.expr(s.Expression))
+	case ast.ReadStmt:
+		f.line("read(%s);", f.expr(s.Target))
+	case ast.ReturnStmt:
+		if s.Value == nil {
+			f.line("return;")
+			return
+		}
+		f.line("return %s;", f.expr(s.Value))
+	case ast.ThrowStmt:
+		f.line("throw %s;", f.expr(s.Value))
+	case ast.TryStmt:
+		f.block("try ", s.Body, "")
+		if s.Catch != nil {
+			f.block(fmt.Sprintf("catch (%s) ", s.CatchName), *s.Catch, "")
+		}
+		if s.Finally != nil {
+			f.block("finally ", *s.Finally, "")
+		}
+	case ast.FunctionDeclStmt:
+		f.block(signature("fn "+s.Name, s.TypeParams, s.Parameters, s.ReturnType)+" ", s.Body, "")
+	case ast.ClassDeclStmt:
+		f.class(s)
+	case ast.TypeDeclStmt:
+		if iface, ok := s.Type.(ast.InterfaceType); ok {
+			f.line("interface %s {", s.Name)
+			f.indent++
+			for _, method := range iface.Methods {
+				f.line("%s;", signature("fn "+method.Name, nil, method.Parameters, method.ReturnType))
+			}
+			f.indent--
+			f.line("}")
+			return
+		}
+		f.line("type %s = %s;", s.Name, typeString(s.Type))
+	case ast.EnumDeclStmt:
+		variants := make([]string, 0, len(s.Variants))
+		for _, variant := range s.Variants {
+			if variant.Payload == nil {
+				variants = append(variants, variant.Name)
+				continue
+			}
+			variants = append(variants, variant.Name+"("+typeList(variant.Payload)+")")
+		}
+		f.line("enum %s { %s }", s.Name, strings.Join(variants, ", "))
+	case ast.MatchStmt:
+		f.line("match (%s) {", f.expr(s.Subject))
+		f.indent++
+		f.arms(s.Arms)
+		f.indent--
+		f.line("}")
+	case ast.ImportStmt:
+		f.line("import { %s } from \"%s\";", strings.Join(s.Names, ", "), s.Path)
+	default:
+		f.line("/* %T */", stmt)
+	}
+}
+
+func (f *formatter) class(s ast.ClassDeclStmt) {
+	header := "class " + s.Name + typeParams(s.TypeParams)
+	if len(s.Implements) > 0 {
+		header += " implements " + strings.Join(s.Implements, ", ")
+	}
+	f.line("%s {", header)
+	f.indent++
+	for _, field := range s.Fields {
+		f.line("%s;", f.varDecl(field))
+	}
+	for _, method := range s.Methods {
+		f.block(signature("fn "+method.Name, method.TypeParams, method.Parameters, method.ReturnType)+" ", method.Body, "")
+	}
+	f.indent--
+	f.line("}")
+}
+
+func (f *formatter) arms(arms []ast.MatchArm) {
+	for _, arm := range arms {
+		header := f.patterns(arm.Patterns) + " => "
+		if arm.Body != nil {
+			f.block(header, *arm.Body, ",")
+			continue
+		}
+		f.line("%s%s,", header, f.expr(arm.Value))
+	}
+}
+
+func (f *formatter) varDecl(s ast.VarDeclStmt) string {
+	keyword := "let"
+	if s.IsConstant {
+		keyword = "const"
+	}
+	text := keyword + " " + s.VariableName
+	if s.ExplicitType != nil {
+		text += ": " + typeString(s.ExplicitType)
+	}
+	if s.AssignedValue != nil {
+		text += " = " + f.expr(s.AssignedValue)
+	}
+	return text
+}
+
+func signature(name string, params []ast.TypeParam, parameters []ast.Parameter, returnType ast.Type) string {
+	list := make([]string, 0, len(parameters))
+	for _, param := range parameters {
+		list = append(list, param.Name+": "+typeString(param.Type))
+	}
+	text := name + typeParams(params) + "(" + strings.Join(list, ", ") + ")"
+	if returnType != nil {
+		text += ": " + typeString(returnType)
+	}
+	return text
+}
+
+func typeParams(params []ast.TypeParam) string {
+	if len(params) == 0 {
+		return ""
+	}
+	list := make([]string, 0, len(params))
+	for _, param := range params {
+		if param.Constraint == nil {
+			list = append(list, param.Name)
+			continue
+		}
+		list = append(list, param.Name+": "+typeString(param.Constraint))
+	}
+	return "<" + strings.Join(list, ", ") + ">"
+}
+
+func typeString(t ast.Type) string {
+	switch t := t.(type) {
+	case ast.SymbolType:
+		return t.Name
+	case ast.ArrayType:
+		return "[]" + typeString(t.Underlying)
+	case ast.MapType:
+		return "map[" + typeString(t.Key) + "]" + typeString(t.Value)
+	case ast.OptionalType:
+		switch t.Underlying.(type) {
+		case ast.UnionType, ast.FunctionType:
+			return "(" + typeString(t.Underlying) + ")?"
+		}
+		return typeString(t.Underlying) + "?"
+	case ast.UnionType:
+		list := make([]string, 0, len(t.Types))
+		for _, member := range t.Types {
+			list = append(list, typeString(member))
+		}
+		return strings.Join(list, " | ")
+	case ast.FunctionType:
+		text := "fn(" + typeList(t.Params) + ")"
+		if t.Return != nil {
+			text += ": " + typeString(t.Return)
+		}
+		return text
+	case ast.StructType:
+		fields := make([]string, 0, len(t.Fields))
+		for _, field := range t.Fields {
+			fields = append(fields, field.Name+": "+typeString(field.Type))
+		}
+		return "{ " + strings.Join(fields, ", ") + " }"
+	case ast.GenericType:
+		return t.Name + "<" + typeList(t.Args) + ">"
+	case ast.TypeParam:
+		return t.Name
+	default:
+		return fmt.Sprintf("/* %T */", t)
+	}
+}
+
+func typeList(types []ast.Type) string {
+	list := make([]string, 0, len(types))
+	for _, t := range types {
+		list = append(list, typeString(t))
+	}
+	return strings.Join(list, ", ")
+}
+
+func (f *formatter) patterns(list []ast.Pattern) string {
+	texts := make([]string, 0, len(list))
+	for _, pattern := range list {
+		switch p := pattern.(type) {
+		case ast.WildcardPattern:
+			texts = append(texts, "_")
+		case ast.BindingPattern:
+			texts = append(texts, p.Name)
+		case ast.LiteralPattern:
+			texts = append(texts, f.expr(p.Value))
+		case ast.RangePattern:
+			operator := ".."
+			if p.Inclusive {
+				operator = "..="
+			}
+			texts = append(texts, f.expr(p.Start)+operator+f.expr(p.End))
+		case ast.VariantPattern:
+			text := p.Enum + "." + p.Variant
+			if p.Bindings != nil {
+				text += "(" + strings.Join(p.Bindings, ", ") + ")"
+			}
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, " | ")
+}
+
+// precedence mirrors the binding powers of the parser, so operands are
+// parenthesized only where the source needs it.
+func precedence(expr ast.Expr) int {
+	switch e := expr.(type) {
+	case ast.AssignmentExpr:
+		return 1
+	case ast.TernaryExpr:
+		return 2
+	case ast.BinaryExpr:
+		switch e.Operator.Kind {
+		case lexer.AND, lexer.OR, lexer.NULLISH:
+			return 3
+		case lexer.PLUS, lexer.DASH:
+			return 6
+		case lexer.STAR, lexer.SLASH, lexer.PERCENT:
+			return 7
+		default:
+			return 4
+		}
+	case ast.RangeExpr:
+		return 5
+	case ast.PrefixExpr:
+		return 8
+	case ast.NumberExpr:
+		if e.Int < 0 || e.Value < 0 {
+			return 8
+		}
+	}
+	return 9
+}
+
+// operand prints expr, parenthesized when it binds looser than min.
+func (f *formatter) operand(e ast.Expr, min int) string {
+	if precedence(e) < min {
+		return "(" + f.expr(e) + ")"
+	}
+	return f.expr(e)
+}
+
+func (f *formatter) expr(e ast.Expr) string {
+	switch e := e.(type) {
+	case ast.NumberExpr:
+		if !e.Float {
+			return strconv.FormatInt(e.Int, 10)
+		}
+		text := strconv.FormatFloat(e.Value, 'f', -1, 64)
+		if !strings.Contains(text, ".") {
+			text += ".0"
+		}
+		return text
+	case ast.StringExpr:
+		return "\"" + e.Value + "\""
+	case ast.SymbolExpr:
+		return e.Value
+	case ast.NullExpr:
+		return "null"
+	case ast.ArrayLiteralExpr:
+		return "[" + f.exprList(e.Elements) + "]"
+	case ast.MapLiteralExpr:
+		entries := make([]string, 0, len(e.Entries))
+		for _, entry := range e.Entries {
+			entries = append(entries, f.expr(entry.Key)+": "+f.expr(entry.Value))
+		}
+		return "{ " + strings.Join(entries, ", ") + " }"
+	case ast.StructLiteralExpr:
+		fields := make([]string, 0, len(e.Fields))
+		for _, field := range e.Fields {
+			fields = append(fields, field.Name+": "+f.expr(field.Value))
+		}
+		return e.TypeName + " { " + strings.Join(fields, ", ") + " }"
+	case ast.BinaryExpr:
+		p := precedence(e)
+		return f.operand(e.Left, p) + " " + e.Operator.Value + " " + f.operand(e.Right, p+1)
+	case ast.PrefixExpr:
+		operator := e.Operator.Value
+		if e.Operator.Kind == lexer.TYPEOF {
+			operator += " "
+		}
+		return operator + f.operand(e.RightExpr, 9)
+	case ast.AssignmentExpr:
+		return f.expr(e.Assigne) + " " + e.Operator.Value + " " + f.operand(e.Value, 1)
+	case ast.TernaryExpr:
+		return f.operand(e.Condition, 3) + " ? " + f.operand(e.Consequent, 3) + " : " + f.operand(e.Alternate, 2)
+	case ast.IndexExpr:
+		return f.operand(e.Target, 9) + "[" + f.expr(e.Index) + "]"
+	case ast.RangeExpr:
+		operator := ".."
+		if e.Inclusive {
+			operator = "..="
+		}
+		text := f.operand(e.Start, 6) + operator + f.operand(e.End, 6)
+		if e.Step != nil {
+			text += " step " + f.operand(e.Step, 6)
+		}
+		return text
+	case ast.CallExpr:
+		return f.operand(e.Callee, 9) + "(" + f.exprList(e.Arguments) + ")"
+	case ast.MemberExpr:
+		if e.Optional {
+			return f.operand(e.Object, 9) + "?." + e.Property
+		}
+		return f.operand(e.Object, 9) + "." + e.Property
+	case ast.NewExpr:
+		name := e.ClassName
+		if len(e.TypeArgs) > 0 {
+			name += "<" + typeList(e.TypeArgs) + ">"
+		}
+		return "new " + name + "(" + f.exprList(e.Arguments) + ")"
+	case ast.FunctionExpr:
+		return f.nested(func(inner *formatter) {
+			inner.block(signature("fn ", nil, e.Parameters, e.ReturnType)+" ", e.Body, "")
+		})
+	case ast.MatchExpr:
+		return f.nested(func(inner *formatter) {
+			inner.line("match (%s) {", inner.expr(e.Subject))
+			inner.indent++
+			inner.arms(e.Arms)
+			inner.indent--
+			inner.line("}")
+		})
+	default:
+		return fmt.Sprintf("/* %T */", e)
+	}
+}
+
+func (f *formatter) exprList(exprs []ast.Expr) string {
+	list := make([]string, 0, len(exprs))
+	for _, e := range exprs {
+		list = append(list, f.expr(e))
+	}
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,492 @@
+// Package optimize simplifies a checked program before it runs. It folds
+// constant arithmetic, replaces uses of constants bound to literals with
+// the literal, drops identities such as x * 1 and, for ints, x + 0, and
+// removes the branches of if statements and ternaries whose condition is
+// constant.
+//
+// Operations that fail at run time, like a division by zero, are left
+// for the backend to report. A constant used through a hoisted function
+// called before the declaration has run sees its value instead of
+// failing as undefined.
+package optimize
+
+import (
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/checker"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+// Program returns the optimized form of a program that passed the type
+// checker, given the types it recorded for the program's expressions.
+// The original tree is left untouched.
+func Program(program ast.BlockStmt, types map[lexer.Span]checker.Type) ast.BlockStmt {
+	o := &optimizer{scope: newScope(nil), types: types}
+	return ast.BlockStmt{Body: o.stmts(program.Body)}
+}
+
+// scope maps the names visible at a point of the program to the literal
+// they are bound to, or nil for variables and shadowed constants.
+type scope struct {
+	consts map[string]ast.Expr
+	outer  *scope
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{consts: make(map[string]ast.Expr), outer: outer}
+}
+
+func (s *scope) lookup(name string) ast.Expr {
+	for ; s != nil; s = s.outer {
+		if value, exists := s.consts[name]; exists {
+			return value
+		}
+	}
+	return nil
+}
+
+type optimizer struct {
+	scope *scope
+	types map[lexer.Span]checker.Type
+}
+
+// nested runs f in a new scope.
+func (o *optimizer) nested(f func()) {
+	outer := o.scope
+	o.scope = newScope(outer)
+	defer func() { o.scope = outer }()
+	f()
+}
+
+func (o *optimizer) declare(names ...string) {
+	for _, name := range names {
+		if name != "" {
+			o.scope.consts[name] = nil
+		}
+	}
+}
+
+func (o *optimizer) block(block ast.BlockStmt) ast.BlockStmt {
+	var body []ast.Stmt
+	o.nested(func() { body = o.stmts(block.Body) })
+	return ast.BlockStmt{Body: body}
+}
+
+func (o *optimizer) stmts(stmts []ast.Stmt) []ast.Stmt {
+	// Functions and classes are hoisted, their names shadow outer constants
+	// from the start of the block
+	for _, stmt := range stmts {
+		switch s := unwrapExport(stmt).(type) {
+		case ast.FunctionDeclStmt:
+			o.declare(s.Name)
+		case ast.ClassDeclStmt:
+			o.declare(s.Name)
+		case ast.EnumDeclStmt:
+			o.declare(s.Name)
+		case ast.ImportStmt:
+			o.declare(s.Names...)
+		}
+	}
+
+	result := make([]ast.Stmt, 0, len(stmts))
+	for _, stmt := range stmts {
+		result = append(result, o.stmt(stmt)...)
+	}
+	return result
+}
+
+// stmt returns the statements replacing stmt, none when it is dead.
+func (o *optimizer) stmt(stmt ast.Stmt) []ast.Stmt {
+	switch s := stmt.(type) {
+	case ast.ExportStmt:
+		s.Declaration = o.stmt(s.Declaration)[0]
+		return []ast.Stmt{s}
+	case ast.ExprStmt:
+		s.Expression = o.expr(s.Expression)
+		return []ast.Stmt{s}
+	case ast.VarDeclStmt:
+		return []ast.Stmt{o.varDecl(s)}
+	case ast.IfStmt:
+		return o.ifStmt(s)
+	case ast.WhileStmt:
+		s.Condition = o.expr(s.Condition)
+		s.Body = o.block(s.Body)
+		return []ast.Stmt{s}
+	case ast.ForeachStmt:
+		s.Iterable = o.expr(s.Iterable)
+		o.nested(func() {
+			o.declare(s.KeyName, s.ValueName)
+			s.Body = ast.BlockStmt{Body: o.stmts(s.Body.Body)}
+		})
+		return []ast.Stmt{s}
+	case ast.BlockStmt:
+		return []ast.Stmt{o.block(s)}
+	case ast.PrintStmt:
+		s.Expression = o.expr(s.Expression)
+		return []ast.Stmt{s}
+	case ast.ReadStmt:
+		s.Target = o.target(s.Target)
+		return []ast.Stmt{s}
+	case ast.ReturnStmt:
+		if s.Value != nil {
+			s.Value = o.expr(s.Value)
+		}
+		return []ast.Stmt{s}
+	case ast.ThrowStmt:
+		s.Value = o.expr(s.Value)
+		return []ast.Stmt{s}
+	case ast.TryStmt:
+		s.Body = o.block(s.Body)
+		if s.Catch != nil {
+			o.nested(func() {
+				o.declare(s.CatchName)
+				catch := ast.BlockStmt{Body: o.stmts(s.Catch.Body)}
+				s.Catch = &catch
+			})
+		}
+		if s.Finally != nil {
+			finally := o.block(*s.Finally)
+			s.Finally = &finally
+		}
+		return []ast.Stmt{s}
+	case ast.FunctionDeclStmt:
+		s.Body = o.function(s.Parameters, s.Body)
+		return []ast.Stmt{s}
+	case ast.ClassDeclStmt:
+		return []ast.Stmt{o.class(s)}
+	case ast.MatchStmt:
+		s.Subject = o.expr(s.Subject)
+		s.Arms = o.arms(s.Arms)
+		return []ast.Stmt{s}
+	default:
+		// Imports and type, interface and enum declarations hold no
+		// expressions
+		return []ast.Stmt{stmt}
+	}
+}
+
+// varDecl records the literal a constant is bound to, so later uses can
+// be replaced by it.
+func (o *optimizer) varDecl(s ast.VarDeclStmt) ast.Stmt {
+	if s.AssignedValue != nil {
+		s.AssignedValue = o.expr(s.AssignedValue)
+	}
+	o.scope.consts[s.VariableName] = nil
+	if s.IsConstant {
+		o.scope.consts[s.VariableName] = constLiteral(s.ExplicitType, s.AssignedValue)
+	}
+	return s
+}
+
+// constLiteral is the literal a constant of type t initialized with value
+// can be replaced with, nil when it holds anything else.
+func constLiteral(t ast.Type, value ast.Expr) ast.Expr {
+	var name string
+	if t != nil {
+		symbol, ok := t.(ast.SymbolType)
+		if !ok {
+			return nil
+		}
+		name = symbol.Name
+	}
+
+	switch v := value.(type) {
+	case ast.NumberExpr:
+		switch name {
+		case "":
+			return v
+		case "int":
+			if !v.Float {
+				return v
+			}
+		case "float":
+			if !v.Float {
+				v.Value, v.Int, v.Float = float64(v.Int), 0, true
+			}
+			return v
+		}
+	case ast.StringExpr:
+		if name == "" || name == "string" {
+			return v
+		}
+	}
+	return nil
+}
+
+// ifStmt keeps only the branch that runs when the condition is constant.
+// The branch is spliced into the enclosing block unless its declarations
+// need a scope of their own.
+func (o *optimizer) ifStmt(s ast.IfStmt) []ast.Stmt {
+	s.Condition = o.expr(s.Condition)
+
+	truthy, known := truth(s.Condition)
+	if !known {
+		s.Consequence = o.block(s.Consequence)
+		if s.Alternative != nil {
+			alternative := o.block(*s.Alternative)
+			s.Alternative = &alternative
+		}
+		return []ast.Stmt{s}
+	}
+
+	var live ast.BlockStmt
+	switch {
+	case truthy:
+		live = o.block(s.Consequence)
+	case s.Alternative != nil:
+		live = o.block(*s.Alternative)
+	default:
+		return nil
+	}
+	if declares(live) {
+		return []ast.Stmt{live}
+	}
+	return live.Body
+}
+
+// declares reports whether block declares names of its own.
+func declares(block ast.BlockStmt) bool {
+	for _, stmt := range block.Body {
+		switch stmt.(type) {
+		case ast.VarDeclStmt, ast.FunctionDeclStmt, ast.ClassDeclStmt, ast.TypeDeclStmt, ast.EnumDeclStmt:
+			return true
+		}
+	}
+	return false
+}
+
+func (o *optimizer) function(params []ast.Parameter, body ast.BlockStmt) ast.BlockStmt {
+	var result ast.BlockStmt
+	o.nested(func() {
+		for _, param := range params {
+			o.declare(param.Name)
+		}
+		result = ast.BlockStmt{Body: o.stmts(body.Body)}
+	})
+	return result
+}
+
+func (o *optimizer) class(s ast.ClassDeclStmt) ast.ClassDeclStmt {
+	fields := make([]ast.VarDeclStmt, 0, len(s.Fields))
+	for _, field := range s.Fields {
+		if field.AssignedValue != nil {
+			field.AssignedValue = o.expr(field.AssignedValue)
+		}
+		fields = append(fields, field)
+	}
+	s.Fields = fields
+
+	methods := make([]ast.FunctionDeclStmt, 0, len(s.Methods))
+	for _, method := range s.Methods {
+		method.Body = o.function(method.Parameters, method.Body)
+		methods = append(methods, method)
+	}
+	s.Methods = methods
+	return s
+}
+
+func (o *optimizer) arms(arms []ast.MatchArm) []ast.MatchArm {
+	result := make([]ast.MatchArm, 0, len(arms))
+	for _, arm := range arms {
+		patterns := make([]ast.Pattern, 0, len(arm.Patterns))
+		for _, pattern := range arm.Patterns {
+			patterns = append(patterns, o.pattern(pattern))
+		}
+		arm.Patterns = patterns
+
+		o.nested(func() {
+			for _, pattern := range arm.Patterns {
+				switch p := pattern.(type) {
+				case ast.BindingPattern:
+					o.declare(p.Name)
+				case ast.VariantPattern:
+					o.declare(p.Bindings...)
+				}
+			}
+			if arm.Body != nil {
+				body := ast.BlockStmt{Body: o.stmts(arm.Body.Body)}
+				arm.Body = &body
+			} else {
+				arm.Value = o.expr(arm.Value)
+			}
+		})
+		result = append(result, arm)
+	}
+	return result
+}
+
+func (o *optimizer) pattern(pattern ast.Pattern) ast.Pattern {
+	switch p := pattern.(type) {
+	case ast.LiteralPattern:
+		p.Value = o.expr(p.Value)
+		return p
+	case ast.RangePattern:
+		p.Start = o.expr(p.Start)
+		p.End = o.expr(p.End)
+		return p
+	default:
+		return pattern
+	}
+}
+
+// target optimizes the operands of an assigned expression, leaving the
+// assigned variable itself in place.
+func (o *optimizer) target(expr ast.Expr) ast.Expr {
+	if _, ok := expr.(ast.SymbolExpr); ok {
+		return expr
+	}
+	return o.expr(expr)
+}
+
+func (o *optimizer) expr(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case ast.SymbolExpr:
+		if literal := o.scope.lookup(e.Value); literal != nil {
+			return withSpan(literal, e.Span())
+		}
+		return e
+	case ast.PrefixExpr:
+		e.RightExpr = o.expr(e.RightExpr)
+		return foldPrefix(e)
+	case ast.BinaryExpr:
+		e.Left = o.expr(e.Left)
+		e.Right = o.expr(e.Right)
+		return o.simplify(foldBinary(e))
+	case ast.TernaryExpr:
+		e.Condition = o.expr(e.Condition)
+		e.Consequent = o.expr(e.Consequent)
+		e.Alternate = o.expr(e.Alternate)
+		if truthy, known := truth(e.Condition); known {
+			branch := e.Alternate
+			if truthy {
+				branch = e.Consequent
+			}
+			return o.branch(e, branch)
+		}
+		return e
+	case ast.AssignmentExpr:
+		e.Assigne = o.target(e.Assigne)
+		e.Value = o.expr(e.Value)
+		return e
+	case ast.ArrayLiteralExpr:
+		e.Elements = o.exprs(e.Elements)
+		return e
+	case ast.MapLiteralExpr:
+		entries := make([]ast.MapEntry, 0, len(e.Entries))
+		for _, entry := range e.Entries {
+			entries = append(entries, ast.MapEntry{Key: o.expr(entry.Key), Value: o.expr(entry.Value)})
+		}
+		e.Entries = entries
+		return e
+	case ast.StructLiteralExpr:
+		fields := make([]ast.StructLiteralField, 0, len(e.Fields))
+		for _, field := range e.Fields {
+			fields = append(fields, ast.StructLiteralField{Name: field.Name, Value: o.expr(field.Value)})
+		}
+		e.Fields = fields
+		return e
+	case ast.IndexExpr:
+		e.Target = o.expr(e.Target)
+		e.Index = o.expr(e.Index)
+		return e
+	case ast.RangeExpr:
+		e.Start = o.expr(e.Start)
+		e.End = o.expr(e.End)
+		if e.Step != nil {
+			e.Step = o.expr(e.Step)
+		}
+		return e
+	case ast.CallExpr:
+		e.Callee = o.expr(e.Callee)
+		e.Arguments = o.exprs(e.Arguments)
+		return e
+	case ast.MemberExpr:
+		e.Object = o.expr(e.Object)
+		return e
+	case ast.NewExpr:
+		e.Arguments = o.exprs(e.Arguments)
+		return e
+	case ast.FunctionExpr:
+		e.Body = o.function(e.Parameters, e.Body)
+		return e
+	case ast.MatchExpr:
+		e.Subject = o.expr(e.Subject)
+		e.Arms = o.arms(e.Arms)
+		return e
+	default:
+		return expr
+	}
+}
+
+// branch replaces the ternary e by the branch its constant condition
+// picks, as long as that keeps its type: 1 > 0 ? 3 : 2.5 is the float 3.
+func (o *optimizer) branch(e ast.TernaryExpr, branch ast.Expr) ast.Expr {
+	t, branchType := o.types[e.Span()], o.types[branch.Span()]
+	if t == nil || branchType != nil && checker.Identical(t, branchType) {
+		return branch
+	}
+	if n, ok := branch.(ast.NumberExpr); ok && !n.Float && t == checker.Float {
+		if float, ok := literal(float64(n.Int), e.Span()); ok {
+			return float
+		}
+	}
+	return e
+}
+
+func (o *optimizer) exprs(exprs []ast.Expr) []ast.Expr {
+	result := make([]ast.Expr, 0, len(exprs))
+	for _, expr := range exprs {
+		result = append(result, o.expr(expr))
+	}
+	return result
+}
+
+// simplify drops the identity operand of x * 1, 1 * x and x / 1, and of
+// x + 0, 0 + x and x - 0 when x is an int. The checker only allows these
+// operators between numbers, for which the result is x itself, but
+// -0.0 + 0 is 0, so the additive ones are kept for floats.
+func (o *optimizer) simplify(expr ast.Expr) ast.Expr {
+	e, ok := expr.(ast.BinaryExpr)
+	if !ok {
+		return expr
+	}
+
+	switch e.Operator.Kind {
+	case lexer.STAR:
+		if isInt(e.Right, 1) {
+			return e.Left
+		}
+		if isInt(e.Left, 1) {
+			return e.Right
+		}
+	case lexer.SLASH:
+		if isInt(e.Right, 1) {
+			return e.Left
+		}
+	case lexer.PLUS:
+		if isInt(e.Right, 0) && o.types[e.Left.Span()] == checker.Int {
+			return e.Left
+		}
+		if isInt(e.Left, 0) && o.types[e.Right.Span()] == checker.Int {
+			return e.Right
+		}
+	case lexer.DASH:
+		if isInt(e.Right, 0) && o.types[e.Left.Span()] == checker.Int {
+			return e.Left
+		}
+	}
+	return e
+}
+
+// isInt reports whether expr is the int literal n. A float identity
+// would turn an int operand into a float.
+func isInt(expr ast.Expr, n int64) bool {
+	number, ok := expr.(ast.NumberExpr)
+	return ok && !number.Float && number.Int == n
+}
+
+func unwrapExport(stmt ast.Stmt) ast.Stmt {
+	if export, ok := stmt.(ast.ExportStmt); ok {
+		return export.Declaration
+	}
+	return stmt
+}
@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/RyanOliveira00/go-compiler/src/ast"
 	"github.com/RyanOliveira00/go-compiler/src/lexer"
@@ -32,19 +33,43 @@ func parser_expr(p *parser, bp binding_power) ast.Expr {
 }
 
 func parser_primary_expr(p *parser) ast.Expr {
+	start := p.currentToken().Span.Start
+
 	switch p.currentTokenKind() {
+	case lexer.NULL:
+		p.advance()
+		return ast.NullExpr{
+			Node: p.nodeFrom(start),
+		}
 	case lexer.NUMBER:
-		number, _ := strconv.ParseFloat(p.advance().Value, 64)
-		return ast.NumberExpr{
-			Value: number,
+		text := p.advance().Value
+		// 2.0 is a float, though its value is whole
+		if strings.Contains(text, ".") {
+			number, _ := strconv.ParseFloat(text, 64)
+			return ast.NumberExpr{Node: p.nodeFrom(start), Value: number, Float: true}
+		}
+		// A literal is never negative, -x negates it
+		number, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			panic(fmt.Sprintf("Integer literal %s overflows int\n", text))
 		}
+		return ast.NumberExpr{Node: p.nodeFrom(start), Int: number}
 	case lexer.STRING:
+		value := p.advance().Value
 		return ast.StringExpr{
-			Value: p.advance().Value,
+			Node:  p.nodeFrom(start),
+			Value: value,
 		}
 	case lexer.IDENTIFIER:
+		// Point { x: ... } is a struct literal, anything else after the
+		// name (e.g. the body of a foreach) is left for the caller
+		if p.peekKind(1) == lexer.OPEN_CURLY && p.peekKind(2) == lexer.IDENTIFIER && p.peekKind(3) == lexer.COLON {
+			return parser_struct_literal_expr(p)
+		}
+		value := p.advance().Value
 		return ast.SymbolExpr{
-			Value: p.advance().Value,
+			Node:  p.nodeFrom(start),
+			Value: value,
 		}
 	default:
 		panic(fmt.Sprintf("Could not parse primary expression: %s\n", lexer.TokenKindString(p.currentTokenKind())))
@@ -55,26 +80,66 @@ func parser_binary_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
 	operator := p.advance()
 	right := parser_expr(p, bp)
 	return ast.BinaryExpr{
+		Node:     p.nodeFrom(left.Span().Start),
 		Left:     left,
 		Operator: operator,
 		Right:    right,
 	}
 }
 
+// step is only a keyword right after the end of a range, so it remains
+// usable as a name everywhere else
+func parser_range_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
+	operator := p.advance()
+	end := parser_expr(p, bp)
+
+	var step ast.Expr
+	if p.currentTokenKind() == lexer.IDENTIFIER && p.currentToken().Value == "step" {
+		p.advance()
+		step = parser_expr(p, bp)
+	}
+
+	return ast.RangeExpr{
+		Node:      p.nodeFrom(left.Span().Start),
+		Start:     left,
+		End:       end,
+		Step:      step,
+		Inclusive: operator.Kind == lexer.DOT_DOT_EQUALS,
+	}
+}
+
 func parser_prefix_expr(p *parser) ast.Expr {
+	start := p.currentToken().Span.Start
 	operator := p.advance()
-	rhs := parser_expr(p, default_bp)
+	rhs := parser_expr(p, unary)
 
 	return ast.PrefixExpr{
+		Node:      p.nodeFrom(start),
 		Operator:  operator,
 		RightExpr: rhs,
 	}
 }
 
+// Right associative: a ? b : c ? d : e parses as a ? b : (c ? d : e)
+func parser_ternary_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
+	p.advance() // Consume the question mark
+	consequent := parser_expr(p, default_bp)
+	p.expect(lexer.COLON)
+	alternate := parser_expr(p, bp-1)
+
+	return ast.TernaryExpr{
+		Node:       p.nodeFrom(left.Span().Start),
+		Condition:  left,
+		Consequent: consequent,
+		Alternate:  alternate,
+	}
+}
+
 func parser_assigment_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
 	operator := p.advance()
 	rhs := parser_expr(p, bp)
 	return ast.AssignmentExpr{
+		Node:     p.nodeFrom(left.Span().Start),
 		Assigne:  left,
 		Operator: operator,
 		Value:    rhs,
@@ -82,8 +147,211 @@ func parser_assigment_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr
 }
 
 func parser_grouping_expr(p *parser) ast.Expr {
+	// () or (name: starts the parameters of an arrow function
+	if p.peekKind(1) == lexer.CLOSE_PAREN || (p.peekKind(1) == lexer.IDENTIFIER && p.peekKind(2) == lexer.COLON) {
+		return parser_arrow_function_expr(p)
+	}
+
 	p.advance() // Consume the open parenthesis
 	expr := parser_expr(p, default_bp)
 	p.expect(lexer.CLOSE_PAREN)
 	return expr
 }
+
+func parser_map_literal_expr(p *parser) ast.Expr {
+	start := p.currentToken().Span.Start
+	p.expect(lexer.OPEN_CURLY)
+	entries := []ast.MapEntry{}
+
+	for p.currentTokenKind() != lexer.CLOSE_CURLY {
+		key := parser_expr(p, conditional)
+		p.expect(lexer.COLON)
+		value := parser_expr(p, default_bp)
+
+		entries = append(entries, ast.MapEntry{
+			Key:   key,
+			Value: value,
+		})
+
+		if p.currentTokenKind() != lexer.CLOSE_CURLY {
+			p.expect(lexer.COMMA)
+		}
+	}
+
+	p.expect(lexer.CLOSE_CURLY)
+
+	return ast.MapLiteralExpr{
+		Node:    p.nodeFrom(start),
+		Entries: entries,
+	}
+}
+
+func parser_index_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
+	p.advance() // Consume the open bracket
+	index := parser_expr(p, default_bp)
+	p.expect(lexer.CLOSE_BRACKET)
+
+	return ast.IndexExpr{
+		Node:   p.nodeFrom(left.Span().Start),
+		Target: left,
+		Index:  index,
+	}
+}
+
+func parser_call_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
+	arguments := parser_arguments(p)
+
+	return ast.CallExpr{
+		Node:      p.nodeFrom(left.Span().Start),
+		Callee:    left,
+		Arguments: arguments,
+	}
+}
+
+func parser_new_expr(p *parser) ast.Expr {
+	start := p.advance().Span.Start // Consume the new keyword
+	className := p.expect(lexer.IDENTIFIER).Value
+
+	var typeArgs []ast.Type
+	if p.currentTokenKind() == lexer.LESS {
+		typeArgs = parse_type_args(p)
+	}
+	arguments := parser_arguments(p)
+
+	return ast.NewExpr{
+		Node:      p.nodeFrom(start),
+		ClassName: className,
+		TypeArgs:  typeArgs,
+		Arguments: arguments,
+	}
+}
+
+// (a, b, c)
+func parser_arguments(p *parser) []ast.Expr {
+	p.expect(lexer.OPEN_PAREN)
+	arguments := []ast.Expr{}
+
+	for p.currentTokenKind() != lexer.CLOSE_PAREN {
+		arguments = append(arguments, parser_expr(p, default_bp))
+
+		if p.currentTokenKind() != lexer.CLOSE_PAREN {
+			p.expect(lexer.COMMA)
+		}
+	}
+
+	p.expect(lexer.CLOSE_PAREN)
+	return arguments
+}
+
+func parser_struct_literal_expr(p *parser) ast.Expr {
+	typeToken := p.expect(lexer.IDENTIFIER)
+	typeName := typeToken.Value
+	p.expect(lexer.OPEN_CURLY)
+	fields := []ast.StructLiteralField{}
+
+	for p.currentTokenKind() != lexer.CLOSE_CURLY {
+		name := p.expect(lexer.IDENTIFIER).Value
+		p.expect(lexer.COLON)
+		value := parser_expr(p, default_bp)
+
+		fields = append(fields, ast.StructLiteralField{
+			Name:  name,
+			Value: value,
+		})
+
+		if p.currentTokenKind() != lexer.CLOSE_CURLY {
+			p.expect(lexer.COMMA)
+		}
+	}
+
+	p.expect(lexer.CLOSE_CURLY)
+
+	return ast.StructLiteralExpr{
+		Node:     p.nodeFrom(typeToken.Span.Start),
+		TypeName: typeName,
+		Fields:   fields,
+	}
+}
+
+func parser_member_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
+	optional := p.advance().Kind == lexer.QUESTION_DOT
+	property := p.expectName()
+
+	return ast.MemberExpr{
+		Node:     p.nodeFrom(left.Span().Start),
+		Object:   left,
+		Property: property,
+		Optional: optional,
+	}
+}
+
+func parser_array_literal_expr(p *parser) ast.Expr {
+	start := p.currentToken().Span.Start
+	p.expect(lexer.OPEN_BRACKET)
+	elements := []ast.Expr{}
+
+	for p.currentTokenKind() != lexer.CLOSE_BRACKET {
+		elements = append(elements, parser_expr(p, default_bp))
+
+		if p.currentTokenKind() != lexer.CLOSE_BRACKET {
+			p.expect(lexer.COMMA)
+		}
+	}
+
+	p.expect(lexer.CLOSE_BRACKET)
+
+	return ast.ArrayLiteralExpr{
+		Node:     p.nodeFrom(start),
+		Elements: elements,
+	}
+}
+
+// fn (x: int): int { ... }
+func parser_function_expr(p *parser) ast.Expr {
+	start := p.advance().Span.Start // Consume the fn keyword
+	parameters := parser_parameters(p)
+
+	var returnType ast.Type
+	if p.currentTokenKind() == lexer.COLON {
+		p.advance()
+		returnType = parser_type(p, default_bp)
+	}
+
+	body := parser_block_stmt(p)
+
+	return ast.FunctionExpr{
+		Node:       p.nodeFrom(start),
+		Parameters: parameters,
+		ReturnType: returnType,
+		Body:       body,
+	}
+}
+
+// (x: int) => x * 2, (x: int): int => { ... }
+func parser_arrow_function_expr(p *parser) ast.Expr {
+	start := p.currentToken().Span.Start
+	parameters := parser_parameters(p)
+
+	var returnType ast.Type
+	if p.currentTokenKind() == lexer.COLON {
+		p.advance()
+		returnType = parser_type(p, default_bp)
+	}
+	p.expect(lexer.ARROW)
+
+	var body ast.BlockStmt
+	if p.currentTokenKind() == lexer.OPEN_CURLY {
+		body = parser_block_stmt(p)
+	} else {
+		body = ast.BlockStmt{
+			Body: []ast.Stmt{ast.ReturnStmt{Value: parser_expr(p, assignment)}},
+		}
+	}
+
+	return ast.FunctionExpr{
+		Node:       p.nodeFrom(start),
+		Parameters: parameters,
+		ReturnType: returnType,
+		Body:       body,
+	}
+}
@@ -11,8 +11,10 @@ const (
 	default_bp binding_power = iota
 	comma
 	assignment
+	conditional
 	logical
 	relational
+	interval
 	additive
 	multiplicative
 	unary
@@ -58,10 +60,15 @@ func createTokenLookups() {
 	led(lexer.MINUS_EQUALS, assignment, parser_assigment_expr)
 	// TODO add *= /= &=
 
+	// Conditional
+	led(lexer.QUESTION, conditional, parser_ternary_expr)
+
+	led(lexer.NULLISH_ASSIGNMENT, assignment, parser_assigment_expr)
+
 	// Logical
 	led(lexer.AND, logical, parser_binary_expr)
 	led(lexer.OR, logical, parser_binary_expr)
-	led(lexer.DOT_DOT, logical, parser_binary_expr) // Range Operator (..)
+	led(lexer.NULLISH, logical, parser_binary_expr)
 
 	// Relational
 	led(lexer.LESS, relational, parser_binary_expr)
@@ -70,6 +77,11 @@ func createTokenLookups() {
 	led(lexer.GREATER_EQUALS, relational, parser_binary_expr)
 	led(lexer.EQUALS, relational, parser_binary_expr)
 	led(lexer.NOT_EQUALS, relational, parser_binary_expr)
+	led(lexer.IN, relational, parser_binary_expr)
+
+	// Range
+	led(lexer.DOT_DOT, interval, parser_range_expr)
+	led(lexer.DOT_DOT_EQUALS, interval, parser_range_expr)
 
 	// Additive & Multiplicative
 	led(lexer.PLUS, additive, parser_binary_expr)
@@ -79,18 +91,45 @@ func createTokenLookups() {
 	led(lexer.SLASH, multiplicative, parser_binary_expr)
 	led(lexer.PERCENT, multiplicative, parser_binary_expr)
 
+	// Call & Member
+	led(lexer.OPEN_PAREN, call, parser_call_expr)
+	led(lexer.OPEN_BRACKET, member, parser_index_expr)
+	led(lexer.DOT, member, parser_member_expr)
+	led(lexer.QUESTION_DOT, member, parser_member_expr)
+
 	// Literals & Symbols
+	nud(lexer.NULL, parser_primary_expr)
 	nud(lexer.NUMBER, parser_primary_expr)
 	nud(lexer.STRING, parser_primary_expr)
 	nud(lexer.IDENTIFIER, parser_primary_expr)
 	nud(lexer.OPEN_PAREN, parser_grouping_expr)
+	nud(lexer.MATCH, parser_match_expr)
+	nud(lexer.FN, parser_function_expr)
+	nud(lexer.OPEN_CURLY, parser_map_literal_expr)
+	nud(lexer.OPEN_BRACKET, parser_array_literal_expr)
+	nud(lexer.NEW, parser_new_expr)
 	nud(lexer.DASH, parser_prefix_expr)
+	nud(lexer.PLUS, parser_prefix_expr)
+	nud(lexer.NOT, parser_prefix_expr)
+	nud(lexer.TYPEOF, parser_prefix_expr)
 
 	// Statements
 	stmt(lexer.CONST, parser_var_decl_stmt)
 	stmt(lexer.LET, parser_var_decl_stmt)
 	stmt(lexer.IF, parser_if_stmt)
 	stmt(lexer.WHILE, parser_while_stmt)
+	stmt(lexer.FOREACH, parser_foreach_stmt)
+	stmt(lexer.TYPE, parser_type_decl_stmt)
+	stmt(lexer.FN, parser_function_stmt)
+	stmt(lexer.RETURN, parser_return_stmt)
+	stmt(lexer.CLASS, parser_class_decl_stmt)
+	stmt(lexer.INTERFACE, parser_interface_decl_stmt)
+	stmt(lexer.ENUM, parser_enum_decl_stmt)
+	stmt(lexer.MATCH, parser_match_stmt)
+	stmt(lexer.IMPORT, parser_import_stmt)
+	stmt(lexer.EXPORT, parser_export_stmt)
 	stmt(lexer.PRINT, parser_print_stmt)
 	stmt(lexer.READ, parser_read_stmt)
+	stmt(lexer.THROW, parser_throw_stmt)
+	stmt(lexer.TRY, parser_try_stmt)
 }
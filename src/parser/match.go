@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/RyanOliveira00/go-compiler/src/ast"
+	"github.com/RyanOliveira00/go-compiler/src/lexer"
+)
+
+func parser_match_stmt(p *parser) ast.Stmt {
+	subject, arms := parser_match(p, true)
+
+	// Like if, a match statement may be followed by a semicolon
+	if p.currentTokenKind() == lexer.SEMI_COLON {
+		p.advance()
+	}
+
+	return ast.MatchStmt{
+		Subject: subject,
+		Arms:    arms,
+	}
+}
+
+func parser_match_expr(p *parser) ast.Expr {
+	start := p.currentToken().Span.Start
+	subject, arms := parser_match(p, false)
+
+	return ast.MatchExpr{
+		Node:    p.nodeFrom(start),
+		Subject: subject,
+		Arms:    arms,
+	}
+}
+
+// match (subject) { pattern | pattern => body, ... }. Arms of a statement
+// may have a block as body, the comma after a block is optional.
+func parser_match(p *parser, statement bool) (ast.Expr, []ast.MatchArm) {
+	p.advance()
+	p.expect(lexer.OPEN_PAREN)
+	subject := parser_expr(p, default_bp)
+	p.expect(lexer.CLOSE_PAREN)
+	p.expect(lexer.OPEN_CURLY)
+
+	arms := []ast.MatchArm{}
+	for p.currentTokenKind() != lexer.CLOSE_CURLY {
+		arm := ast.MatchArm{Patterns: []ast.Pattern{parser_pattern(p)}}
+		for p.currentTokenKind() == lexer.PIPE {
+			p.advance()
+			arm.Patterns = append(arm.Patterns, parser_pattern(p))
+		}
+		p.expect(lexer.ARROW)
+
+		if statement && p.currentTokenKind() == lexer.OPEN_CURLY {
+			body := parser_block_stmt(p)
+			arm.Body = &body
+			if p.currentTokenKind() == lexer.COMMA {
+				p.advance()
+			}
+		} else {
+			arm.Value = parser_expr(p, default_bp)
+			if p.currentTokenKind() != lexer.CLOSE_CURLY {
+				p.expect(lexer.COMMA)
+			}
+		}
+		arms = append(arms, arm)
+	}
+	p.expect(lexer.CLOSE_CURLY)
+
+	return subject, arms
+}
+
+// Patterns are parsed as expressions and then reinterpreted, so 1..10
+// and Shape.Circle(r) read exactly like the values they match.
+func parser_pattern(p *parser) ast.Pattern {
+	expr := parser_expr(p, logical)
+
+	switch e := expr.(type) {
+	case ast.SymbolExpr:
+		if e.Value == "_" {
+			return ast.WildcardPattern{}
+		}
+		return ast.BindingPattern{Name: e.Value}
+	case ast.NumberExpr, ast.StringExpr, ast.NullExpr:
+		return ast.LiteralPattern{Value: e}
+	case ast.PrefixExpr:
+		if _, ok := e.RightExpr.(ast.NumberExpr); ok && e.Operator.Kind == lexer.DASH {
+			return ast.LiteralPattern{Value: e}
+		}
+	case ast.RangeExpr:
+		if e.Step == nil {
+			return ast.RangePattern{Start: e.Start, End: e.End, Inclusive: e.Inclusive}
+		}
+	case ast.MemberExpr:
+		if enum, ok := e.Object.(ast.SymbolExpr); ok && !e.Optional {
+			return ast.VariantPattern{Enum: enum.Value, Variant: e.Property}
+		}
+	case ast.CallExpr:
+		member, ok := e.Callee.(ast.MemberExpr)
+		if !ok {
+			break
+		}
+		enum, ok := member.Object.(ast.SymbolExpr)
+		if !ok {
+			break
+		}
+		bindings := make([]string, 0, len(e.Arguments))
+		for _, argument := range e.Arguments {
+			binding, ok := argument.(ast.SymbolExpr)
+			if !ok {
+				panic(fmt.Sprintf("Expected a name to bind in pattern %s.%s\n", enum.Value, member.Property))
+			}
+			bindings = append(bindings, binding.Value)
+		}
+		return ast.VariantPattern{Enum: enum.Value, Variant: member.Property, Bindings: bindings}
+	}
+	panic(fmt.Sprintf("Invalid pattern in match: %T\n", expr))
+}
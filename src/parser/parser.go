@@ -43,12 +43,26 @@ func (p *parser) currentTokenKind() lexer.TokenKind {
 	return p.currentToken().Kind
 }
 
+// peekKind looks offset tokens ahead of the current one without consuming
+func (p *parser) peekKind(offset int) lexer.TokenKind {
+	if p.pos+offset >= len(p.tokens) {
+		return lexer.EOF
+	}
+	return p.tokens[p.pos+offset].Kind
+}
+
 func (p *parser) advance() lexer.Token {
 	tk := p.currentToken()
 	p.pos++
 	return tk
 }
 
+// nodeFrom spans the source from start to the end of the last consumed
+// token.
+func (p *parser) nodeFrom(start lexer.Position) ast.Node {
+	return ast.Node{Loc: lexer.Span{Start: start, End: p.tokens[p.pos-1].Span.End}}
+}
+
 func (p *parser) hasTokens() bool {
 	return p.pos < len(p.tokens) && p.currentTokenKind() != lexer.EOF
 }
@@ -58,7 +72,7 @@ func (p *parser) expectError(expectedKind lexer.TokenKind, err any) lexer.Token
 	kind := token.Kind
 
 	if kind != expectedKind {
-		if err != nil {
+		if err == nil {
 			err = fmt.Sprintf("Expected token %s, got %s\n", lexer.TokenKindString(expectedKind), lexer.TokenKindString(kind))
 		}
 
@@ -71,3 +85,13 @@ func (p *parser) expectError(expectedKind lexer.TokenKind, err any) lexer.Token
 func (p *parser) expect(expectedKind lexer.TokenKind) lexer.Token {
 	return p.expectError(expectedKind, nil)
 }
+
+// expectName consumes a member name. Reserved words are valid names after
+// a dot, so a class can have a method called read.
+func (p *parser) expectName() string {
+	if token := p.currentToken(); lexer.IsKeyword(token.Value) {
+		p.advance()
+		return token.Value
+	}
+	return p.expect(lexer.IDENTIFIER).Value
+}
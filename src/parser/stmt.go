@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"fmt"
+
 	"github.com/RyanOliveira00/go-compiler/src/ast"
 	"github.com/RyanOliveira00/go-compiler/src/lexer"
 )
@@ -21,12 +23,12 @@ func parser_stmt(p *parser) ast.Stmt {
 	}
 }
 
+// The parentheses around the condition of if and while are optional, as
+// (x) is just x, and so is the semicolon after them.
 func parser_if_stmt(p *parser) ast.Stmt {
 	p.advance()
 
-	p.expect(lexer.OPEN_PAREN)
 	condition := parser_expr(p, default_bp)
-	p.expect(lexer.CLOSE_PAREN)
 
 	p.expect(lexer.OPEN_CURLY)
 	var consequenceStmts []ast.Stmt
@@ -51,7 +53,9 @@ func parser_if_stmt(p *parser) ast.Stmt {
 		alternative = &alt
 	}
 
-	p.expect(lexer.SEMI_COLON)
+	if p.currentTokenKind() == lexer.SEMI_COLON {
+		p.advance()
+	}
 
 	return ast.IfStmt{
 		Condition:   condition,
@@ -81,11 +85,12 @@ func parser_block_stmt(p *parser) ast.BlockStmt {
 
 func parser_while_stmt(p *parser) ast.Stmt {
 	p.advance()
-	p.expect(lexer.OPEN_PAREN)
 	condition := parser_expr(p, default_bp)
-	p.expect(lexer.CLOSE_PAREN)
 
 	body := parser_block_stmt(p)
+	if p.currentTokenKind() == lexer.SEMI_COLON {
+		p.advance()
+	}
 
 	return ast.WhileStmt{
 		Condition: condition,
@@ -93,6 +98,106 @@ func parser_while_stmt(p *parser) ast.Stmt {
 	}
 }
 
+func parser_foreach_stmt(p *parser) ast.Stmt {
+	p.advance()
+	keyName := p.expect(lexer.IDENTIFIER).Value
+
+	var valueName string
+	if p.currentTokenKind() == lexer.COMMA {
+		p.advance()
+		valueName = p.expect(lexer.IDENTIFIER).Value
+	}
+
+	p.expect(lexer.IN)
+	iterable := parser_expr(p, default_bp)
+	body := parser_block_stmt(p)
+
+	return ast.ForeachStmt{
+		KeyName:   keyName,
+		ValueName: valueName,
+		Iterable:  iterable,
+		Body:      body,
+	}
+}
+
+func parser_type_decl_stmt(p *parser) ast.Stmt {
+	p.advance()
+	name := p.expect(lexer.IDENTIFIER).Value
+	p.expect(lexer.ASSIGNMENT)
+	underlying := parser_type(p, default_bp)
+	p.expect(lexer.SEMI_COLON)
+
+	return ast.TypeDeclStmt{
+		Name: name,
+		Type: underlying,
+	}
+}
+
+// interface Reader { fn read(): string; fn close() }, the separators
+// between methods are optional
+func parser_interface_decl_stmt(p *parser) ast.Stmt {
+	p.advance()
+	name := p.expect(lexer.IDENTIFIER).Value
+	p.expect(lexer.OPEN_CURLY)
+
+	methods := []ast.MethodSignature{}
+	for p.currentTokenKind() != lexer.CLOSE_CURLY {
+		p.expectError(lexer.FN, fmt.Sprintf("Expected method in interface %s", name))
+		method := ast.MethodSignature{
+			Name:       p.expectName(),
+			Parameters: parser_parameters(p),
+		}
+		if p.currentTokenKind() == lexer.COLON {
+			p.advance()
+			method.ReturnType = parser_type(p, default_bp)
+		}
+		methods = append(methods, method)
+
+		if p.currentTokenKind() == lexer.SEMI_COLON || p.currentTokenKind() == lexer.COMMA {
+			p.advance()
+		}
+	}
+	p.expect(lexer.CLOSE_CURLY)
+
+	return ast.TypeDeclStmt{
+		Name: name,
+		Type: ast.InterfaceType{Methods: methods},
+	}
+}
+
+// enum Shape { Circle(float), Rect(float, float), Empty }
+func parser_enum_decl_stmt(p *parser) ast.Stmt {
+	p.advance()
+	name := p.expect(lexer.IDENTIFIER).Value
+	p.expect(lexer.OPEN_CURLY)
+
+	variants := []ast.EnumVariant{}
+	for p.currentTokenKind() != lexer.CLOSE_CURLY {
+		variant := ast.EnumVariant{Name: p.expect(lexer.IDENTIFIER).Value}
+		if p.currentTokenKind() == lexer.OPEN_PAREN {
+			p.advance()
+			for p.currentTokenKind() != lexer.CLOSE_PAREN {
+				variant.Payload = append(variant.Payload, parser_type(p, default_bp))
+				if p.currentTokenKind() != lexer.CLOSE_PAREN {
+					p.expect(lexer.COMMA)
+				}
+			}
+			p.expect(lexer.CLOSE_PAREN)
+		}
+		variants = append(variants, variant)
+
+		if p.currentTokenKind() != lexer.CLOSE_CURLY {
+			p.expect(lexer.COMMA)
+		}
+	}
+	p.expect(lexer.CLOSE_CURLY)
+
+	return ast.EnumDeclStmt{
+		Name:     name,
+		Variants: variants,
+	}
+}
+
 func parser_print_stmt(p *parser) ast.Stmt {
 	p.advance()
 	p.expect(lexer.OPEN_PAREN)
@@ -118,24 +223,24 @@ func parser_read_stmt(p *parser) ast.Stmt {
 }
 
 func parser_function_stmt(p *parser) ast.Stmt {
-	p.advance()
-	name := p.expect(lexer.IDENTIFIER).Value
-
-	p.expect(lexer.OPEN_PAREN)
-	parameters := []string{}
-
-	if p.currentTokenKind() != lexer.CLOSE_PAREN {
-		for {
-			param := p.expect(lexer.IDENTIFIER).Value
-			parameters = append(parameters, param)
-
-			if p.currentTokenKind() != lexer.COMMA {
-				break
-			}
-			p.advance()
+	// fn (...) without a name starts an anonymous function expression
+	if p.peekKind(1) == lexer.OPEN_PAREN {
+		expression := parser_expr(p, default_bp)
+		p.expect(lexer.SEMI_COLON)
+		return ast.ExprStmt{
+			Expression: expression,
 		}
 	}
-	p.expect(lexer.CLOSE_PAREN)
+
+	p.advance()
+	return parser_function_decl(p, p.expect(lexer.IDENTIFIER).Value)
+}
+
+// parser_function_decl parses what follows fn name: type parameters,
+// parameters, return type and body.
+func parser_function_decl(p *parser, name string) ast.FunctionDeclStmt {
+	typeParams := parser_type_params(p)
+	parameters := parser_parameters(p)
 
 	var returnType ast.Type
 	if p.currentTokenKind() == lexer.COLON {
@@ -144,15 +249,74 @@ func parser_function_stmt(p *parser) ast.Stmt {
 	}
 
 	body := parser_block_stmt(p)
+	// A function returning a value may end with the expression it returns
+	if last := len(body.Body) - 1; returnType != nil && last >= 0 {
+		if final, ok := body.Body[last].(ast.ExprStmt); ok {
+			body.Body[last] = ast.ReturnStmt{Value: final.Expression}
+		}
+	}
 
 	return ast.FunctionDeclStmt{
 		Name:       name,
+		TypeParams: typeParams,
 		Parameters: parameters,
 		ReturnType: returnType,
 		Body:       body,
 	}
 }
 
+// <T, U: int | float>, absent on functions and classes that are not generic
+func parser_type_params(p *parser) []ast.TypeParam {
+	if p.currentTokenKind() != lexer.LESS {
+		return nil
+	}
+	p.advance()
+	params := []ast.TypeParam{}
+
+	for {
+		param := ast.TypeParam{Name: p.expect(lexer.IDENTIFIER).Value}
+		if p.currentTokenKind() == lexer.COLON {
+			p.advance()
+			param.Constraint = parser_type(p, default_bp)
+		}
+		params = append(params, param)
+
+		if p.currentTokenKind() != lexer.COMMA {
+			break
+		}
+		p.advance()
+	}
+	p.expect(lexer.GREATER)
+
+	return params
+}
+
+// (a: int, b: string)
+func parser_parameters(p *parser) []ast.Parameter {
+	p.expect(lexer.OPEN_PAREN)
+	parameters := []ast.Parameter{}
+
+	if p.currentTokenKind() != lexer.CLOSE_PAREN {
+		for {
+			param := p.expect(lexer.IDENTIFIER).Value
+			p.expectError(lexer.COLON, fmt.Sprintf("Expected type for parameter %s", param))
+			paramType := parser_type(p, default_bp)
+			parameters = append(parameters, ast.Parameter{
+				Name: param,
+				Type: paramType,
+			})
+
+			if p.currentTokenKind() != lexer.COMMA {
+				break
+			}
+			p.advance()
+		}
+	}
+	p.expect(lexer.CLOSE_PAREN)
+
+	return parameters
+}
+
 func parser_return_stmt(p *parser) ast.Stmt {
 	p.advance()
 
@@ -167,6 +331,44 @@ func parser_return_stmt(p *parser) ast.Stmt {
 	}
 }
 
+func parser_throw_stmt(p *parser) ast.Stmt {
+	p.advance()
+	value := parser_expr(p, default_bp)
+	p.expect(lexer.SEMI_COLON)
+
+	return ast.ThrowStmt{
+		Value: value,
+	}
+}
+
+func parser_try_stmt(p *parser) ast.Stmt {
+	p.advance()
+	stmt := ast.TryStmt{
+		Body: parser_block_stmt(p),
+	}
+
+	if p.currentTokenKind() == lexer.CATCH {
+		p.advance()
+		p.expect(lexer.OPEN_PAREN)
+		stmt.CatchName = p.expectError(lexer.IDENTIFIER, "Expected name of the caught error").Value
+		p.expect(lexer.CLOSE_PAREN)
+		catch := parser_block_stmt(p)
+		stmt.Catch = &catch
+	}
+
+	if p.currentTokenKind() == lexer.FINALLY {
+		p.advance()
+		finally := parser_block_stmt(p)
+		stmt.Finally = &finally
+	}
+
+	if stmt.Catch == nil && stmt.Finally == nil {
+		panic("Expected catch or finally after try block")
+	}
+
+	return stmt
+}
+
 func parser_var_decl_stmt(p *parser) ast.Stmt {
 	var explicitType ast.Type
 	var assignedValue ast.Expr
@@ -198,3 +400,84 @@ func parser_var_decl_stmt(p *parser) ast.Stmt {
 		AssignedValue: assignedValue,
 	}
 }
+
+func parser_class_decl_stmt(p *parser) ast.Stmt {
+	p.advance()
+	name := p.expect(lexer.IDENTIFIER).Value
+	typeParams := parser_type_params(p)
+
+	var implements []string
+	if p.currentTokenKind() == lexer.IMPLEMENTS {
+		p.advance()
+		for {
+			implements = append(implements, p.expect(lexer.IDENTIFIER).Value)
+			if p.currentTokenKind() != lexer.COMMA {
+				break
+			}
+			p.advance()
+		}
+	}
+	p.expect(lexer.OPEN_CURLY)
+
+	fields := []ast.VarDeclStmt{}
+	methods := []ast.FunctionDeclStmt{}
+
+	for p.currentTokenKind() != lexer.CLOSE_CURLY {
+		switch p.currentTokenKind() {
+		case lexer.LET, lexer.CONST:
+			fields = append(fields, parser_var_decl_stmt(p).(ast.VarDeclStmt))
+		case lexer.FN:
+			p.advance()
+			methods = append(methods, parser_function_decl(p, p.expectName()))
+		default:
+			panic(fmt.Sprintf("Expected field or method in class %s, got %s\n", name, lexer.TokenKindString(p.currentTokenKind())))
+		}
+	}
+
+	p.expect(lexer.CLOSE_CURLY)
+
+	return ast.ClassDeclStmt{
+		Name:       name,
+		TypeParams: typeParams,
+		Implements: implements,
+		Fields:     fields,
+		Methods:    methods,
+	}
+}
+
+func parser_import_stmt(p *parser) ast.Stmt {
+	p.advance()
+	p.expect(lexer.OPEN_CURLY)
+	names := []string{}
+
+	for p.currentTokenKind() != lexer.CLOSE_CURLY {
+		names = append(names, p.expect(lexer.IDENTIFIER).Value)
+
+		if p.currentTokenKind() != lexer.CLOSE_CURLY {
+			p.expect(lexer.COMMA)
+		}
+	}
+
+	p.expect(lexer.CLOSE_CURLY)
+	p.expect(lexer.FROM)
+	path := p.expect(lexer.STRING).Value
+	p.expect(lexer.SEMI_COLON)
+
+	return ast.ImportStmt{
+		Names: names,
+		Path:  path,
+	}
+}
+
+func parser_export_stmt(p *parser) ast.Stmt {
+	p.advance()
+
+	switch p.currentTokenKind() {
+	case lexer.LET, lexer.CONST, lexer.FN, lexer.CLASS, lexer.TYPE, lexer.INTERFACE, lexer.ENUM:
+		return ast.ExportStmt{
+			Declaration: parser_stmt(p),
+		}
+	default:
+		panic(fmt.Sprintf("Cannot export %s\n", lexer.TokenKindString(p.currentTokenKind())))
+	}
+}
@@ -30,11 +30,124 @@ func type_nud(kind lexer.TokenKind, nud_fn type_nud_handler) {
 func createTypeLookups() {
 	type_nud(lexer.IDENTIFIER, parse_symbol_type)
 	type_nud(lexer.OPEN_BRACKET, parse_array_type)
+	type_nud(lexer.OPEN_CURLY, parse_struct_type)
+	type_nud(lexer.FN, parse_function_type)
+	type_nud(lexer.OPEN_PAREN, parse_grouping_type)
+
+	type_led(lexer.LESS, call, parse_generic_type)
+	type_led(lexer.PIPE, logical, parse_union_type)
+	type_led(lexer.QUESTION, member, parse_optional_type)
+}
+
+// Box<int>, an application of a generic class
+func parse_generic_type(p *parser, left ast.Type, bp binding_power) ast.Type {
+	symbol, ok := left.(ast.SymbolType)
+	if !ok {
+		panic("Only named types can have type arguments\n")
+	}
+	return ast.GenericType{
+		Name: symbol.Name,
+		Args: parse_type_args(p),
+	}
+}
+
+// <int, string>
+func parse_type_args(p *parser) []ast.Type {
+	p.expect(lexer.LESS)
+	args := []ast.Type{}
+
+	for {
+		args = append(args, parser_type(p, default_bp))
+
+		if p.currentTokenKind() != lexer.COMMA {
+			break
+		}
+		p.advance()
+	}
+	p.expect(lexer.GREATER)
+
+	return args
+}
+
+// int | string | bool is a single union of three types
+func parse_union_type(p *parser, left ast.Type, bp binding_power) ast.Type {
+	p.advance()
+	right := parser_type(p, bp)
+
+	if union, ok := left.(ast.UnionType); ok {
+		return ast.UnionType{Types: append(union.Types, right)}
+	}
+	return ast.UnionType{Types: []ast.Type{left, right}}
+}
+
+// ([]int)? groups a type, since []int? is an array of optional ints
+func parse_grouping_type(p *parser) ast.Type {
+	p.advance()
+	t := parser_type(p, default_bp)
+	p.expect(lexer.CLOSE_PAREN)
+	return t
+}
+
+func parse_optional_type(p *parser, left ast.Type, bp binding_power) ast.Type {
+	p.advance()
+	return ast.OptionalType{
+		Underlying: left,
+	}
+}
+
+// fn(int, string): bool
+func parse_function_type(p *parser) ast.Type {
+	p.advance()
+	p.expect(lexer.OPEN_PAREN)
+	params := []ast.Type{}
+
+	for p.currentTokenKind() != lexer.CLOSE_PAREN {
+		params = append(params, parser_type(p, default_bp))
+
+		if p.currentTokenKind() != lexer.CLOSE_PAREN {
+			p.expect(lexer.COMMA)
+		}
+	}
+	p.expect(lexer.CLOSE_PAREN)
+
+	var returnType ast.Type
+	if p.currentTokenKind() == lexer.COLON {
+		p.advance()
+		returnType = parser_type(p, default_bp)
+	}
+
+	return ast.FunctionType{
+		Params: params,
+		Return: returnType,
+	}
 }
 
 func parse_symbol_type(p *parser) ast.Type {
+	name := p.expect(lexer.IDENTIFIER).Value
+
+	// map is not a reserved word, so map[K]V is recognised here
+	if name == "map" && p.currentTokenKind() == lexer.OPEN_BRACKET {
+		return parse_map_type(p)
+	}
+
+	// boolean is another name for bool
+	if name == "boolean" {
+		name = "bool"
+	}
+
 	return ast.SymbolType{
-		Name: p.expect(lexer.IDENTIFIER).Value,
+		Name: name,
+	}
+}
+
+func parse_map_type(p *parser) ast.Type {
+	p.expect(lexer.OPEN_BRACKET)
+	keyType := parser_type(p, default_bp)
+	p.expect(lexer.CLOSE_BRACKET)
+	valueType := parser_type(p, default_bp)
+	return ast.MapType{
+		Key:   keyType,
+		Value: valueType,
 	}
 }
 
@@ -47,6 +160,32 @@ func parse_array_type(p *parser) ast.Type {
 	}
 }
 
+func parse_struct_type(p *parser) ast.Type {
+	p.expect(lexer.OPEN_CURLY)
+	fields := []ast.StructField{}
+
+	for p.currentTokenKind() != lexer.CLOSE_CURLY {
+		name := p.expect(lexer.IDENTIFIER).Value
+		p.expect(lexer.COLON)
+		fieldType := parser_type(p, default_bp)
+
+		fields = append(fields, ast.StructField{
+			Name: name,
+			Type: fieldType,
+		})
+
+		if p.currentTokenKind() != lexer.CLOSE_CURLY {
+			p.expect(lexer.COMMA)
+		}
+	}
+
+	p.expect(lexer.CLOSE_CURLY)
+
+	return ast.StructType{
+		Fields: fields,
+	}
+}
+
 func parser_type(p *parser, bp binding_power) ast.Type {
 	tokenKind := p.currentTokenKind()
 	nud_fn, exists := type_nud_lu[tokenKind]
@@ -3,6 +3,8 @@ package repl
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 
@@ -13,9 +15,11 @@ import (
 
 const PROMPT = ">> "
 
-func Start(in io.Reader, out io.Writer) {
+// Start runs the REPL, giving the evaluated lines the capabilities caps.
+func Start(in io.Reader, out io.Writer, caps compiler.Capabilities) {
 	scanner := bufio.NewScanner(in)
 	comp := compiler.New()
+	comp.SetCapabilities(caps)
 
 	fmt.Fprintln(out, "Bem vindo ao compilador de Go!")
 
@@ -34,9 +38,16 @@ func Start(in io.Reader, out io.Writer) {
 		tokens := lexer.Tokenize(line)
 		ast := parser.Parse(tokens)
 
-		result, err := comp.Compile(ast)
+		result, err := comp.Compile(context.Background(), ast)
 		if err != nil {
-			fmt.Fprintf(out, "Error: %s\n", err)
+			var runtimeErr *compiler.RuntimeError
+			if errors.As(err, &runtimeErr) {
+				fmt.Fprintf(out, "Error: %s", runtimeErr.Report(func(path string) (string, bool) {
+					return line, path == ""
+				}))
+			} else {
+				fmt.Fprintf(out, "Error: %s\n", err)
+			}
 			continue
 		}
 